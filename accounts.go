@@ -0,0 +1,103 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// linkServices are the services an account can be linked on, with what the
+// account name is
+var linkServices = map[string]string{
+	"steam": "steam vanity name",
+	"osu":   "osu! username",
+}
+
+func linkServiceNames() []string {
+	names := make([]string, 0, len(linkServices))
+	for name := range linkServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withLinkedAccount runs handler with the account named in the command, or
+// with the account the caller linked on service when none is named
+func (app *TamakoBot) withLinkedAccount(service string, handler func(req *CommandRequest, account string) error) func(req *CommandRequest) error {
+	return func(req *CommandRequest) error {
+		if req.Text != "" {
+			return handler(req, req.Text)
+		}
+		ctx := req.Context()
+		usage := tr(ctx, "Usage : %s", req.Command.UsageLine(req.Prefix)) + "\n" +
+			tr(ctx, "or link your account with %s", req.Prefix+"link "+service+" <"+linkServices[service]+">")
+		if req.Source == nil || req.Source.UserID == "" {
+			return app.replyText(req.ReplyToken, usage)
+		}
+		account, err := app.store.LinkedAccount(req.Source.UserID, service)
+		if err != nil {
+			return err
+		}
+		if account == "" {
+			return app.replyText(req.ReplyToken, usage)
+		}
+		return handler(req, account)
+	}
+}
+
+func (app *TamakoBot) linkCommand(req *CommandRequest) error {
+	if req.Source == nil || req.Source.UserID == "" {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "I can't see your LINE account, add me as a friend first"))
+	}
+	if len(req.Args) == 0 {
+		user, err := app.store.User(req.Source.UserID)
+		if err != nil {
+			return err
+		}
+		if len(user.Links) == 0 {
+			return app.replyText(req.ReplyToken, tr(req.Context(), "No linked accounts")+"\n"+tr(req.Context(), "Usage : %s", req.Command.UsageLine(req.Prefix)))
+		}
+		lines := []string{tr(req.Context(), "Linked accounts :")}
+		for _, service := range linkServiceNames() {
+			if account, ok := user.Links[service]; ok {
+				lines = append(lines, service+" : "+account)
+			}
+		}
+		return app.replyText(req.ReplyToken, strings.Join(lines, "\n"))
+	}
+
+	service := strings.ToLower(req.Args[0])
+	if _, ok := linkServices[service]; !ok {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Unknown service %s, use one of %s", req.Args[0], strings.Join(linkServiceNames(), ", ")))
+	}
+	if len(req.Args) < 2 {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Usage : %s", req.Prefix+"link "+service+" <"+linkServices[service]+">"))
+	}
+	account := strings.Join(req.Args[1:], " ")
+	if err := app.store.Link(req.Source.UserID, service, account); err != nil {
+		return err
+	}
+	return app.replyText(req.ReplyToken, tr(req.Context(), "Linked your %s account %s", service, account))
+}
+
+func (app *TamakoBot) unlinkCommand(req *CommandRequest) error {
+	if req.Source == nil || req.Source.UserID == "" {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "I can't see your LINE account, add me as a friend first"))
+	}
+	var services []string
+	for _, arg := range req.Args {
+		service := strings.ToLower(arg)
+		if _, ok := linkServices[service]; !ok {
+			return app.replyText(req.ReplyToken, tr(req.Context(), "Unknown service %s, use one of %s", arg, strings.Join(linkServiceNames(), ", ")))
+		}
+		services = append(services, service)
+	}
+	removed, err := app.store.Unlink(req.Source.UserID, services...)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Nothing to unlink"))
+	}
+	return app.replyText(req.ReplyToken, tr(req.Context(), "Unlinked %s", strings.Join(removed, ", ")))
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// minAdminTokenLength keeps the admin token hard to guess
+const minAdminTokenLength = 16
+
+// maxAdminTextLength is the longest text message LINE accepts
+const maxAdminTextLength = 5000
+
+// defaultAuditLimit is the number of audit entries returned by default
+const defaultAuditLimit = 100
+
+// trackMembership records the groups and rooms the bot is in from the join
+// and leave events, and from any other event for chats joined before
+func (app *TamakoBot) trackMembership(ctx context.Context, event *linebot.Event) {
+	if event.Source == nil || event.Source.Type == linebot.EventSourceTypeUser {
+		return
+	}
+	chatID, chatType := sourceID(event.Source), string(event.Source.Type)
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	var err error
+	switch event.Type {
+	case linebot.EventTypeJoin:
+		err = app.store.JoinChat(chatID, chatType, at)
+	case linebot.EventTypeLeave:
+		err = app.store.LeaveChat(chatID, at)
+	default:
+		err = app.store.SeenChat(chatID, chatType, at)
+	}
+	if err != nil && err != store.ErrNotFound {
+		slog.ErrorContext(ctx, "Recording membership", "err", err)
+	}
+}
+
+// leaveChat makes the bot leave a group or room. LINE sends no leave event
+// when the bot leaves by itself, so the membership is updated here.
+func (app *TamakoBot) leaveChat(chatID, chatType string) error {
+	var err error
+	switch linebot.EventSourceType(chatType) {
+	case linebot.EventSourceTypeGroup:
+		err = app.bot.LeaveGroup(chatID)
+	case linebot.EventSourceTypeRoom:
+		err = app.bot.LeaveRoom(chatID)
+	default:
+		return errors.New("only groups and rooms can be left")
+	}
+	if err != nil {
+		return err
+	}
+	if err := app.store.LeaveChat(chatID, time.Now()); err != nil && err != store.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// adminError is an admin API failure with its HTTP status
+type adminError struct {
+	status  int
+	message string
+}
+
+func (e *adminError) Error() string {
+	return e.message
+}
+
+// adminAPI serves /admin/, every request needs the ADMIN_TOKEN as a bearer
+// token and is recorded in the audit log
+//
+//	GET  /admin/chats             groups and rooms the bot is in
+//	GET  /admin/chats/{id}        settings, stats and subscriptions of a chat
+//	POST /admin/chats/{id}/leave  make the bot leave a chat
+//	POST /admin/chats/{id}/push   push {"text": "..."} to a chat
+//	POST /admin/broadcast         push {"text": "..."} to every chat
+//	GET  /admin/audit?limit=N     the audit log, newest first
+func (app *TamakoBot) adminAPI(w http.ResponseWriter, r *http.Request) {
+	if app.config.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !app.adminAuthorized(r) {
+		app.auditUnauthorized(r)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin"), "/"), "/")
+	entry := &store.AuditEntry{Time: time.Now(), Remote: r.RemoteAddr}
+	var result interface{}
+	var err error
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "chats":
+		entry.Action = "list chats"
+		result, err = app.adminChats()
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "chats":
+		entry.Action, entry.Target = "show chat", parts[1]
+		result, err = app.adminChat(parts[1])
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "chats" && parts[2] == "leave":
+		entry.Action, entry.Target = "leave chat", parts[1]
+		result, err = app.adminLeave(parts[1])
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "chats" && parts[2] == "push":
+		entry.Action, entry.Target = "push", parts[1]
+		result, err = app.adminPush(r, parts[1], entry)
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "broadcast":
+		entry.Action = "broadcast"
+		result, err = app.adminBroadcast(r, entry)
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "audit":
+		entry.Action = "read audit log"
+		result, err = app.adminAudit(r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown admin endpoint " + r.Method + " " + r.URL.Path})
+		return
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if aerr := app.store.AddAudit(entry); aerr != nil {
+		slog.ErrorContext(r.Context(), "Audit log", "action", entry.Action, "err", aerr)
+	}
+	slog.InfoContext(r.Context(), "Admin action", "action", entry.Action, "target", entry.Target, "remote", entry.Remote, "err", entry.Error)
+
+	var status *adminError
+	switch {
+	case errors.As(err, &status):
+		writeJSON(w, status.status, map[string]string{"error": status.message})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// adminAuthorized reports whether the request carries the admin token as
+// "Authorization: Bearer <token>"
+func (app *TamakoBot) adminAuthorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") || app.config.AdminToken == "" {
+		return false
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(app.config.AdminToken)) == 1
+}
+
+// auditUnauthorized records a request refused by adminAuthorized in the
+// audit log
+func (app *TamakoBot) auditUnauthorized(r *http.Request) {
+	entry := &store.AuditEntry{
+		Time:   time.Now(),
+		Action: "unauthorized",
+		Detail: r.Method + " " + r.URL.Path,
+		Remote: r.RemoteAddr,
+		Error:  "wrong bearer token",
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		entry.Error = "no bearer token"
+	}
+	slog.WarnContext(r.Context(), "Admin token refused", "detail", entry.Detail, "remote", entry.Remote, "err", entry.Error)
+	if err := app.store.AddAudit(entry); err != nil {
+		slog.ErrorContext(r.Context(), "Audit log", "action", entry.Action, "err", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// activeChats returns the groups and rooms the bot is in
+func (app *TamakoBot) activeChats() ([]*store.Membership, error) {
+	memberships, err := app.store.Memberships()
+	if err != nil {
+		return nil, err
+	}
+	var active []*store.Membership
+	for _, m := range memberships {
+		if m.Active() {
+			active = append(active, m)
+		}
+	}
+	return active, nil
+}
+
+func (app *TamakoBot) adminChats() (interface{}, error) {
+	chats, err := app.activeChats()
+	if err != nil {
+		return nil, err
+	}
+	if chats == nil {
+		chats = []*store.Membership{}
+	}
+	return map[string]interface{}{"chats": chats}, nil
+}
+
+// membership returns the membership of a chat, as a 404 when unknown
+func (app *TamakoBot) membership(chatID string) (*store.Membership, error) {
+	m, err := app.store.Membership(chatID)
+	if err == store.ErrNotFound {
+		return nil, &adminError{http.StatusNotFound, "the bot doesn't know chat " + chatID}
+	}
+	return m, err
+}
+
+func (app *TamakoBot) adminChat(chatID string) (interface{}, error) {
+	m, err := app.membership(chatID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := app.store.Chat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := app.store.ChatStats(chatID)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := app.store.Subscriptions(chatID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"membership":    m,
+		"settings":      settings,
+		"stats":         stats,
+		"subscriptions": subs,
+	}, nil
+}
+
+func (app *TamakoBot) adminLeave(chatID string) (interface{}, error) {
+	m, err := app.membership(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Active() {
+		return nil, &adminError{http.StatusConflict, "the bot already left " + chatID}
+	}
+	if err := app.leaveChat(chatID, m.Type); err != nil {
+		return nil, err
+	}
+	return map[string]string{"left": chatID}, nil
+}
+
+// adminText reads the {"text": "..."} body of the push endpoints
+func adminText(r *http.Request) (string, error) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&body); err != nil {
+		return "", &adminError{http.StatusBadRequest, "body must be JSON like {\"text\": \"...\"}"}
+	}
+	body.Text = strings.TrimSpace(body.Text)
+	if body.Text == "" {
+		return "", &adminError{http.StatusBadRequest, "text is empty"}
+	}
+	if utf8.RuneCountInString(body.Text) > maxAdminTextLength {
+		return "", &adminError{http.StatusBadRequest, "text is longer than " + strconv.Itoa(maxAdminTextLength) + " characters"}
+	}
+	return body.Text, nil
+}
+
+func (app *TamakoBot) adminPush(r *http.Request, chatID string, entry *store.AuditEntry) (interface{}, error) {
+	text, err := adminText(r)
+	if err != nil {
+		return nil, err
+	}
+	entry.Detail = text
+	m, err := app.membership(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Active() {
+		return nil, &adminError{http.StatusConflict, "the bot left " + chatID}
+	}
+	if err := app.bot.PushMessage(chatID, linebot.NewTextMessage(text)); err != nil {
+		return nil, err
+	}
+	return map[string]string{"pushed": chatID}, nil
+}
+
+func (app *TamakoBot) adminBroadcast(r *http.Request, entry *store.AuditEntry) (interface{}, error) {
+	text, err := adminText(r)
+	if err != nil {
+		return nil, err
+	}
+	entry.Detail = text
+	chats, err := app.activeChats()
+	if err != nil {
+		return nil, err
+	}
+	sent := []string{}
+	failed := map[string]string{}
+	for _, m := range chats {
+		if err := app.bot.PushMessage(m.ChatID, linebot.NewTextMessage(text)); err != nil {
+			failed[m.ChatID] = err.Error()
+			continue
+		}
+		sent = append(sent, m.ChatID)
+	}
+	if len(failed) > 0 {
+		entry.Detail += " (" + strconv.Itoa(len(failed)) + " failed)"
+	}
+	return map[string]interface{}{"sent": sent, "failed": failed}, nil
+}
+
+func (app *TamakoBot) adminAudit(r *http.Request) (interface{}, error) {
+	limit := defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, &adminError{http.StatusBadRequest, "limit must be a positive number"}
+		}
+		limit = n
+	}
+	entries, err := app.store.AuditLog(limit)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*store.AuditEntry{}
+	}
+	return map[string]interface{}{"entries": entries}, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testAdminToken = "0123456789abcdef-admin"
+
+func TestAdminAuthorization(t *testing.T) {
+	app, _ := newTestBot(t, http.NotFoundHandler(), func(cfg *Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	tests := []struct {
+		authorization string
+		want          int
+	}{
+		{"", http.StatusUnauthorized},
+		{testAdminToken, http.StatusUnauthorized},
+		{"Bearer", http.StatusUnauthorized},
+		{"Bearer ", http.StatusUnauthorized},
+		{"Basic " + testAdminToken, http.StatusUnauthorized},
+		{"Bearer " + testAdminToken + "x", http.StatusUnauthorized},
+		{"Bearer " + testAdminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/admin/chats", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		app.adminAPI(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q: got %d, want %d", tt.authorization, rec.Code, tt.want)
+		}
+	}
+
+	entries, err := app.store.AuditLog(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := 0
+	for _, entry := range entries {
+		if entry.Action == "unauthorized" {
+			refused++
+			if entry.Detail != "GET /admin/chats" || entry.Error == "" {
+				t.Errorf("unexpected audit entry %+v", entry)
+			}
+		}
+	}
+	if refused != 6 {
+		t.Errorf("%d refused requests in the audit log, want 6", refused)
+	}
+	if len(entries) == 0 || entries[0].Action != "list chats" {
+		t.Errorf("the allowed request isn't the last audit entry: %+v", entries)
+	}
+}
+
+func TestAdminOffWithoutToken(t *testing.T) {
+	app, _ := newTestBot(t, http.NotFoundHandler(), nil)
+	req := httptest.NewRequest(http.MethodGet, "/admin/chats", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	app.adminAPI(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", rec.Code)
+	}
+}
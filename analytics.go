@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/metrics"
+	"github.com/afifmakarim/go-tamako/provider"
+	"github.com/afifmakarim/go-tamako/store"
+)
+
+// statsTopSize is the length of the top lists of !stats
+const statsTopSize = 5
+
+// botMetrics are the metrics served on /metrics
+type botMetrics struct {
+	registry        *metrics.Registry
+	commands        *metrics.Counter
+	commandLatency  *metrics.Histogram
+	upstream        *metrics.Counter
+	upstreamLatency *metrics.Histogram
+}
+
+func newBotMetrics() *botMetrics {
+	registry := metrics.NewRegistry()
+	return &botMetrics{
+		registry:        registry,
+		commands:        registry.Counter("tamako_commands_total", "Commands run, by command and outcome.", "command", "outcome"),
+		commandLatency:  registry.Histogram("tamako_command_duration_seconds", "Time spent running a command.", metrics.DefaultBuckets, "command"),
+		upstream:        registry.Counter("tamako_upstream_requests_total", "Requests to the upstream providers, by provider and outcome.", "provider", "outcome"),
+		upstreamLatency: registry.Histogram("tamako_upstream_request_duration_seconds", "Time spent on a request to an upstream provider.", metrics.DefaultBuckets, "provider"),
+	}
+}
+
+// metricsHandler serves the metrics to the holders of the admin token, it is
+// off like the admin API when there is none
+func (app *TamakoBot) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if app.config.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !app.adminAuthorized(r) {
+		app.auditUnauthorized(r)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	app.metrics.registry.ServeHTTP(w, r)
+}
+
+// observeUpstream is the provider.Client hook counting the upstream requests
+func (m *botMetrics) observeUpstream(name string, elapsed time.Duration, err *provider.Error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = err.Kind.String()
+	}
+	m.upstream.Inc(name, outcome)
+	m.upstreamLatency.Observe(elapsed.Seconds(), name)
+}
+
+// commandOutcome names the result of a command handler: ok, the kind of
+// provider failure, or error
+func commandOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if perr, ok := provider.AsError(err); ok {
+		return "provider_" + perr.Kind.String()
+	}
+	return "error"
+}
+
+// recordCommand counts a command run in the metrics and, when it was typed in
+// a chat, in the stats of the chat
+func (app *TamakoBot) recordCommand(cmd *Command, req *CommandRequest, elapsed time.Duration, outcome string) {
+	app.metrics.commands.Inc(cmd.Name, outcome)
+	app.metrics.commandLatency.Observe(elapsed.Seconds(), cmd.Name)
+
+	chatID := sourceID(req.Source)
+	if chatID == "" {
+		return
+	}
+	use := store.CommandUse{
+		ChatID:  chatID,
+		Command: cmd.Name,
+		Latency: elapsed,
+		Time:    time.Now(),
+	}
+	if outcome != "ok" {
+		use.Error = outcome
+	}
+	// scheduled runs count for the command but not for whoever subscribed
+	if req.Message != nil && req.Source != nil {
+		use.UserID = req.Source.UserID
+	}
+	if err := app.store.RecordCommand(use); err != nil {
+		slog.ErrorContext(req.Context(), "Recording stats", "err", err)
+	}
+}
+
+func (app *TamakoBot) statsCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	stats, err := app.store.ChatStats(sourceID(req.Source))
+	if err != nil {
+		return err
+	}
+	if len(stats.Commands) == 0 {
+		return app.replyText(req.ReplyToken, tr(ctx, "No commands used here yet"))
+	}
+
+	commands := flex.VBox(&flex.Text{Text: tr(ctx, "Top commands"), Size: "md", Color: "#555555", Weight: "bold"})
+	commands.Spacing = "sm"
+	commands.Margin = "xxl"
+	for _, ranked := range stats.TopCommands(statsTopSize) {
+		cmd := stats.Commands[ranked.Name]
+		value := tr(ctx, "%d, %s avg", cmd.Count, cmd.AverageLatency().Round(10*time.Millisecond))
+		if failed := cmd.ErrorCount(); failed > 0 {
+			value += tr(ctx, ", %d failed", failed)
+		}
+		commands.Add(rankRow(req.Prefix+ranked.Name, value))
+	}
+
+	users := flex.VBox(&flex.Text{Text: tr(ctx, "Top users"), Size: "md", Color: "#555555", Weight: "bold"})
+	users.Spacing = "sm"
+	users.Margin = "xxl"
+	top := stats.TopUsers(statsTopSize)
+	for _, ranked := range top {
+		users.Add(rankRow(app.displayName(ranked.Name), strconv.Itoa(ranked.Count)))
+	}
+	if len(top) == 0 {
+		users.Add(&flex.Text{Text: tr(ctx, "Only scheduled commands so far"), Size: "sm", Color: "#aaaaaa"})
+	}
+
+	bubble := &flex.Bubble{
+		Body: flex.VBox(
+			&flex.Text{Text: "STATS", Weight: "bold", Color: "#1db446", Size: "sm"},
+			&flex.Text{Text: tr(ctx, "Command usage"), Weight: "bold", Size: "xxl", Margin: "md"},
+			&flex.Text{Text: tr(ctx, "Since %s", stats.Since.In(app.location).Format("2006-01-02")), Size: "xs", Color: "#aaaaaa", Wrap: true},
+			&flex.Separator{Margin: "xxl"},
+			commands,
+			users,
+		),
+	}
+	return app.replyFlex(req.ReplyToken, tr(ctx, "Command usage of this chat"), bubble)
+}
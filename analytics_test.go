@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsNeedTheAdminToken(t *testing.T) {
+	app, messenger := newTestBot(t, http.NotFoundHandler(), func(cfg *Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	sendText(t, app, messenger, "!usage write")
+
+	tests := []struct {
+		authorization string
+		want          int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer nope", http.StatusUnauthorized},
+		{"Bearer " + testAdminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		app.metricsHandler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q: got %d, want %d", tt.authorization, rec.Code, tt.want)
+		}
+		if body := rec.Body.String(); (rec.Code == http.StatusOK) != strings.Contains(body, `tamako_commands_total{command="usage",outcome="ok"} 1`) {
+			t.Errorf("Authorization %q: unexpected body %q", tt.authorization, body)
+		}
+	}
+}
+
+func TestMetricsOffWithoutAdminToken(t *testing.T) {
+	app, _ := newTestBot(t, http.NotFoundHandler(), nil)
+	rec := httptest.NewRecorder()
+	app.metricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", rec.Code)
+	}
+}
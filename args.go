@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ArgError is returned when the arguments of a command can't be parsed. The
+// message is kept as a format so it can be translated.
+type ArgError struct {
+	Format string
+	Args   []interface{}
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+// argErrorText is the reply to an error of tokenize or parseArgs
+func argErrorText(ctx context.Context, err error) string {
+	var aerr *ArgError
+	if errors.As(err, &aerr) {
+		return tr(ctx, aerr.Format, aerr.Args...)
+	}
+	return err.Error()
+}
+
+// ParsedArgs holds the positional arguments and `--flag=value` options of a command
+type ParsedArgs struct {
+	Positional []string
+	Flags      map[string]string
+}
+
+// closing quote for every opening quote we understand
+var quotePairs = map[rune]rune{
+	'"':      '"',
+	'\'':     '\'',
+	'\u201c': '\u201d', // “ ” sent by some mobile keyboards
+	'\u2018': '\u2019', // ‘ ’
+}
+
+// tokenize splits a command line into words. A quote only opens at the start
+// of a word or right after the = of a --name= option, so apostrophes like in
+// "don't" are kept as they are.
+func tokenize(input string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inToken := false
+	var closing rune
+	quoted := false
+
+	for _, r := range input {
+		switch {
+		case quoted:
+			if r == closing {
+				quoted = false
+				continue
+			}
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			if c, ok := quotePairs[r]; ok && (!inToken || optionValueStart(current.String())) {
+				closing = c
+				quoted = true
+				inToken = true
+				continue
+			}
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, &ArgError{Format: "Missing closing quote"}
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// optionValueStart reports whether a word so far is a --name= option waiting
+// for its value
+func optionValueStart(word string) bool {
+	return strings.HasPrefix(word, "--") && strings.IndexByte(word, '=') == len(word)-1
+}
+
+// parseArgs splits tokens into positional arguments and options. Options are
+// written as `--name=value` or `--name`, and `--` ends the options.
+func parseArgs(tokens []string, spec ArgSpec) (*ParsedArgs, error) {
+	parsed := &ParsedArgs{
+		Flags: make(map[string]string),
+	}
+	for i, token := range tokens {
+		if spec.Passthrough {
+			parsed.Positional = append(parsed.Positional, token)
+			continue
+		}
+		if token == "--" {
+			parsed.Positional = append(parsed.Positional, tokens[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(token, "--") {
+			parsed.Positional = append(parsed.Positional, token)
+			continue
+		}
+		name, value := token[2:], "true"
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, value = name[:eq], name[eq+1:]
+		}
+		name = strings.ToLower(name)
+		if !spec.allowsFlag(name) {
+			return nil, &ArgError{Format: "Unknown option --%s", Args: []interface{}{name}}
+		}
+		parsed.Flags[name] = value
+	}
+
+	n := len(parsed.Positional)
+	if n < spec.Min {
+		return nil, &ArgError{Format: "Not enough arguments"}
+	}
+	if spec.Max >= 0 && n > spec.Max {
+		return nil, &ArgError{Format: "Too many arguments"}
+	}
+	return parsed, nil
+}
+
+func (spec ArgSpec) allowsFlag(name string) bool {
+	for _, flag := range spec.Flags {
+		if flag == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"osu cookiezi", []string{"osu", "cookiezi"}},
+		{"  osu \t cookiezi  ", []string{"osu", "cookiezi"}},
+		{`osu "mr ekko"`, []string{"osu", "mr ekko"}},
+		{`say 'hello world'`, []string{"say", "hello world"}},
+		{"say “hello world”", []string{"say", "hello world"}},
+		{"say ‘hi there’", []string{"say", "hi there"}},
+		{"don't stop", []string{"don't", "stop"}},
+		{`say ""`, []string{"say", ""}},
+		{`a"b c"`, []string{`a"b`, `c"`}},
+		{`osu --name="mr ekko" x`, []string{"osu", "--name=mr ekko", "x"}},
+		{"osu --name=“mr ekko”", []string{"osu", "--name=mr ekko"}},
+		{`osu --name=""`, []string{"osu", "--name="}},
+		{`osu "--name=mr ekko"`, []string{"osu", "--name=mr ekko"}},
+		{`osu --name=mr"ekko x"`, []string{"osu", `--name=mr"ekko`, `x"`}},
+		{`osu -name="a b"`, []string{"osu", `-name="a`, `b"`}},
+		{`osu --a=b="c d"`, []string{"osu", `--a=b="c`, `d"`}},
+	}
+	for _, tt := range tests {
+		got, err := tokenize(tt.input)
+		if err != nil {
+			t.Errorf("tokenize(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeUnclosedQuote(t *testing.T) {
+	for _, input := range []string{`say "hello`, "say “hello\"", `'`, `--name="hello`} {
+		if _, err := tokenize(input); err == nil {
+			t.Errorf("tokenize(%q): want an error", input)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	spec := ArgSpec{Min: 1, Max: 2, Flags: []string{"mode", "all"}}
+	tests := []struct {
+		tokens     []string
+		positional []string
+		flags      map[string]string
+	}{
+		{[]string{"cookiezi"}, []string{"cookiezi"}, map[string]string{}},
+		{[]string{"cookiezi", "--mode=taiko"}, []string{"cookiezi"}, map[string]string{"mode": "taiko"}},
+		{[]string{"--MODE=taiko", "cookiezi"}, []string{"cookiezi"}, map[string]string{"mode": "taiko"}},
+		{[]string{"cookiezi", "--all"}, []string{"cookiezi"}, map[string]string{"all": "true"}},
+		{[]string{"--mode=", "cookiezi"}, []string{"cookiezi"}, map[string]string{"mode": ""}},
+		{[]string{"-x", "cookiezi"}, []string{"-x", "cookiezi"}, map[string]string{}},
+		{[]string{"--", "--mode=taiko", "x"}, []string{"--mode=taiko", "x"}, map[string]string{}},
+		{[]string{"a", "--"}, []string{"a"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		got, err := parseArgs(tt.tokens, spec)
+		if err != nil {
+			t.Errorf("parseArgs(%q): %v", tt.tokens, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Positional, tt.positional) || !reflect.DeepEqual(got.Flags, tt.flags) {
+			t.Errorf("parseArgs(%q) = %q %v, want %q %v", tt.tokens, got.Positional, got.Flags, tt.positional, tt.flags)
+		}
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	spec := ArgSpec{Min: 1, Max: 2, Flags: []string{"mode"}}
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{nil, "Not enough arguments"},
+		{[]string{"--mode=taiko"}, "Not enough arguments"},
+		{[]string{"a", "b", "c"}, "Too many arguments"},
+		{[]string{"a", "--nope"}, "Unknown option --nope"},
+	}
+	for _, tt := range tests {
+		_, err := parseArgs(tt.tokens, spec)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parseArgs(%q) error = %v, want %q", tt.tokens, err, tt.want)
+		}
+	}
+}
+
+func TestParseArgsPassthrough(t *testing.T) {
+	spec := ArgSpec{Max: -1, Passthrough: true}
+	tokens := []string{"osu", "--mode=taiko", "--", "x"}
+	got, err := parseArgs(tokens, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Positional, tokens) || len(got.Flags) != 0 {
+		t.Errorf("parseArgs(%q) = %q %v, want the tokens as they are", tokens, got.Positional, got.Flags)
+	}
+}
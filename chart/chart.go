@@ -0,0 +1,180 @@
+// Package chart draws simple line charts as PNG images, e.g. the rank
+// history of an osu! player, without any font or graphics dependency.
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Point is a value at a time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Line is a chart of one series of points
+type Line struct {
+	Points []Point
+	// Inverted puts the lowest values at the top, for ranks
+	Inverted bool
+	// Color of the line, blue by default
+	Color color.RGBA
+	// Width and Height of the image, 800x500 by default
+	Width, Height int
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	axisColor  = color.RGBA{0x88, 0x88, 0x88, 0xff}
+	labelColor = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	lineColor  = color.RGBA{0x3b, 0x82, 0xf6, 0xff}
+)
+
+// layout of the plot area
+const (
+	marginLeft   = 110
+	marginRight  = 30
+	marginTop    = 30
+	marginBottom = 50
+	labelScale   = 3
+	gridLines    = 4
+)
+
+// Render draws the chart
+func (l *Line) Render() *image.RGBA {
+	width, height := l.Width, l.Height
+	if width <= 0 || height <= 0 {
+		width, height = 800, 500
+	}
+	stroke := l.Color
+	if stroke == (color.RGBA{}) {
+		stroke = lineColor
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, background)
+
+	left, top := marginLeft, marginTop
+	right, bottom := width-marginRight, height-marginBottom
+	if len(l.Points) == 0 {
+		return img
+	}
+
+	minV, maxV := l.Points[0].Value, l.Points[0].Value
+	minT, maxT := l.Points[0].Time, l.Points[0].Time
+	for _, p := range l.Points {
+		minV, maxV = math.Min(minV, p.Value), math.Max(maxV, p.Value)
+		if p.Time.Before(minT) {
+			minT = p.Time
+		}
+		if p.Time.After(maxT) {
+			maxT = p.Time
+		}
+	}
+	if minV == maxV {
+		minV, maxV = minV-1, maxV+1
+	}
+	span := maxT.Sub(minT)
+
+	x := func(t time.Time) int {
+		if span <= 0 {
+			return (left + right) / 2
+		}
+		return left + int(float64(right-left)*float64(t.Sub(minT))/float64(span))
+	}
+	y := func(v float64) int {
+		ratio := (v - minV) / (maxV - minV)
+		if l.Inverted {
+			return top + int(float64(bottom-top)*ratio)
+		}
+		return bottom - int(float64(bottom-top)*ratio)
+	}
+
+	// horizontal grid with the values on the left
+	for i := 0; i <= gridLines; i++ {
+		v := minV + (maxV-minV)*float64(i)/gridLines
+		gy := y(v)
+		drawLine(img, left, gy, right, gy, gridColor, 1)
+		label := formatValue(v)
+		drawText(img, left-12-textWidth(label, labelScale), gy-glyphHeight*labelScale/2, label, labelScale, labelColor)
+	}
+	drawLine(img, left, top, left, bottom, axisColor, 1)
+	drawLine(img, left, bottom, right, bottom, axisColor, 1)
+
+	// first and last dates under the axis
+	first, last := minT.Format("01/02"), maxT.Format("01/02")
+	drawText(img, left, bottom+14, first, labelScale, labelColor)
+	if span > 0 {
+		drawText(img, right-textWidth(last, labelScale), bottom+14, last, labelScale, labelColor)
+	}
+
+	for i, p := range l.Points {
+		px, py := x(p.Time), y(p.Value)
+		if i > 0 {
+			prev := l.Points[i-1]
+			drawLine(img, x(prev.Time), y(prev.Value), px, py, stroke, 3)
+		}
+		fillRect(img, px-3, py-3, 7, 7, stroke)
+	}
+	return img
+}
+
+// EncodePNG renders the chart into w
+func (l *Line) EncodePNG(w io.Writer) error {
+	return png.Encode(w, l.Render())
+}
+
+// formatValue shortens large values, 12345 becomes 12.3k
+func formatValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e6:
+		return strconv.FormatFloat(v/1e6, 'f', 1, 64) + "M"
+	case abs >= 1e4:
+		return strconv.FormatFloat(v/1e3, 'f', 1, 64) + "k"
+	default:
+		return strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	}
+}
+
+// drawLine draws a line of the given thickness with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, thickness int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	half := thickness / 2
+	e := dx + dy
+	for {
+		fillRect(img, x0-half, y0-half, thickness, thickness, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,72 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// glyphs is a 3x5 bitmap font with what the axis labels need, each row is
+// 3 bits from left to right
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 2, 2, 2},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'#': {5, 7, 5, 7, 5},
+	'/': {1, 1, 2, 4, 4},
+	'-': {0, 0, 7, 0, 0},
+	'+': {0, 2, 7, 2, 0},
+	'.': {0, 0, 0, 0, 2},
+	',': {0, 0, 0, 2, 4},
+	':': {0, 2, 0, 2, 0},
+	'%': {5, 1, 2, 4, 5},
+	'k': {4, 5, 6, 5, 5},
+	'M': {5, 7, 7, 5, 5},
+	' ': {0, 0, 0, 0, 0},
+}
+
+// glyph size in pixels at scale 1, with one column of spacing
+const (
+	glyphWidth  = 4
+	glyphHeight = 5
+)
+
+// textWidth is the width of s drawn at the given scale
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*glyphWidth - 1) * scale
+}
+
+// drawText draws s with its top left corner at x, y. Unknown characters are
+// left blank.
+func drawText(img *image.RGBA, x, y int, s string, scale int, c color.Color) {
+	for _, r := range s {
+		rows := glyphs[r]
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < 3; col++ {
+				if rows[row]&(4>>uint(col)) == 0 {
+					continue
+				}
+				fillRect(img, x+col*scale, y+row*scale, scale, scale, c)
+			}
+		}
+		x += glyphWidth * scale
+	}
+}
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			img.Set(x+dx, y+dy, c)
+		}
+	}
+}
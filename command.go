@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// Command is a single chat command such as `!osu` or `!help`. Admin
+// commands can only be run by the admins of the chat, Owner ones by the
+// owners of the bot, Schedulable ones can be posted on a schedule with
+// `!subscribe`.
+type Command struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	Description string
+	Providers   []string
+	Admin       bool
+	Owner       bool
+	Schedulable bool
+	Args        ArgSpec
+	Handler     func(req *CommandRequest) error
+}
+
+// ArgSpec tells how many positional arguments a command accepts and which
+// `--flag` options it understands, Max -1 means unlimited. With Passthrough
+// the options are kept as positional arguments, for commands that hand them
+// to another command.
+type ArgSpec struct {
+	Min         int
+	Max         int
+	Flags       []string
+	Passthrough bool
+}
+
+// CommandRequest is what a command handler receives
+type CommandRequest struct {
+	ctx        context.Context
+	Command    *Command
+	Prefix     string
+	Chat       *store.Chat
+	Args       []string
+	Flags      map[string]string
+	Text       string
+	ReplyToken string
+	Source     *linebot.EventSource
+	Message    *linebot.TextMessage
+}
+
+// UsageLine returns the usage of the command with the given prefix
+func (cmd *Command) UsageLine(prefix string) string {
+	if cmd.Usage == "" {
+		return prefix + cmd.Name
+	}
+	return prefix + cmd.Name + " " + cmd.Usage
+}
+
+// Context returns the context of the event being handled
+func (req *CommandRequest) Context() context.Context {
+	if req.ctx != nil {
+		return req.ctx
+	}
+	return context.Background()
+}
+
+// Flag returns the value of an option, or fallback when it wasn't given
+func (req *CommandRequest) Flag(name, fallback string) string {
+	if value, ok := req.Flags[name]; ok {
+		return value
+	}
+	return fallback
+}
+
+// CommandRegistry keeps the commands known by the bot
+type CommandRegistry struct {
+	commands []*Command
+	index    map[string]*Command
+}
+
+// NewCommandRegistry function
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		index: make(map[string]*Command),
+	}
+}
+
+// Register adds commands to the registry, names and aliases must be unique
+func (r *CommandRegistry) Register(cmds ...*Command) error {
+	for _, cmd := range cmds {
+		if cmd.Name == "" || cmd.Handler == nil {
+			return fmt.Errorf("command %q: name and handler are required", cmd.Name)
+		}
+		keys := append([]string{cmd.Name}, cmd.Aliases...)
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if _, ok := r.index[key]; ok {
+				return fmt.Errorf("command %q: %q is already registered", cmd.Name, key)
+			}
+			r.index[key] = cmd
+		}
+		r.commands = append(r.commands, cmd)
+	}
+	return nil
+}
+
+// Lookup finds a command by its name or one of its aliases
+func (r *CommandRegistry) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.index[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands returns the registered commands in registration order
+func (r *CommandRegistry) Commands() []*Command {
+	return r.commands
+}
+
+// Names returns the names of the registered commands
+func (r *CommandRegistry) Names() []string {
+	names := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
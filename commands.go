@@ -0,0 +1,393 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/i18n"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// commandList returns every chat command of the bot
+func (app *TamakoBot) commandList() []*Command {
+	return []*Command{
+		{
+			Name:        "help",
+			Description: "Show the available keywords",
+			Args:        ArgSpec{Min: 0, Max: -1},
+			Handler:     app.helpCommand,
+		},
+		{
+			Name:        "usage",
+			Usage:       "<keyword>",
+			Description: "Show how to use a keyword",
+			Args:        ArgSpec{Min: 1, Max: 1},
+			Handler:     app.usageCommand,
+		},
+		{
+			Name:        "sing",
+			Description: "Choose a Tamako song to play",
+			Args:        ArgSpec{Min: 0, Max: -1},
+			Handler:     app.singCommand,
+		},
+		{
+			Name:        "about",
+			Description: "About the developer",
+			Args:        ArgSpec{Min: 0, Max: -1},
+			Handler:     app.aboutCommand,
+		},
+		{
+			Name:        "write",
+			Usage:       "<text>",
+			Description: "Write a text on Tamako's notebook",
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler:     app.writeCommand,
+		},
+		{
+			Name:        "dota",
+			Usage:       "[watch|unwatch] [<steam vanity name>]",
+			Description: "Dota 2 profile and recent match, of your linked steam account by default. watch posts every new match of the player in this chat",
+			Providers:   []string{providerSteam, providerOpenDota},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 0, Max: 2},
+			Handler:     app.dotaCommand,
+		},
+		{
+			Name:        "games",
+			Usage:       "<title>",
+			Description: "Search video game information",
+			Providers:   []string{providerGiantBomb},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler: func(req *CommandRequest) error {
+				return app.gameMessage(req.Context(), req.Text, req.ReplyToken)
+			},
+		},
+		{
+			Name:        "manga",
+			Usage:       "<title>",
+			Description: "Search manga information",
+			Providers:   []string{providerKitsu},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler: func(req *CommandRequest) error {
+				return app.mangaMessage(req.Context(), req.Text, req.ReplyToken)
+			},
+		},
+		{
+			Name:        "motw",
+			Description: "Music of the week",
+			Providers:   []string{providerITunes},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 0, Max: 0},
+			Handler: func(req *CommandRequest) error {
+				return app.motwMessage(req.Context(), req.ReplyToken)
+			},
+		},
+		{
+			Name:        "ynm",
+			Usage:       "<question>",
+			Description: "Answer a question with yes, no or maybe",
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler:     app.ynmCommand,
+		},
+		{
+			Name:        "chs",
+			Usage:       "<option 1>-<option 2>[-<option n>]",
+			Description: "Choose one of the options",
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler:     app.chsCommand,
+		},
+		{
+			Name:        "osu",
+			Usage:       "[history|best|recent] [standard|taiko|ctb|mania] [<username>] [--mode[=<mode>]], or map <beatmap id or link> [+<mods>]",
+			Description: "osu! ranks in every mode, or the details of one mode given by name or with --mode, the most played one with a bare --mode, a chart of the rank over time, or the best or recent plays, of your linked osu! account by default. map shows the star rating and pp of a beatmap, also for the beatmap links sent in the chat",
+			Providers:   []string{providerOsu},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 0, Max: -1, Flags: []string{"mode"}},
+			Handler:     app.osuCommand,
+		},
+		{
+			Name:        "steam",
+			Usage:       "[<steam vanity name>]",
+			Description: "Steam profile and recently played games, of your linked steam account by default",
+			Providers:   []string{providerSteam},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 0, Max: 1},
+			Handler: app.withLinkedAccount("steam", func(req *CommandRequest, account string) error {
+				return app.steamMessage(req.Context(), account, req.ReplyToken)
+			}),
+		},
+		{
+			Name:        "urban",
+			Usage:       "<word>",
+			Description: "Urban dictionary definition",
+			Providers:   []string{providerUrban},
+			Schedulable: true,
+			Args:        ArgSpec{Min: 1, Max: -1},
+			Handler: func(req *CommandRequest) error {
+				return app.urbanMessage(req.Context(), req.Text, req.ReplyToken)
+			},
+		},
+		{
+			Name:        "link",
+			Usage:       "[<steam|osu> <account>]",
+			Description: "Link your steam or osu! account so steam, dota and osu work without a name, or list your linked accounts",
+			Args:        ArgSpec{Min: 0, Max: -1},
+			Handler:     app.linkCommand,
+		},
+		{
+			Name:        "unlink",
+			Usage:       "[<steam|osu>]",
+			Description: "Forget your linked accounts, or only the given one",
+			Args:        ArgSpec{Min: 0, Max: 2},
+			Handler:     app.unlinkCommand,
+		},
+		{
+			Name:        "config",
+			Usage:       "[prefix <prefix> | disable <keyword>... | enable <keyword>... | admin <add|remove> <me|user ID> | reset]",
+			Description: "Show or change the settings of this chat, changes are for the chat admins",
+			Args:        ArgSpec{Min: 0, Max: -1},
+			Handler:     app.configCommand,
+		},
+		{
+			Name:        "lang",
+			Usage:       "[me] [<en|id|ja|reset>]",
+			Description: "Show or change the language of the replies, for this group or room or with me only for yourself",
+			Args:        ArgSpec{Min: 0, Max: 2},
+			Handler:     app.langCommand,
+		},
+		{
+			Name:        "subscribe",
+			Usage:       "<keyword> [<arguments>...] <hourly :MM | daily HH:MM | weekly <day> HH:MM>",
+			Description: "Post the result of a keyword in this chat on a schedule",
+			Admin:       true,
+			Args:        ArgSpec{Min: 2, Max: -1, Passthrough: true},
+			Handler:     app.subscribeCommand,
+		},
+		{
+			Name:        "subscriptions",
+			Description: "List the scheduled posts of this chat",
+			Args:        ArgSpec{Min: 0, Max: 0},
+			Handler:     app.subscriptionsCommand,
+		},
+		{
+			Name:        "unsubscribe",
+			Usage:       "<subscription number>",
+			Description: "Stop a scheduled post of this chat",
+			Admin:       true,
+			Args:        ArgSpec{Min: 1, Max: 1},
+			Handler:     app.unsubscribeCommand,
+		},
+		{
+			Name:        "stats",
+			Description: "Top commands and users of this chat",
+			Args:        ArgSpec{Min: 0, Max: 0},
+			Handler:     app.statsCommand,
+		},
+		{
+			Name:        "ping",
+			Description: "Uptime, version and whether the providers can be reached, for the owners of the bot",
+			Owner:       true,
+			Args:        ArgSpec{Min: 0, Max: 0},
+			Handler:     app.pingCommand,
+		},
+		{
+			Name:        "bye",
+			Aliases:     []string{"leave"},
+			Description: "Make the bot leave this group or room",
+			Admin:       true,
+			Args:        ArgSpec{Min: 0, Max: 0},
+			Handler:     app.byeCommand,
+		},
+	}
+}
+
+func (app *TamakoBot) helpCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	profile, err := app.bot.GetProfile(req.Source.UserID)
+	if err != nil {
+		return app.replyText(req.ReplyToken, tr(ctx, "add me as a friend"))
+	}
+
+	var keywords []string
+	for _, cmd := range app.commands.Commands() {
+		if cmd.Owner && !app.config.IsOwner(req.Source.UserID) {
+			continue
+		}
+		if len(app.config.MissingProviders(cmd)) == 0 && !req.Chat.IsDisabled(cmd.Name) {
+			keywords = append(keywords, cmd.Name)
+		}
+	}
+
+	var help = tr(ctx, "Hello %s, nice to meet you ^_^ \nKeywords: %s.\n\nFor help type : \n%susage <available keyword>", profile.DisplayName, strings.Join(keywords, ", "), req.Prefix)
+	return app.replyText(req.ReplyToken, help)
+}
+
+func (app *TamakoBot) usageCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	cmd, ok := app.commands.Lookup(strings.TrimPrefix(req.Args[0], req.Prefix))
+	if !ok {
+		return app.replyText(req.ReplyToken, tr(ctx, "Unknown keyword %s", req.Args[0]))
+	}
+	usage := tr(ctx, "Usage : %s", cmd.UsageLine(req.Prefix)) + "\n" + i18n.Text(languageOf(ctx), cmd.Description)
+	if len(cmd.Aliases) > 0 {
+		usage += "\n" + tr(ctx, "Aliases : %s", strings.Join(cmd.Aliases, ", "))
+	}
+	return app.replyText(req.ReplyToken, usage)
+}
+
+func (app *TamakoBot) singCommand(req *CommandRequest) error {
+	imageURL := "https://s-media-cache-ak0.pinimg.com/564x/9e/fa/18/9efa18b56cd5057101bf72a0b023ad7f.jpg"
+	template := linebot.NewButtonsTemplate(
+		imageURL, tr(req.Context(), "Choose Tamako Song"), "CV : Suzaki Aya",
+		linebot.NewPostbackAction("Dramatic Market Ride", "dmr", "", ""),
+		linebot.NewPostbackAction("Principle", "principle", "", ""),
+		linebot.NewPostbackAction("Koi no Uta", "koinouta", "", ""),
+		linebot.NewPostbackAction("Neguse", "neguse", "", ""),
+	)
+	if err := app.bot.ReplyMessage(
+		req.ReplyToken,
+		linebot.NewTemplateMessage(tr(req.Context(), "Song List"), template),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (app *TamakoBot) aboutCommand(req *CommandRequest) error {
+	imageURL := "https://01d54fec-a-62cb3a1a-s-sites.googlegroups.com/site/untukaudio1/directory/tamakomem.jpg"
+	template := linebot.NewButtonsTemplate(
+		imageURL, tr(req.Context(), "About Developer"), "Mr. Rojokundo",
+		linebot.NewURIAction("Youtube", "https://www.youtube.com/rojofactory"),
+		linebot.NewURIAction("Line@", "https://line.me/R/ti/p/%40wfq6948b"),
+		linebot.NewURIAction("Instagram", "https://www.instagram.com/afifmakarim88"),
+	)
+	if err := app.bot.ReplyMessage(
+		req.ReplyToken,
+		linebot.NewTemplateMessage(tr(req.Context(), "About Developer"), template),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (app *TamakoBot) writeCommand(req *CommandRequest) error {
+	rawEncoded := Rawurlencode(req.Text)
+	var imageUrl string
+
+	if rawEncoded == "" {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Nothing to write"))
+	}
+
+	if len(rawEncoded) >= 8 && len(rawEncoded) <= 55 {
+		imageUrl = "https://res.cloudinary.com/dftovjqdo/image/upload/a_-27,g_west,l_text:dark_name:" + rawEncoded + ",w_450,x_280,y_100/anime_notebook_yhekwa.jpg"
+	} else if len(rawEncoded) <= 8 {
+		imageUrl = "https://res.cloudinary.com/dftovjqdo/image/upload/a_-27,g_west,l_text:dark_name:" + rawEncoded + ",w_200,x_250,y_100/anime_notebook_yhekwa.jpg"
+	} else {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Text too long :("))
+	}
+
+	if err := app.bot.ReplyMessage(
+		req.ReplyToken,
+		linebot.NewImageMessage(imageUrl, imageUrl),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (app *TamakoBot) ynmCommand(req *CommandRequest) error {
+	array := []string{tr(req.Context(), "Yes"), tr(req.Context(), "No"), tr(req.Context(), "Maybe")}
+	rand.Seed(time.Now().UnixNano())
+	randomInt := randomInt(0, len(array))
+	return app.replyText(req.ReplyToken, req.Text+"\n"+array[randomInt])
+}
+
+func (app *TamakoBot) chsCommand(req *CommandRequest) error {
+	explode := strings.Split(req.Text, "-")
+	rand.Seed(time.Now().UnixNano())
+	randomInt := randomInt(0, len(explode))
+	return app.replyText(req.ReplyToken, tr(req.Context(), "I choose %s", strings.TrimSpace(explode[randomInt])))
+}
+
+func (app *TamakoBot) dotaCommand(req *CommandRequest) error {
+	if len(req.Args) > 0 {
+		switch action := strings.ToLower(req.Args[0]); action {
+		case "watch", "unwatch":
+			if !app.isChatAdmin(req.Chat, req.Source) {
+				return app.replyText(req.ReplyToken, tr(req.Context(), "Only the admins of this chat can use %s", req.Prefix+"dota "+action))
+			}
+			sub := *req
+			sub.Args = req.Args[1:]
+			sub.Text = strings.Join(sub.Args, " ")
+			if action == "unwatch" {
+				return app.dotaUnwatchCommand(&sub, sub.Text)
+			}
+			return app.withLinkedAccount("steam", app.dotaWatchCommand)(&sub)
+		}
+	}
+	if len(req.Args) > 1 {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Too many arguments")+"\n"+tr(req.Context(), "Usage : %s", req.Command.UsageLine(req.Prefix)))
+	}
+	return app.withLinkedAccount("steam", func(req *CommandRequest, account string) error {
+		return app.dotaMessage(req.Context(), account, req.ReplyToken)
+	})(req)
+}
+
+func (app *TamakoBot) osuCommand(req *CommandRequest) error {
+	if len(req.Args) > 0 && strings.ToLower(req.Args[0]) == "map" {
+		sub := *req
+		sub.Args = req.Args[1:]
+		sub.Text = strings.Join(sub.Args, " ")
+		return app.osuMapCommand(&sub)
+	}
+	if len(req.Args) > 0 {
+		subcommands := map[string]func(req *CommandRequest, account string) error{
+			"history": app.osuHistoryCommand,
+			"best":    app.osuScoresCommand("best"),
+			"recent":  app.osuScoresCommand("recent"),
+		}
+		if handler, ok := subcommands[strings.ToLower(req.Args[0])]; ok {
+			sub := *req
+			sub.Args = req.Args[1:]
+			sub.Text = strings.Join(sub.Args, " ")
+			osuRulesetArg(&sub)
+			return app.withLinkedAccount("osu", handler)(&sub)
+		}
+	}
+	sub := *req
+	if osuRulesetArg(&sub) {
+		return app.withLinkedAccount("osu", app.osuModeCommand)(&sub)
+	}
+	if _, ok := req.Flags["mode"]; ok {
+		return app.withLinkedAccount("osu", app.osuModeCommand)(req)
+	}
+	return app.withLinkedAccount("osu", func(req *CommandRequest, account string) error {
+		return app.osuMessage(req.Context(), account, req.ReplyToken)
+	})(req)
+}
+
+func (app *TamakoBot) byeCommand(req *CommandRequest) error {
+	source := req.Source
+	switch source.Type {
+	case linebot.EventSourceTypeUser:
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Bot can't leave from 1:1 chat"))
+	case linebot.EventSourceTypeGroup:
+		if err := app.replyText(req.ReplyToken, tr(req.Context(), "Leaving group")); err != nil {
+			return err
+		}
+		if err := app.leaveChat(source.GroupID, string(source.Type)); err != nil {
+			return app.replyText(req.ReplyToken, err.Error())
+		}
+	case linebot.EventSourceTypeRoom:
+		if err := app.replyText(req.ReplyToken, tr(req.Context(), "Leaving room")); err != nil {
+			return err
+		}
+		if err := app.leaveChat(source.RoomID, string(source.Type)); err != nil {
+			return app.replyText(req.ReplyToken, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// testSource is the group the test messages are sent from
+var testSource = &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "G1", UserID: "U1"}
+
+// newTestBot builds a bot on a RecordingMessenger with its own database and
+// every provider endpoint pointed at upstream
+func newTestBot(t *testing.T, upstream http.Handler, configure func(cfg *Config)) (*TamakoBot, *RecordingMessenger) {
+	t.Helper()
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+	cfg := &Config{
+		ChannelSecret: "secret",
+		ChannelToken:  "token",
+		DownloadDir:   filepath.Join(dir, "line-bot"),
+		DBPath:        filepath.Join(dir, "tamako.db"),
+		Providers:     make(map[string]ProviderConfig),
+	}
+	for name := range providerSpecs {
+		cfg.Providers[name] = ProviderConfig{Endpoint: server.URL}
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	cfg.applyDefaults()
+	if err := os.MkdirAll(cfg.DownloadDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	messenger := NewRecordingMessenger()
+	app, err := NewTamakoBotWithMessenger(messenger, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.Close(context.Background()) })
+	return app, messenger
+}
+
+// sendText handles a text message from testSource and returns the texts
+// replied since the last call
+func sendText(t *testing.T, app *TamakoBot, messenger *RecordingMessenger, text string) []string {
+	t.Helper()
+	return sendTextFrom(t, app, messenger, testSource, text)
+}
+
+// sendTextFrom is sendText for a message from source
+func sendTextFrom(t *testing.T, app *TamakoBot, messenger *RecordingMessenger, source *linebot.EventSource, text string) []string {
+	t.Helper()
+	before := len(messenger.Replies())
+	if err := app.handleText(context.Background(), &linebot.TextMessage{Text: text}, "reply-token", source); err != nil {
+		t.Fatalf("%q: %v", text, err)
+	}
+	var texts []string
+	for _, reply := range messenger.Replies()[before:] {
+		for _, message := range reply.Messages {
+			if m, ok := message.(*linebot.TextMessage); ok {
+				texts = append(texts, m.Text)
+			}
+		}
+	}
+	return texts
+}
+
+func TestCommandReplies(t *testing.T) {
+	app, messenger := newTestBot(t, http.NotFoundHandler(), nil)
+	messenger.SetProfile("U1", "Kanna")
+
+	tests := []struct {
+		name string
+		text string
+		want string // the reply starts with it, empty for no reply
+	}{
+		{"help", "!help", "Hello Kanna, nice to meet you ^_^ \nKeywords: help, usage,"},
+		{"usage", "!usage write", "Usage : !write <text>\nWrite a text on Tamako's notebook"},
+		{"usage of an alias", "!usage leave", "Usage : !bye"},
+		{"usage with the prefix", "!usage !write", "Usage : !write <text>"},
+		{"unknown keyword", "!usage nope", "Unknown keyword nope"},
+		{"unknown command is echoed", "!nope", "!nope"},
+		{"not a command", "hello there", ""},
+		{"only the prefix", "!", ""},
+		{"not enough arguments", "!usage", "Not enough arguments\nUsage : !usage <keyword>"},
+		{"too many arguments", "!motw now", "Too many arguments\nUsage : !motw"},
+		{"unknown option", "!usage --all write", "Unknown option --all\nUsage : !usage <keyword>"},
+		{"missing quote", `!write "hello`, "Missing closing quote"},
+		{"owner command", "!ping", "Only the owners of the bot can use !ping"},
+		{"missing provider", "!steam gaben", "!steam is disabled, steam is not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := sendText(t, app, messenger, tt.text)
+			if tt.want == "" {
+				if len(replies) > 0 {
+					t.Errorf("%q: want no reply, got %q", tt.text, replies)
+				}
+				return
+			}
+			if len(replies) != 1 || !strings.HasPrefix(replies[0], tt.want) {
+				t.Errorf("%q: got %q, want one reply starting with %q", tt.text, replies, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelpWithoutProfile(t *testing.T) {
+	app, messenger := newTestBot(t, http.NotFoundHandler(), nil)
+	replies := sendText(t, app, messenger, "!help")
+	if len(replies) != 1 || replies[0] != "add me as a friend" {
+		t.Errorf("got %q, want the friend request", replies)
+	}
+}
+
+func TestPing(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	app, messenger := newTestBot(t, upstream, func(cfg *Config) {
+		cfg.Owners = []string{"U1"}
+	})
+	replies := sendText(t, app, messenger, "!ping")
+	if len(replies) != 1 {
+		t.Fatalf("got %q, want one reply", replies)
+	}
+	for _, want := range []string{"pong\n", "Version : ", "Events queued : 0/100", "OpenDota : ok in", "(HTTP 418)", "Steam : not configured"} {
+		if !strings.Contains(replies[0], want) {
+			t.Errorf("reply %q lacks %q", replies[0], want)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/afifmakarim/go-tamako/i18n"
+	"github.com/afifmakarim/go-tamako/ratelimit"
+)
+
+// Provider names, used by Command.Providers and the config file
+const (
+	providerOsu       = "osu"
+	providerSteam     = "steam"
+	providerOpenDota  = "opendota"
+	providerGiantBomb = "giantbomb"
+	providerKitsu     = "kitsu"
+	providerUrban     = "urban"
+	providerITunes    = "itunes"
+)
+
+// ProviderConfig holds the credentials and endpoint of an upstream provider
+type ProviderConfig struct {
+	Key string `json:"key,omitempty"`
+	// ClientID is the OAuth client of the providers using client
+	// credentials, whose Key is the client secret
+	ClientID string `json:"client_id,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// providerSpec describes where a provider reads its settings from
+type providerSpec struct {
+	keyEnv          string // empty when the provider needs no key
+	clientIDEnv     string // empty when the provider doesn't use OAuth
+	endpointEnv     string
+	defaultEndpoint string
+}
+
+var providerSpecs = map[string]providerSpec{
+	providerOsu:       {keyEnv: "OSU_CLIENT_SECRET", clientIDEnv: "OSU_CLIENT_ID", endpointEnv: "OSU_ENDPOINT", defaultEndpoint: "https://osu.ppy.sh"},
+	providerSteam:     {keyEnv: "STEAM_API_KEY", endpointEnv: "STEAM_ENDPOINT", defaultEndpoint: "https://api.steampowered.com"},
+	providerOpenDota:  {endpointEnv: "OPENDOTA_ENDPOINT", defaultEndpoint: "https://api.opendota.com/api"},
+	providerGiantBomb: {keyEnv: "GIANTBOMB_API_KEY", endpointEnv: "GIANTBOMB_ENDPOINT", defaultEndpoint: "https://www.giantbomb.com/api"},
+	providerKitsu:     {endpointEnv: "KITSU_ENDPOINT", defaultEndpoint: "https://kitsu.io/api/edge"},
+	providerUrban:     {keyEnv: "MASHAPE_KEY", endpointEnv: "URBAN_ENDPOINT", defaultEndpoint: "https://mashape-community-urban-dictionary.p.mashape.com"},
+	providerITunes:    {endpointEnv: "ITUNES_ENDPOINT", defaultEndpoint: "https://rss.itunes.apple.com/api/v1"},
+}
+
+// Config holds every setting of the bot. It is read from an optional JSON
+// file (CONFIG_FILE) and then overridden by environment variables.
+type Config struct {
+	ChannelSecret string                    `json:"channel_secret,omitempty"`
+	ChannelToken  string                    `json:"channel_token,omitempty"`
+	AppBaseURL    string                    `json:"app_base_url,omitempty"`
+	EndpointBase  string                    `json:"endpoint_base,omitempty"`
+	Port          string                    `json:"port,omitempty"`
+	Prefix        string                    `json:"prefix,omitempty"`
+	DownloadDir   string                    `json:"download_dir,omitempty"`
+	CacheDir      string                    `json:"cache_dir,omitempty"`
+	DBPath        string                    `json:"db_path,omitempty"`
+	Timezone      string                    `json:"timezone,omitempty"`
+	Workers       int                       `json:"workers,omitempty"`
+	QueueSize     int                       `json:"queue_size,omitempty"`
+	Providers     map[string]ProviderConfig `json:"providers,omitempty"`
+	RateLimits    RateLimitConfig           `json:"rate_limits"`
+	// Owners are the LINE user IDs allowed to run the owner commands
+	Owners []string `json:"owners,omitempty"`
+	// LogLevel is debug, info, warn or error
+	LogLevel string `json:"log_level,omitempty"`
+	// LogFormat is text or json
+	LogFormat string `json:"log_format,omitempty"`
+	// DefaultLang is the language of the replies in chats where nobody chose
+	// one, en, id or ja
+	DefaultLang string `json:"default_lang,omitempty"`
+	// AdminToken is the bearer token of the /admin API and /metrics, which
+	// are off when it is empty
+	AdminToken string `json:"admin_token,omitempty"`
+}
+
+// RateLimitConfig holds the token bucket limits of chat commands. A limit
+// is written "5/1m" (5 commands a minute) or "off".
+type RateLimitConfig struct {
+	// User limits each LINE user across every chat
+	User ratelimit.Limit `json:"user"`
+	// Group limits each group or room as a whole
+	Group ratelimit.Limit `json:"group"`
+	// Commands limits every use of a command, to protect provider quotas
+	Commands map[string]ratelimit.Limit `json:"commands,omitempty"`
+}
+
+// defaultRateLimits keeps the commands calling paid or quota limited APIs
+// well under their quotas
+func defaultRateLimits() RateLimitConfig {
+	return RateLimitConfig{
+		User:  ratelimit.Limit{Count: 6, Per: time.Minute},
+		Group: ratelimit.Limit{Count: 20, Per: time.Minute},
+		Commands: map[string]ratelimit.Limit{
+			"dota":  {Count: 10, Per: time.Minute},
+			"osu":   {Count: 20, Per: time.Minute},
+			"steam": {Count: 20, Per: time.Minute},
+		},
+	}
+}
+
+// LoadConfig reads the config file named by CONFIG_FILE, if any, and the
+// environment
+func LoadConfig() (*Config, error) {
+	return loadConfig(os.Getenv)
+}
+
+// loadConfig is LoadConfig reading the environment with getenv
+func loadConfig(getenv func(string) string) (*Config, error) {
+	cfg := &Config{RateLimits: defaultRateLimits()}
+	if path := getenv("CONFIG_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if err := cfg.read(file); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+	cfg.applyDefaults()
+	return cfg, nil
+}
+
+func (cfg *Config) read(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+func (cfg *Config) applyEnv(getenv func(string) string) error {
+	set := func(field *string, name string) {
+		if value := getenv(name); value != "" {
+			*field = value
+		}
+	}
+	set(&cfg.ChannelSecret, "CHANNEL_SECRET")
+	set(&cfg.ChannelToken, "CHANNEL_TOKEN")
+	set(&cfg.AppBaseURL, "APP_BASE_URL")
+	set(&cfg.EndpointBase, "ENDPOINT_BASE")
+	set(&cfg.Port, "PORT")
+	set(&cfg.Prefix, "COMMAND_PREFIX")
+	set(&cfg.DownloadDir, "DOWNLOAD_DIR")
+	set(&cfg.CacheDir, "CACHE_DIR")
+	set(&cfg.DBPath, "DB_PATH")
+	set(&cfg.Timezone, "TIMEZONE")
+	set(&cfg.LogLevel, "LOG_LEVEL")
+	set(&cfg.LogFormat, "LOG_FORMAT")
+	set(&cfg.AdminToken, "ADMIN_TOKEN")
+	set(&cfg.DefaultLang, "DEFAULT_LANG")
+	setInt := func(field *int, name string) error {
+		if value := getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", name, value)
+			}
+			*field = n
+		}
+		return nil
+	}
+	if err := setInt(&cfg.Workers, "WORKERS"); err != nil {
+		return err
+	}
+	if err := setInt(&cfg.QueueSize, "QUEUE_SIZE"); err != nil {
+		return err
+	}
+	if err := cfg.RateLimits.applyEnv(getenv); err != nil {
+		return err
+	}
+	if value := getenv("OWNERS"); value != "" {
+		cfg.Owners = nil
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				cfg.Owners = append(cfg.Owners, id)
+			}
+		}
+	}
+
+	if cfg.Providers == nil {
+		cfg.Providers = make(map[string]ProviderConfig)
+	}
+	for name, spec := range providerSpecs {
+		provider := cfg.Providers[name]
+		if spec.keyEnv != "" {
+			set(&provider.Key, spec.keyEnv)
+		}
+		if spec.clientIDEnv != "" {
+			set(&provider.ClientID, spec.clientIDEnv)
+		}
+		set(&provider.Endpoint, spec.endpointEnv)
+		cfg.Providers[name] = provider
+	}
+	return nil
+}
+
+// applyEnv reads RATE_LIMIT_USER, RATE_LIMIT_GROUP and RATE_LIMIT_COMMANDS,
+// the last one as a list like "osu=10/1m,steam=off"
+func (rl *RateLimitConfig) applyEnv(getenv func(string) string) error {
+	set := func(field *ratelimit.Limit, name string) error {
+		if value := getenv(name); value != "" {
+			limit, err := ratelimit.ParseLimit(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*field = limit
+		}
+		return nil
+	}
+	if err := set(&rl.User, "RATE_LIMIT_USER"); err != nil {
+		return err
+	}
+	if err := set(&rl.Group, "RATE_LIMIT_GROUP"); err != nil {
+		return err
+	}
+	value := getenv("RATE_LIMIT_COMMANDS")
+	if value == "" {
+		return nil
+	}
+	if rl.Commands == nil {
+		rl.Commands = make(map[string]ratelimit.Limit)
+	}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("RATE_LIMIT_COMMANDS: %q must look like osu=10/1m", item)
+		}
+		limit, err := ratelimit.ParseLimit(parts[1])
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_COMMANDS: %w", err)
+		}
+		rl.Commands[strings.ToLower(strings.TrimSpace(parts[0]))] = limit
+	}
+	return nil
+}
+
+func (cfg *Config) applyDefaults() {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "!"
+	}
+	if cfg.DownloadDir == "" {
+		cfg.DownloadDir = filepath.Join(filepath.Dir(os.Args[0]), "line-bot")
+	}
+	if cfg.DBPath == "" {
+		cfg.DBPath = filepath.Join(filepath.Dir(cfg.DownloadDir), "tamako.db")
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "text"
+	}
+	if cfg.DefaultLang == "" {
+		cfg.DefaultLang = i18n.Default
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 8
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.Providers == nil {
+		cfg.Providers = make(map[string]ProviderConfig)
+	}
+	for name, spec := range providerSpecs {
+		provider := cfg.Providers[name]
+		if provider.Endpoint == "" {
+			provider.Endpoint = spec.defaultEndpoint
+		}
+		provider.Endpoint = strings.TrimRight(provider.Endpoint, "/")
+		cfg.Providers[name] = provider
+	}
+}
+
+// Validate reports the settings the bot can't start without
+func (cfg *Config) Validate() error {
+	var problems []string
+	if cfg.ChannelSecret == "" {
+		problems = append(problems, "CHANNEL_SECRET is not set")
+	}
+	if cfg.ChannelToken == "" {
+		problems = append(problems, "CHANNEL_TOKEN is not set")
+	}
+	if cfg.Prefix == "" || strings.IndexFunc(cfg.Prefix, unicode.IsSpace) >= 0 {
+		problems = append(problems, fmt.Sprintf("command prefix %q must not be empty or contain spaces", cfg.Prefix))
+	}
+	if cfg.AppBaseURL != "" && !isHTTPURL(cfg.AppBaseURL) {
+		problems = append(problems, fmt.Sprintf("APP_BASE_URL %q is not an http(s) URL", cfg.AppBaseURL))
+	}
+	if cfg.EndpointBase != "" && !isHTTPURL(cfg.EndpointBase) {
+		problems = append(problems, fmt.Sprintf("ENDPOINT_BASE %q is not an http(s) URL", cfg.EndpointBase))
+	}
+	for _, name := range providerNames() {
+		if endpoint := cfg.Providers[name].Endpoint; !isHTTPURL(endpoint) {
+			problems = append(problems, fmt.Sprintf("%s endpoint %q is not an http(s) URL", name, endpoint))
+		}
+	}
+	if cfg.AdminToken != "" && len(cfg.AdminToken) < minAdminTokenLength {
+		problems = append(problems, fmt.Sprintf("ADMIN_TOKEN must be at least %d characters long", minAdminTokenLength))
+	}
+	for _, id := range cfg.Owners {
+		if !isLineUserID(id) {
+			problems = append(problems, fmt.Sprintf("owner %q is not a LINE user ID", id))
+		}
+	}
+	if _, err := parseLogLevel(cfg.LogLevel); err != nil {
+		problems = append(problems, "LOG_LEVEL "+err.Error())
+	}
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT %q must be text or json", cfg.LogFormat))
+	}
+	if !i18n.Supported(cfg.DefaultLang) {
+		problems = append(problems, fmt.Sprintf("DEFAULT_LANG %q must be one of %s", cfg.DefaultLang, strings.Join(i18n.Languages, ", ")))
+	}
+	if _, err := cfg.Location(); err != nil {
+		problems = append(problems, fmt.Sprintf("TIMEZONE %q is unknown", cfg.Timezone))
+	}
+	known := make(map[string]bool)
+	for _, cmd := range (&TamakoBot{config: cfg}).commandList() {
+		known[cmd.Name] = true
+	}
+	var unknown []string
+	for name := range cfg.RateLimits.Commands {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("rate limit set for unknown command %q", name))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Location is the time zone of the schedules, the local one by default
+func (cfg *Config) Location() (*time.Location, error) {
+	if cfg.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(cfg.Timezone)
+}
+
+// Secrets returns the credentials that must never show up in the logs
+func (cfg *Config) Secrets() []string {
+	secrets := []string{cfg.ChannelSecret, cfg.ChannelToken, cfg.AdminToken}
+	for _, name := range providerNames() {
+		secrets = append(secrets, cfg.Providers[name].Key)
+	}
+	return secrets
+}
+
+// IsOwner reports whether a LINE user is an owner of the bot
+func (cfg *Config) IsOwner(userID string) bool {
+	for _, id := range cfg.Owners {
+		if userID != "" && id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider returns the settings of a provider
+func (cfg *Config) Provider(name string) ProviderConfig {
+	return cfg.Providers[name]
+}
+
+// ProviderConfigured reports whether a provider has the credentials it needs
+func (cfg *Config) ProviderConfigured(name string) bool {
+	_, ok := providerSpecs[name]
+	return ok && len(cfg.missingCredentials(name)) == 0
+}
+
+// missingCredentials returns the variables a provider still needs
+func (cfg *Config) missingCredentials(name string) []string {
+	spec, provider := providerSpecs[name], cfg.Providers[name]
+	var missing []string
+	if spec.clientIDEnv != "" && provider.ClientID == "" {
+		missing = append(missing, spec.clientIDEnv)
+	}
+	if spec.keyEnv != "" && provider.Key == "" {
+		missing = append(missing, spec.keyEnv)
+	}
+	return missing
+}
+
+// MissingProviders returns the providers of cmd that are not configured
+func (cfg *Config) MissingProviders(cmd *Command) []string {
+	var missing []string
+	for _, name := range cmd.Providers {
+		if !cfg.ProviderConfigured(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providerSpecs))
+	for name := range providerSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// runConfigCheck validates the config and lists the commands disabled because
+// their provider credentials are missing. It returns false when the bot
+// can't start with this config.
+func runConfigCheck(out io.Writer, getenv func(string) string) bool {
+	cfg, err := loadConfig(getenv)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return false
+	}
+	ok := true
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(out, err)
+		ok = false
+	} else {
+		fmt.Fprintln(out, "config is valid")
+	}
+
+	fmt.Fprintln(out, "\nproviders:")
+	for _, name := range providerNames() {
+		status := "configured"
+		if !cfg.ProviderConfigured(name) {
+			status = "missing " + strings.Join(cfg.missingCredentials(name), ", ")
+		}
+		fmt.Fprintf(out, "  %-10s %s (%s)\n", name, status, cfg.Providers[name].Endpoint)
+	}
+
+	fmt.Fprintf(out, "\nrate limits:\n  user       %s\n  group      %s\n", cfg.RateLimits.User, cfg.RateLimits.Group)
+
+	fmt.Fprintf(out, "\nowners: %d\n", len(cfg.Owners))
+	fmt.Fprintf(out, "default language: %s\n", i18n.Name(cfg.DefaultLang))
+	if cfg.AdminToken == "" {
+		fmt.Fprintln(out, "admin API: off, ADMIN_TOKEN is not set")
+	} else {
+		fmt.Fprintln(out, "admin API: on")
+	}
+
+	app := &TamakoBot{config: cfg}
+	fmt.Fprintln(out, "\ncommands:")
+	for _, cmd := range app.commandList() {
+		status := "enabled"
+		if missing := cfg.MissingProviders(cmd); len(missing) > 0 {
+			status = "disabled, missing " + strings.Join(missing, ", ")
+		}
+		if limit, ok := cfg.RateLimits.Commands[cmd.Name]; ok {
+			status += ", limited to " + limit.String()
+		}
+		fmt.Fprintf(out, "  %s%-10s %s\n", cfg.Prefix, cmd.Name, status)
+	}
+	return ok
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/ratelimit"
+)
+
+// fakeEnv is a getenv reading a map
+func fakeEnv(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+// configFile writes a config file and returns its path
+func configFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := configFile(t, `{
+		"channel_secret": "file-secret",
+		"prefix": "?",
+		"port": "8080",
+		"workers": 4,
+		"owners": ["U00000000000000000000000000000001"],
+		"providers": {"steam": {"key": "file-key", "endpoint": "https://steam.example/"}},
+		"rate_limits": {"user": "3/1m", "commands": {"osu": "off"}}
+	}`)
+	cfg, err := loadConfig(fakeEnv(map[string]string{
+		"CONFIG_FILE":         path,
+		"CHANNEL_SECRET":      "env-secret",
+		"COMMAND_PREFIX":      ".",
+		"WORKERS":             "2",
+		"STEAM_API_KEY":       "env-key",
+		"OWNERS":              " U00000000000000000000000000000002 ,,",
+		"RATE_LIMIT_GROUP":    "50/1h",
+		"RATE_LIMIT_COMMANDS": "STEAM=1/1s, dota=off",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"env over file", cfg.ChannelSecret, "env-secret"},
+		{"env prefix", cfg.Prefix, "."},
+		{"file only", cfg.Port, "8080"},
+		{"env number", cfg.Workers, 2},
+		{"default", cfg.QueueSize, 100},
+		{"env provider key", cfg.Providers[providerSteam].Key, "env-key"},
+		{"file endpoint", cfg.Providers[providerSteam].Endpoint, "https://steam.example"},
+		{"default endpoint", cfg.Providers[providerKitsu].Endpoint, "https://kitsu.io/api/edge"},
+		{"env owners", cfg.Owners, []string{"U00000000000000000000000000000002"}},
+		{"file user limit", cfg.RateLimits.User, ratelimit.Limit{Count: 3, Per: time.Minute}},
+		{"env group limit", cfg.RateLimits.Group, ratelimit.Limit{Count: 50, Per: time.Hour}},
+		{"env command limits", cfg.RateLimits.Commands, map[string]ratelimit.Limit{
+			"osu":   {},
+			"steam": {Count: 1, Per: time.Second},
+			"dota":  {},
+		}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestLoadConfigWithoutFile(t *testing.T) {
+	cfg, err := loadConfig(fakeEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Prefix != "!" || cfg.RateLimits.User != defaultRateLimits().User || cfg.LogLevel != "info" {
+		t.Errorf("got %+v, want the defaults", cfg)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing file", map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "none.json")}, "none.json"},
+		{"unknown field", map[string]string{"CONFIG_FILE": configFile(t, `{"prefx": "?"}`)}, `unknown field "prefx"`},
+		{"bad limit in the file", map[string]string{"CONFIG_FILE": configFile(t, `{"rate_limits": {"user": "many"}}`)}, `rate limit "many"`},
+		{"bad number", map[string]string{"WORKERS": "eight"}, `WORKERS: "eight" is not a number`},
+		{"bad user limit", map[string]string{"RATE_LIMIT_USER": "5"}, "RATE_LIMIT_USER: rate limit"},
+		{"bad group limit", map[string]string{"RATE_LIMIT_GROUP": "x/1m"}, "RATE_LIMIT_GROUP: rate limit"},
+		{"command without limit", map[string]string{"RATE_LIMIT_COMMANDS": "osu"}, `RATE_LIMIT_COMMANDS: "osu" must look like`},
+		{"bad command limit", map[string]string{"RATE_LIMIT_COMMANDS": "osu=10/1m,steam=10/fortnight"}, "RATE_LIMIT_COMMANDS: rate limit"},
+	}
+	for _, tt := range tests {
+		_, err := loadConfig(fakeEnv(tt.env))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error with %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		cfg, err := loadConfig(fakeEnv(map[string]string{"CHANNEL_SECRET": "secret", "CHANNEL_TOKEN": "token"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		change func(cfg *Config)
+		want   string
+	}{
+		{func(cfg *Config) { cfg.ChannelSecret = "" }, "CHANNEL_SECRET is not set"},
+		{func(cfg *Config) { cfg.Prefix = "! " }, "must not be empty or contain spaces"},
+		{func(cfg *Config) { cfg.AppBaseURL = "example.com" }, "APP_BASE_URL"},
+		{func(cfg *Config) { cfg.Providers[providerOsu] = ProviderConfig{Endpoint: "ftp://osu"} }, "osu endpoint"},
+		{func(cfg *Config) { cfg.AdminToken = "short" }, "ADMIN_TOKEN must be at least"},
+		{func(cfg *Config) { cfg.Owners = []string{"someone"} }, `owner "someone"`},
+		{func(cfg *Config) { cfg.LogLevel = "loud" }, "LOG_LEVEL"},
+		{func(cfg *Config) { cfg.LogFormat = "xml" }, "LOG_FORMAT"},
+		{func(cfg *Config) { cfg.DefaultLang = "xx" }, "DEFAULT_LANG"},
+		{func(cfg *Config) { cfg.Timezone = "Mars/Olympus" }, "TIMEZONE"},
+		{func(cfg *Config) { cfg.RateLimits.Commands["nope"] = ratelimit.Limit{} }, `unknown command "nope"`},
+	}
+	for _, tt := range tests {
+		cfg := valid()
+		tt.change(cfg)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want an error with %q", err, tt.want)
+		}
+	}
+}
+
+func TestMissingProviders(t *testing.T) {
+	cfg, err := loadConfig(fakeEnv(map[string]string{"STEAM_API_KEY": "key", "OSU_CLIENT_ID": "id"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		providers []string
+		want      []string
+	}{
+		{nil, nil},
+		{[]string{providerSteam, providerOpenDota}, nil},
+		{[]string{providerOsu}, []string{providerOsu}},
+		{[]string{providerGiantBomb, providerKitsu, providerUrban}, []string{providerGiantBomb, providerUrban}},
+		{[]string{"unknown"}, []string{"unknown"}},
+	}
+	for _, tt := range tests {
+		if got := cfg.MissingProviders(&Command{Providers: tt.providers}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MissingProviders(%q) = %q, want %q", tt.providers, got, tt.want)
+		}
+	}
+	if got := cfg.missingCredentials(providerOsu); !reflect.DeepEqual(got, []string{"OSU_CLIENT_SECRET"}) {
+		t.Errorf("osu misses %q, want OSU_CLIENT_SECRET", got)
+	}
+}
+
+func TestRunConfigCheck(t *testing.T) {
+	var out bytes.Buffer
+	ok := runConfigCheck(&out, fakeEnv(map[string]string{
+		"CHANNEL_SECRET":      "secret",
+		"CHANNEL_TOKEN":       "token",
+		"STEAM_API_KEY":       "key",
+		"RATE_LIMIT_COMMANDS": "steam=2/1m",
+	}))
+	if !ok {
+		t.Fatalf("the config is refused:\n%s", &out)
+	}
+	for _, want := range []string{
+		"config is valid",
+		"steam      configured",
+		"osu        missing OSU_CLIENT_ID, OSU_CLIENT_SECRET",
+		"!steam      enabled, limited to 2/1m0s",
+		"!osu        disabled, missing osu",
+		"admin API: off",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the report has no %q:\n%s", want, &out)
+		}
+	}
+
+	out.Reset()
+	if runConfigCheck(&out, fakeEnv(nil)) || !strings.Contains(out.String(), "CHANNEL_SECRET is not set") {
+		t.Errorf("a config without credentials passes:\n%s", &out)
+	}
+	out.Reset()
+	if runConfigCheck(&out, fakeEnv(map[string]string{"WORKERS": "x"})) {
+		t.Errorf("a config that can't be read passes:\n%s", &out)
+	}
+}
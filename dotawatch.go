@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/store"
+)
+
+// dotaWatchInterval is how often the watched players are polled
+const dotaWatchInterval = 2 * time.Minute
+
+// maxDotaAnnounce is the most matches announced at once for a player, e.g.
+// after the bot was down for a while
+const maxDotaAnnounce = 3
+
+// dotaAccountID resolves a steam vanity name to the 32-bit account ID of
+// OpenDota, or "" when there is no such player
+func (app *TamakoBot) dotaAccountID(ctx context.Context, vanity string) (string, error) {
+	var steam Steam
+	if err := app.http.GetJSON(ctx, "Steam", app.steamVanityURL(vanity), nil, &steam); err != nil {
+		return "", err
+	}
+	if steam.Response.Steamid == "" {
+		return "", nil
+	}
+	return convert32bit(steam.Response.Steamid), nil
+}
+
+// dotaRecentMatches returns the recent matches of a player, newest first
+func (app *TamakoBot) dotaRecentMatches(ctx context.Context, accountID string) ([]DotaMatch, error) {
+	var matches []DotaMatch
+	url := app.providerURL(providerOpenDota, "/players/"+accountID+"/recentMatches")
+	if err := app.http.GetJSON(ctx, "OpenDota", url, nil, &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func (app *TamakoBot) dotaWatchCommand(req *CommandRequest, vanity string) error {
+	accountID, err := app.dotaAccountID(req.Context(), vanity)
+	if err != nil {
+		return err
+	}
+	if accountID == "" {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Dota 2 information not found"))
+	}
+	matches, err := app.dotaRecentMatches(req.Context(), accountID)
+	if err != nil {
+		return err
+	}
+	// only the matches played from now on are announced
+	lastMatchID := 0
+	if len(matches) > 0 {
+		lastMatchID = matches[0].Match_id
+	}
+	if err := app.store.WatchDota(accountID, sourceID(req.Source), vanity, lastMatchID); err != nil {
+		return err
+	}
+	return app.replyText(req.ReplyToken, tr(req.Context(), "Watching %s, new Dota 2 matches will be posted here", vanity))
+}
+
+func (app *TamakoBot) dotaUnwatchCommand(req *CommandRequest, vanity string) error {
+	accountID := ""
+	if vanity != "" {
+		var err error
+		accountID, err = app.dotaAccountID(req.Context(), vanity)
+		if err != nil {
+			return err
+		}
+		if accountID == "" {
+			return app.replyText(req.ReplyToken, tr(req.Context(), "Dota 2 information not found"))
+		}
+	}
+	removed, err := app.store.UnwatchDota(sourceID(req.Source), accountID)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "No Dota 2 players watched here"))
+	}
+	return app.replyText(req.ReplyToken, tr(req.Context(), "Stopped watching %s", strings.Join(removed, ", ")))
+}
+
+// pollDotaWatches pushes the matches the watched players finished since the
+// last poll to the chats watching them
+func (app *TamakoBot) pollDotaWatches(ctx context.Context, now time.Time) {
+	watches, err := app.store.DotaWatches()
+	if err != nil {
+		slog.ErrorContext(ctx, "Dota watches", "err", err)
+		return
+	}
+	for _, watch := range watches {
+		if ctx.Err() != nil {
+			return
+		}
+		ctx := withLogAttrs(ctx, "dota_account", watch.AccountID)
+		matches, err := app.dotaRecentMatches(ctx, watch.AccountID)
+		if err != nil {
+			slog.WarnContext(ctx, "Dota watch poll", "err", err)
+			continue
+		}
+		var fresh []DotaMatch
+		for _, match := range matches {
+			if match.Match_id > watch.LastMatchID {
+				fresh = append(fresh, match)
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		if len(fresh) > maxDotaAnnounce {
+			fresh = fresh[:maxDotaAnnounce]
+		}
+		// oldest first, so the chat reads them in the order they were played
+		for i := len(fresh) - 1; i >= 0; i-- {
+			for _, chatID := range watch.ChatIDs() {
+				vanity := watch.Chats[chatID]
+				ctx := withLanguage(ctx, app.chatLanguage(chatID))
+				bubble := dotaMatchBubble(ctx, vanity, fresh[i])
+				if err := app.pushFlex(chatID, tr(ctx, "Dota 2 match of %s", vanity), bubble); err != nil {
+					slog.ErrorContext(ctx, "Dota watch push", "chat", chatID, "err", err)
+				}
+			}
+		}
+		// nobody watches the player anymore when it was unwatched meanwhile
+		if err := app.store.SetDotaLastMatch(watch.AccountID, fresh[0].Match_id); err != nil && !errors.Is(err, store.ErrNotFound) {
+			slog.ErrorContext(ctx, "Dota watch last match", "err", err)
+		}
+	}
+}
+
+// dotaMatchBubble is the result card of a finished match
+func dotaMatchBubble(ctx context.Context, player string, match DotaMatch) *flex.Bubble {
+	duration := fmt.Sprintf("%d:%02d", match.Duration/60, match.Duration%60)
+	result, color := tr(ctx, "Lost in %s", duration), "#c23c2a"
+	if match.Won() {
+		result, color = tr(ctx, "Won in %s", duration), "#5a9c2a"
+	}
+
+	header := flex.VBox(
+		&flex.Text{Text: defaultValue(player), Weight: "bold", Size: "md", Color: "#ffffff", Wrap: true},
+		&flex.Text{Text: result, Size: "sm", Color: "#ffffff"},
+	)
+	header.BackgroundColor = color
+	header.PaddingAll = "13px"
+
+	body := flex.VBox(
+		detailRow(tr(ctx, "Hero"), hero_id_to_names(strconv.Itoa(match.Hero_id))),
+		detailRow("K/D/A", strconv.Itoa(match.Kills)+"/"+strconv.Itoa(match.Deaths)+"/"+strconv.Itoa(match.Assists)),
+		detailRow("LH/GPM", strconv.Itoa(match.Last_hits)+"/"+strconv.Itoa(match.Gold_per_min)),
+	)
+	body.Spacing = "sm"
+	body.PaddingAll = "13px"
+
+	return &flex.Bubble{
+		Size:   "kilo",
+		Header: header,
+		Body:   body,
+		Footer: flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Dotabuff"), URI: "https://www.dotabuff.com/matches/" + strconv.Itoa(match.Match_id)},
+			Style:  "primary",
+			Color:  "#a12b1f",
+		}),
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// dotaUpstream resolves every vanity name to one player, whose recent
+// matches are the IDs set with setMatches, newest first
+type dotaUpstream struct {
+	mu      sync.Mutex
+	matches []int
+	// onMatches is called when the matches are fetched
+	onMatches func()
+}
+
+func (u *dotaUpstream) setMatches(newest, oldest int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.matches = nil
+	for id := newest; id >= oldest; id-- {
+		u.matches = append(u.matches, id)
+	}
+}
+
+func (u *dotaUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.Contains(r.URL.Path, "/ResolveVanityURL/"):
+		fmt.Fprint(w, `{"response":{"steamid":"76561198047011640","success":1}}`)
+	case r.URL.Path == "/players/86745912/recentMatches":
+		u.mu.Lock()
+		matches, onMatches := u.matches, u.onMatches
+		u.mu.Unlock()
+		if onMatches != nil {
+			onMatches()
+		}
+		var list []DotaMatch
+		for _, id := range matches {
+			list = append(list, DotaMatch{Match_id: id, Duration: 1800, Kills: 10, Hero_id: 1})
+		}
+		json.NewEncoder(w).Encode(list)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// pushedMatches returns the matches pushed since before, as chat:match
+func pushedMatches(t *testing.T, messenger *RecordingMessenger, before int) []string {
+	t.Helper()
+	var pushed []string
+	for _, push := range messenger.Replies()[before:] {
+		for _, message := range push.Messages {
+			raw, err := json.Marshal(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			i := bytes.Index(raw, []byte("dotabuff.com/matches/"))
+			if i < 0 {
+				t.Fatalf("pushed %s", raw)
+			}
+			id := raw[i+len("dotabuff.com/matches/"):]
+			pushed = append(pushed, push.To+":"+string(id[:bytes.IndexByte(id, '"')]))
+		}
+	}
+	return pushed
+}
+
+func TestDotaWatch(t *testing.T) {
+	upstream := &dotaUpstream{}
+	upstream.setMatches(105, 101)
+	app, messenger := newTestBot(t, upstream, func(cfg *Config) {
+		cfg.Owners = []string{"U1"}
+		cfg.Providers[providerSteam] = ProviderConfig{Key: "key", Endpoint: cfg.Providers[providerSteam].Endpoint}
+	})
+	// every poll must see the upstream as it is
+	app.http.Cache = nil
+	ctx := context.Background()
+	poll := func(want ...string) {
+		t.Helper()
+		before := len(messenger.Replies())
+		app.pollDotaWatches(ctx, time.Now())
+		if got := pushedMatches(t, messenger, before); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("pushed %q, want %q", got, want)
+		}
+	}
+
+	for _, chat := range []string{"G1", "G2"} {
+		source := &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: chat, UserID: "U1"}
+		if replies := sendTextFrom(t, app, messenger, source, "!dota watch arteezy"); len(replies) != 1 || !strings.HasPrefix(replies[0], "Watching arteezy") {
+			t.Fatalf("got %q", replies)
+		}
+	}
+	// the matches played before the watch aren't announced
+	poll()
+
+	upstream.setMatches(106, 101)
+	poll("G1:106", "G2:106")
+	poll()
+
+	// after a long downtime only the 3 newest matches are announced, oldest
+	// first
+	upstream.setMatches(112, 103)
+	poll("G1:110", "G2:110", "G1:111", "G2:111", "G1:112", "G2:112")
+	poll()
+
+	// the players unwatched during a poll are left alone
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	upstream.setMatches(113, 103)
+	upstream.onMatches = func() {
+		app.store.UnwatchDota("G1", "")
+		app.store.UnwatchDota("G2", "")
+	}
+	poll("G1:113", "G2:113")
+	if strings.Contains(logs.String(), "level=ERROR") {
+		t.Errorf("an unwatch during the poll logged an error:\n%s", &logs)
+	}
+	if watches, _ := app.store.DotaWatches(); len(watches) != 0 {
+		t.Errorf("%d players watched after the unwatch", len(watches))
+	}
+}
+
+func TestDotaMatchesCachedLessThanAPoll(t *testing.T) {
+	for _, rule := range cacheRules {
+		if rule.Match == "/recentMatches" && rule.TTL >= dotaWatchInterval {
+			t.Errorf("recent matches are cached for %s, polled every %s", rule.TTL, dotaWatchInterval)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// replyTokenTTL is how long after the event a reply token is trusted. LINE
+// accepts replies for about a minute, later results are pushed instead.
+const replyTokenTTL = 50 * time.Second
+
+// eventTimeout bounds the time spent handling a single event
+const eventTimeout = 2 * time.Minute
+
+// errQueueClosed is returned by eventQueue.Enqueue after Shutdown
+var errQueueClosed = errors.New("event queue is shut down")
+
+// errQueueFull is returned by eventQueue.Enqueue when every worker is busy
+// and the queue has no room left
+var errQueueFull = errors.New("event queue is full")
+
+// eventQueue hands webhook events to a fixed number of workers so Callback
+// can answer LINE right away
+type eventQueue struct {
+	mu      sync.Mutex
+	closed  bool
+	events  chan *linebot.Event
+	workers sync.WaitGroup
+	pending sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// newEventQueue starts workers goroutines calling handle, with room for size
+// waiting events
+func newEventQueue(workers, size int, handle func(ctx context.Context, event *linebot.Event)) *eventQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &eventQueue{
+		events: make(chan *linebot.Event, size),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for event := range q.events {
+				q.run(handle, event)
+			}
+		}()
+	}
+	return q
+}
+
+// run handles one event, a panic is logged instead of killing the worker
+func (q *eventQueue) run(handle func(ctx context.Context, event *linebot.Event), event *linebot.Event) {
+	defer q.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Event panicked", "event", event.Type, "source", sourceID(event.Source), "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		}
+	}()
+	ctx, cancel := context.WithTimeout(q.ctx, eventTimeout)
+	defer cancel()
+	handle(ctx, event)
+}
+
+// Enqueue queues the event without blocking
+func (q *eventQueue) Enqueue(event *linebot.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return errQueueClosed
+	}
+	q.pending.Add(1)
+	select {
+	case q.events <- event:
+		return nil
+	default:
+		q.pending.Done()
+		return errQueueFull
+	}
+}
+
+// Len returns the number of events waiting for a worker and the room of
+// the queue
+func (q *eventQueue) Len() (queued, size int) {
+	return len(q.events), cap(q.events)
+}
+
+// Wait blocks until every queued event has been handled
+func (q *eventQueue) Wait() {
+	q.pending.Wait()
+}
+
+// Shutdown stops accepting events and waits for the queued ones to be
+// handled. When ctx ends first the running handlers are cancelled.
+func (q *eventQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// replyTarget is where the reply to an event goes when its token can't be
+// used. LINE takes a single reply per token, so once it is used the later
+// replies fail like they would without the fallback.
+type replyTarget struct {
+	to       string
+	received time.Time
+	used     bool
+}
+
+// replyFallback is the Messenger used by the handlers. A reply whose token
+// is too old, or that LINE refused, is pushed to the user, group or room the
+// event came from.
+type replyFallback struct {
+	Messenger
+	mu      sync.Mutex
+	targets map[string]replyTarget
+	now     func() time.Time
+}
+
+func newReplyFallback(messenger Messenger) *replyFallback {
+	return &replyFallback{
+		Messenger: messenger,
+		targets:   make(map[string]replyTarget),
+		now:       time.Now,
+	}
+}
+
+// track remembers where the reply to event goes, until forget is called
+func (f *replyFallback) track(event *linebot.Event) {
+	if event.ReplyToken == "" {
+		return
+	}
+	received := event.Timestamp
+	if received.IsZero() {
+		received = f.now()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets[event.ReplyToken] = replyTarget{to: sourceID(event.Source), received: received}
+}
+
+// pushTo makes every reply with replyToken a push to the given chat, for
+// command output that isn't an answer to an event
+func (f *replyFallback) pushTo(replyToken, to string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets[replyToken] = replyTarget{to: to}
+}
+
+func (f *replyFallback) forget(replyToken string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.targets, replyToken)
+}
+
+// markUsed records that the reply token was replied with
+func (f *replyFallback) markUsed(replyToken string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if target, ok := f.targets[replyToken]; ok {
+		target.used = true
+		f.targets[replyToken] = target
+	}
+}
+
+// ReplyMessage replies, or pushes when the token is too old or the reply
+// fails. A token that was already replied with is never pushed to.
+func (f *replyFallback) ReplyMessage(replyToken string, messages ...linebot.SendingMessage) error {
+	f.mu.Lock()
+	target, ok := f.targets[replyToken]
+	f.mu.Unlock()
+	if !ok || target.to == "" || target.used {
+		return f.Messenger.ReplyMessage(replyToken, messages...)
+	}
+	if f.now().Sub(target.received) < replyTokenTTL {
+		err := f.Messenger.ReplyMessage(replyToken, messages...)
+		if err == nil {
+			f.markUsed(replyToken)
+			return nil
+		}
+		slog.Warn("Reply failed, pushing instead", "to", target.to, "err", err)
+	}
+	return f.Messenger.PushMessage(target.to, messages...)
+}
+
+// sourceID is the ID messages to the source of an event are pushed to
+func sourceID(source *linebot.EventSource) string {
+	if source == nil {
+		return ""
+	}
+	switch {
+	case source.GroupID != "":
+		return source.GroupID
+	case source.RoomID != "":
+		return source.RoomID
+	default:
+		return source.UserID
+	}
+}
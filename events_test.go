@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+func TestEventQueue(t *testing.T) {
+	handled := make(chan string, 10)
+	q := newEventQueue(2, 10, func(ctx context.Context, event *linebot.Event) {
+		if event.ReplyToken == "panic" {
+			panic("boom")
+		}
+		handled <- event.ReplyToken
+	})
+	for _, token := range []string{"a", "panic", "b"} {
+		if err := q.Enqueue(&linebot.Event{ReplyToken: token}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 2 {
+		t.Errorf("%d events handled, want the 2 that didn't panic", len(handled))
+	}
+	if err := q.Enqueue(&linebot.Event{}); err != errQueueClosed {
+		t.Errorf("Enqueue after Shutdown = %v, want errQueueClosed", err)
+	}
+}
+
+func TestEventQueueFull(t *testing.T) {
+	q := newEventQueue(0, 1, func(ctx context.Context, event *linebot.Event) {})
+	defer q.Shutdown(context.Background())
+	if err := q.Enqueue(&linebot.Event{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(&linebot.Event{}); err != errQueueFull {
+		t.Errorf("Enqueue = %v, want errQueueFull", err)
+	}
+}
+
+func TestCallbackAsksForRedelivery(t *testing.T) {
+	app, _ := newTestBot(t, http.NotFoundHandler(), nil)
+	event := map[string]interface{}{
+		"type":       "message",
+		"replyToken": "reply-token",
+		"timestamp":  time.Now().UnixNano() / int64(time.Millisecond),
+		"source":     map[string]interface{}{"type": "user", "userId": "U1"},
+		"message":    map[string]interface{}{"type": "text", "id": "1", "text": "hello"},
+	}
+	if code, err := deliverEvent(app, app.config.ChannelSecret, event); err != nil || code != http.StatusOK {
+		t.Fatalf("got %d, %v, want 200", code, err)
+	}
+	app.events.Shutdown(context.Background())
+	if code, err := deliverEvent(app, app.config.ChannelSecret, event); err != nil || code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, %v with the queue closed, want 503", code, err)
+	}
+}
+
+func TestReplyFallback(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	messenger := NewRecordingMessenger()
+	f := newReplyFallback(messenger)
+	f.now = func() time.Time { return now }
+	event := func(token string) *linebot.Event {
+		return &linebot.Event{ReplyToken: token, Timestamp: now, Source: &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "G1"}}
+	}
+	text := linebot.NewTextMessage("hi")
+	last := func() RecordedReply {
+		replies := messenger.Replies()
+		return replies[len(replies)-1]
+	}
+
+	// a fresh token is replied with, once
+	f.track(event("fresh"))
+	if err := f.ReplyMessage("fresh", text); err != nil || last().ReplyToken != "fresh" {
+		t.Fatalf("got %v %+v, want a reply", err, last())
+	}
+	messenger.SetReplyError(errors.New("invalid reply token"))
+	if err := f.ReplyMessage("fresh", text); err == nil {
+		t.Error("a second reply with a used token should fail")
+	}
+	if n := len(messenger.Replies()); n != 1 {
+		t.Errorf("the used token was pushed to, %d messages sent", n)
+	}
+
+	// a rejected token is pushed to the group
+	f.track(event("rejected"))
+	if err := f.ReplyMessage("rejected", text); err != nil || last().To != "G1" {
+		t.Errorf("got %v %+v, want a push to G1", err, last())
+	}
+	messenger.SetReplyError(nil)
+
+	// an old token isn't even tried
+	f.track(event("old"))
+	now = now.Add(replyTokenTTL)
+	if err := f.ReplyMessage("old", text); err != nil || last().To != "G1" {
+		t.Errorf("got %v %+v, want a push to G1", err, last())
+	}
+
+	// the tokens of events that aren't tracked are replied with as they are
+	f.forget("old")
+	if err := f.ReplyMessage("old", text); err != nil || last().ReplyToken != "old" {
+		t.Errorf("got %v %+v, want a plain reply", err, last())
+	}
+}
@@ -0,0 +1,540 @@
+// Package flex builds LINE Flex Message contents with typed Go values, so
+// user text is always escaped by encoding/json instead of being pasted into
+// JSON strings.
+//
+// Bubble and Carousel implement linebot.FlexContainer and can be given to
+// linebot.NewFlexMessage as they are, after checking them with Validate.
+package flex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of the Messaging API
+const (
+	MaxAltTextLength   = 400
+	MaxCarouselBubbles = 12
+	MaxURLLength       = 1000
+	MaxActionLabel     = 40
+)
+
+// Container is a bubble or a carousel
+type Container interface {
+	FlexContainer()
+	validate() error
+}
+
+// Component is anything that can be placed inside a box
+type Component interface {
+	component()
+	validate() error
+}
+
+// Action is what happens when a button is tapped
+type Action interface {
+	action()
+	validate() error
+}
+
+// Int returns a pointer to n, for the optional Flex fields
+func Int(n int) *int {
+	return &n
+}
+
+// AltText shortens text to MaxAltTextLength characters, ending it with "…"
+// when it had to be cut, for alt texts made of user text
+func AltText(text string) string {
+	if utf8.RuneCountInString(text) <= MaxAltTextLength {
+		return text
+	}
+	return string([]rune(text)[:MaxAltTextLength-1]) + "…"
+}
+
+// Validate checks the alt text and the container against the LINE limits
+func Validate(altText string, c Container) error {
+	if strings.TrimSpace(altText) == "" {
+		return errors.New("flex: alt text is empty")
+	}
+	if n := utf8.RuneCountInString(altText); n > MaxAltTextLength {
+		return fmt.Errorf("flex: alt text has %d characters, the limit is %d", n, MaxAltTextLength)
+	}
+	if c == nil {
+		return errors.New("flex: container is nil")
+	}
+	return c.validate()
+}
+
+// Carousel is a list of bubbles scrolled horizontally
+type Carousel struct {
+	Contents []*Bubble
+}
+
+// FlexContainer implements linebot.FlexContainer
+func (*Carousel) FlexContainer() {}
+
+// Add appends bubbles to the carousel
+func (c *Carousel) Add(bubbles ...*Bubble) *Carousel {
+	c.Contents = append(c.Contents, bubbles...)
+	return c
+}
+
+// MarshalJSON method of Carousel
+func (c *Carousel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type     string    `json:"type"`
+		Contents []*Bubble `json:"contents"`
+	}{
+		Type:     "carousel",
+		Contents: c.Contents,
+	})
+}
+
+func (c *Carousel) validate() error {
+	if len(c.Contents) == 0 {
+		return errors.New("flex: carousel has no bubbles")
+	}
+	if len(c.Contents) > MaxCarouselBubbles {
+		return fmt.Errorf("flex: carousel has %d bubbles, the limit is %d", len(c.Contents), MaxCarouselBubbles)
+	}
+	for i, bubble := range c.Contents {
+		if bubble == nil {
+			return fmt.Errorf("flex: bubble %d is nil", i)
+		}
+		if err := bubble.validate(); err != nil {
+			return fmt.Errorf("bubble %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Bubble is a single card
+type Bubble struct {
+	Size   string
+	Header *Box
+	Hero   *Image
+	Body   *Box
+	Footer *Box
+	Styles *BubbleStyles
+}
+
+// BubbleStyles sets the style of the blocks of a bubble
+type BubbleStyles struct {
+	Header *BlockStyle `json:"header,omitempty"`
+	Hero   *BlockStyle `json:"hero,omitempty"`
+	Body   *BlockStyle `json:"body,omitempty"`
+	Footer *BlockStyle `json:"footer,omitempty"`
+}
+
+// BlockStyle is the style of one block of a bubble
+type BlockStyle struct {
+	BackgroundColor string `json:"backgroundColor,omitempty"`
+	Separator       bool   `json:"separator,omitempty"`
+	SeparatorColor  string `json:"separatorColor,omitempty"`
+}
+
+// FlexContainer implements linebot.FlexContainer
+func (*Bubble) FlexContainer() {}
+
+// MarshalJSON method of Bubble
+func (b *Bubble) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type   string        `json:"type"`
+		Size   string        `json:"size,omitempty"`
+		Header *Box          `json:"header,omitempty"`
+		Hero   *Image        `json:"hero,omitempty"`
+		Body   *Box          `json:"body,omitempty"`
+		Footer *Box          `json:"footer,omitempty"`
+		Styles *BubbleStyles `json:"styles,omitempty"`
+	}{
+		Type:   "bubble",
+		Size:   b.Size,
+		Header: b.Header,
+		Hero:   b.Hero,
+		Body:   b.Body,
+		Footer: b.Footer,
+		Styles: b.Styles,
+	})
+}
+
+func (b *Bubble) validate() error {
+	if b.Header == nil && b.Hero == nil && b.Body == nil && b.Footer == nil {
+		return errors.New("flex: bubble is empty")
+	}
+	for _, block := range []Component{b.Header, b.Body, b.Footer} {
+		if box, ok := block.(*Box); ok && box != nil {
+			if err := box.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if b.Hero != nil {
+		return b.Hero.validate()
+	}
+	return nil
+}
+
+// Box lays out its contents vertically, horizontally or on a baseline
+type Box struct {
+	Layout          string
+	Contents        []Component
+	Flex            *int
+	Spacing         string
+	Margin          string
+	PaddingAll      string
+	PaddingTop      string
+	BackgroundColor string
+	CornerRadius    string
+}
+
+// VBox returns a vertical box
+func VBox(contents ...Component) *Box {
+	return &Box{Layout: "vertical", Contents: contents}
+}
+
+// HBox returns a horizontal box
+func HBox(contents ...Component) *Box {
+	return &Box{Layout: "horizontal", Contents: contents}
+}
+
+// BaselineBox returns a box whose contents are aligned on their baseline
+func BaselineBox(contents ...Component) *Box {
+	return &Box{Layout: "baseline", Contents: contents}
+}
+
+// Add appends components to the box
+func (b *Box) Add(contents ...Component) *Box {
+	b.Contents = append(b.Contents, contents...)
+	return b
+}
+
+func (*Box) component() {}
+
+// MarshalJSON method of Box
+func (b *Box) MarshalJSON() ([]byte, error) {
+	contents := b.Contents
+	if contents == nil {
+		contents = []Component{}
+	}
+	return json.Marshal(&struct {
+		Type            string      `json:"type"`
+		Layout          string      `json:"layout"`
+		Contents        []Component `json:"contents"`
+		Flex            *int        `json:"flex,omitempty"`
+		Spacing         string      `json:"spacing,omitempty"`
+		Margin          string      `json:"margin,omitempty"`
+		PaddingAll      string      `json:"paddingAll,omitempty"`
+		PaddingTop      string      `json:"paddingTop,omitempty"`
+		BackgroundColor string      `json:"backgroundColor,omitempty"`
+		CornerRadius    string      `json:"cornerRadius,omitempty"`
+	}{
+		Type:            "box",
+		Layout:          b.Layout,
+		Contents:        contents,
+		Flex:            b.Flex,
+		Spacing:         b.Spacing,
+		Margin:          b.Margin,
+		PaddingAll:      b.PaddingAll,
+		PaddingTop:      b.PaddingTop,
+		BackgroundColor: b.BackgroundColor,
+		CornerRadius:    b.CornerRadius,
+	})
+}
+
+func (b *Box) validate() error {
+	switch b.Layout {
+	case "vertical", "horizontal", "baseline":
+	default:
+		return fmt.Errorf("flex: unknown box layout %q", b.Layout)
+	}
+	for _, c := range b.Contents {
+		if c == nil {
+			return errors.New("flex: box contains a nil component")
+		}
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Text is a text component, Text must not be empty
+type Text struct {
+	Text   string
+	Flex   *int
+	Margin string
+	Size   string
+	Align  string
+	Wrap   bool
+	Weight string
+	Color  string
+}
+
+func (*Text) component() {}
+
+// MarshalJSON method of Text
+func (t *Text) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type   string `json:"type"`
+		Text   string `json:"text"`
+		Flex   *int   `json:"flex,omitempty"`
+		Margin string `json:"margin,omitempty"`
+		Size   string `json:"size,omitempty"`
+		Align  string `json:"align,omitempty"`
+		Wrap   bool   `json:"wrap,omitempty"`
+		Weight string `json:"weight,omitempty"`
+		Color  string `json:"color,omitempty"`
+	}{
+		Type:   "text",
+		Text:   t.Text,
+		Flex:   t.Flex,
+		Margin: t.Margin,
+		Size:   t.Size,
+		Align:  t.Align,
+		Wrap:   t.Wrap,
+		Weight: t.Weight,
+		Color:  t.Color,
+	})
+}
+
+func (t *Text) validate() error {
+	if t.Text == "" {
+		return errors.New("flex: text is empty")
+	}
+	return nil
+}
+
+// Image is an image component, URL must be HTTPS
+type Image struct {
+	URL         string
+	Flex        *int
+	Margin      string
+	Size        string
+	AspectRatio string
+	AspectMode  string
+}
+
+func (*Image) component() {}
+
+// MarshalJSON method of Image
+func (i *Image) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type        string `json:"type"`
+		URL         string `json:"url"`
+		Flex        *int   `json:"flex,omitempty"`
+		Margin      string `json:"margin,omitempty"`
+		Size        string `json:"size,omitempty"`
+		AspectRatio string `json:"aspectRatio,omitempty"`
+		AspectMode  string `json:"aspectMode,omitempty"`
+	}{
+		Type:        "image",
+		URL:         i.URL,
+		Flex:        i.Flex,
+		Margin:      i.Margin,
+		Size:        i.Size,
+		AspectRatio: i.AspectRatio,
+		AspectMode:  i.AspectMode,
+	})
+}
+
+func (i *Image) validate() error {
+	if !strings.HasPrefix(i.URL, "https://") {
+		return fmt.Errorf("flex: image url %q is not https", i.URL)
+	}
+	if len(i.URL) > MaxURLLength {
+		return fmt.Errorf("flex: image url is longer than %d characters", MaxURLLength)
+	}
+	return nil
+}
+
+// Button is a button component
+type Button struct {
+	Action Action
+	Flex   *int
+	Margin string
+	Height string
+	Style  string
+	Color  string
+}
+
+func (*Button) component() {}
+
+// MarshalJSON method of Button
+func (b *Button) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type   string `json:"type"`
+		Action Action `json:"action"`
+		Flex   *int   `json:"flex,omitempty"`
+		Margin string `json:"margin,omitempty"`
+		Height string `json:"height,omitempty"`
+		Style  string `json:"style,omitempty"`
+		Color  string `json:"color,omitempty"`
+	}{
+		Type:   "button",
+		Action: b.Action,
+		Flex:   b.Flex,
+		Margin: b.Margin,
+		Height: b.Height,
+		Style:  b.Style,
+		Color:  b.Color,
+	})
+}
+
+func (b *Button) validate() error {
+	if b.Action == nil {
+		return errors.New("flex: button has no action")
+	}
+	return b.Action.validate()
+}
+
+// Separator draws a line between components
+type Separator struct {
+	Margin string
+	Color  string
+}
+
+func (*Separator) component() {}
+
+// MarshalJSON method of Separator
+func (s *Separator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type   string `json:"type"`
+		Margin string `json:"margin,omitempty"`
+		Color  string `json:"color,omitempty"`
+	}{
+		Type:   "separator",
+		Margin: s.Margin,
+		Color:  s.Color,
+	})
+}
+
+func (*Separator) validate() error { return nil }
+
+// Spacer adds space between components
+type Spacer struct {
+	Size string
+}
+
+func (*Spacer) component() {}
+
+// MarshalJSON method of Spacer
+func (s *Spacer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type string `json:"type"`
+		Size string `json:"size,omitempty"`
+	}{
+		Type: "spacer",
+		Size: s.Size,
+	})
+}
+
+func (*Spacer) validate() error { return nil }
+
+// URIAction opens a link
+type URIAction struct {
+	Label string
+	URI   string
+}
+
+func (*URIAction) action() {}
+
+// MarshalJSON method of URIAction
+func (a *URIAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type  string `json:"type"`
+		Label string `json:"label"`
+		URI   string `json:"uri"`
+	}{
+		Type:  "uri",
+		Label: a.Label,
+		URI:   a.URI,
+	})
+}
+
+func (a *URIAction) validate() error {
+	if err := validateLabel(a.Label); err != nil {
+		return err
+	}
+	if a.URI == "" {
+		return errors.New("flex: uri action has no uri")
+	}
+	if len(a.URI) > MaxURLLength {
+		return fmt.Errorf("flex: uri is longer than %d characters", MaxURLLength)
+	}
+	return nil
+}
+
+// MessageAction sends a text as the user when tapped
+type MessageAction struct {
+	Label string
+	Text  string
+}
+
+func (*MessageAction) action() {}
+
+// MarshalJSON method of MessageAction
+func (a *MessageAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type  string `json:"type"`
+		Label string `json:"label"`
+		Text  string `json:"text"`
+	}{
+		Type:  "message",
+		Label: a.Label,
+		Text:  a.Text,
+	})
+}
+
+func (a *MessageAction) validate() error {
+	if err := validateLabel(a.Label); err != nil {
+		return err
+	}
+	if a.Text == "" {
+		return errors.New("flex: message action has no text")
+	}
+	return nil
+}
+
+// PostbackAction sends a postback event when tapped
+type PostbackAction struct {
+	Label string
+	Data  string
+}
+
+func (*PostbackAction) action() {}
+
+// MarshalJSON method of PostbackAction
+func (a *PostbackAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type  string `json:"type"`
+		Label string `json:"label"`
+		Data  string `json:"data"`
+	}{
+		Type:  "postback",
+		Label: a.Label,
+		Data:  a.Data,
+	})
+}
+
+func (a *PostbackAction) validate() error {
+	if err := validateLabel(a.Label); err != nil {
+		return err
+	}
+	if a.Data == "" {
+		return errors.New("flex: postback action has no data")
+	}
+	return nil
+}
+
+func validateLabel(label string) error {
+	if label == "" {
+		return errors.New("flex: action has no label")
+	}
+	if n := utf8.RuneCountInString(label); n > MaxActionLabel {
+		return fmt.Errorf("flex: action label has %d characters, the limit is %d", n, MaxActionLabel)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package flex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestValidate(t *testing.T) {
+	text := func(s string) *Bubble { return &Bubble{Body: VBox(&Text{Text: s})} }
+	button := func(a Action) *Bubble { return &Bubble{Footer: VBox(&Button{Action: a})} }
+	bubbles := func(n int) *Carousel {
+		c := &Carousel{}
+		for i := 0; i < n; i++ {
+			c.Add(text("x"))
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		altText string
+		c       Container
+		wantErr string // empty when the container is valid
+	}{
+		{"bubble", "alt", text("hello"), ""},
+		{"full carousel", "alt", bubbles(MaxCarouselBubbles), ""},
+		{"longest alt text", strings.Repeat("あ", MaxAltTextLength), text("x"), ""},
+		{"https hero", "alt", &Bubble{Hero: &Image{URL: "https://example.com/a.jpg"}}, ""},
+		{"uri button", "alt", button(&URIAction{Label: "Open", URI: "https://example.com"}), ""},
+		{"message button", "alt", button(&MessageAction{Label: "Say", Text: "!help"}), ""},
+		{"postback button", "alt", button(&PostbackAction{Label: "Play", Data: "dmr"}), ""},
+		{"separator and spacer", "alt", &Bubble{Body: VBox(&Text{Text: "x"}, &Separator{}, &Spacer{Size: "sm"})}, ""},
+
+		{"empty alt text", " ", text("x"), "alt text is empty"},
+		{"long alt text", strings.Repeat("a", MaxAltTextLength+1), text("x"), "alt text has 401 characters"},
+		{"nil container", "alt", nil, "container is nil"},
+		{"empty carousel", "alt", &Carousel{}, "carousel has no bubbles"},
+		{"too many bubbles", "alt", bubbles(MaxCarouselBubbles + 1), "carousel has 13 bubbles"},
+		{"nil bubble", "alt", &Carousel{Contents: []*Bubble{text("x"), nil}}, "bubble 1 is nil"},
+		{"empty bubble", "alt", &Bubble{}, "bubble is empty"},
+		{"empty text", "alt", text(""), "text is empty"},
+		{"error in a carousel", "alt", &Carousel{Contents: []*Bubble{text("x"), text("")}}, "bubble 1: flex: text is empty"},
+		{"unknown layout", "alt", &Bubble{Body: &Box{Layout: "grid"}}, `unknown box layout "grid"`},
+		{"nil component", "alt", &Bubble{Body: VBox(nil)}, "nil component"},
+		{"http hero", "alt", &Bubble{Hero: &Image{URL: "http://example.com/a.jpg"}}, "is not https"},
+		{"long image url", "alt", &Bubble{Hero: &Image{URL: "https://" + strings.Repeat("a", MaxURLLength)}}, "image url is longer"},
+		{"button without action", "alt", button(nil), "button has no action"},
+		{"uri without uri", "alt", button(&URIAction{Label: "Open"}), "uri action has no uri"},
+		{"long uri", "alt", button(&URIAction{Label: "Open", URI: "https://" + strings.Repeat("a", MaxURLLength)}), "uri is longer"},
+		{"no label", "alt", button(&URIAction{URI: "https://example.com"}), "action has no label"},
+		{"long label", "alt", button(&MessageAction{Label: strings.Repeat("a", MaxActionLabel+1), Text: "x"}), "action label has 41 characters"},
+		{"message without text", "alt", button(&MessageAction{Label: "Say"}), "message action has no text"},
+		{"postback without data", "alt", button(&PostbackAction{Label: "Play"}), "postback action has no data"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.altText, tt.c)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && err == nil:
+			t.Errorf("%s: want an error with %q", tt.name, tt.wantErr)
+		case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+			t.Errorf("%s: error %q, want one with %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAltText(t *testing.T) {
+	if got := AltText("osu! information of cookiezi"); got != "osu! information of cookiezi" {
+		t.Errorf("short alt text changed to %q", got)
+	}
+	exact := strings.Repeat("a", MaxAltTextLength)
+	if got := AltText(exact); got != exact {
+		t.Errorf("alt text at the limit was cut")
+	}
+	got := AltText(strings.Repeat("漫", MaxAltTextLength+50))
+	if n := utf8.RuneCountInString(got); n != MaxAltTextLength {
+		t.Errorf("cut alt text has %d characters, want %d", n, MaxAltTextLength)
+	}
+	if !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+		t.Errorf("cut alt text %q should be valid UTF-8 ending with …", got[len(got)-10:])
+	}
+	if err := Validate(got, &Bubble{Body: VBox(&Text{Text: "x"})}); err != nil {
+		t.Errorf("cut alt text is still rejected: %v", err)
+	}
+}
+
+func TestMarshalEscapesText(t *testing.T) {
+	title := "He said \"hi\"\\\n<b>"
+	raw, err := json.Marshal(&Carousel{Contents: []*Bubble{{
+		Body:   VBox(&Text{Text: title, Wrap: true}),
+		Footer: VBox(&Button{Action: &URIAction{Label: "Open", URI: "https://example.com/?a=1&b=2"}}),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Type     string
+		Contents []struct {
+			Type string
+			Body struct {
+				Layout   string
+				Contents []struct {
+					Type string
+					Text string
+					Wrap bool
+				}
+			}
+			Footer struct {
+				Contents []struct {
+					Action struct {
+						Type, Label, URI string
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("invalid JSON %s: %v", raw, err)
+	}
+	if decoded.Type != "carousel" || len(decoded.Contents) != 1 || decoded.Contents[0].Type != "bubble" {
+		t.Fatalf("unexpected JSON %s", raw)
+	}
+	bubble := decoded.Contents[0]
+	if bubble.Body.Layout != "vertical" || bubble.Body.Contents[0].Text != title || !bubble.Body.Contents[0].Wrap {
+		t.Errorf("text didn't survive the round trip: %s", raw)
+	}
+	if action := bubble.Footer.Contents[0].Action; action.Type != "uri" || action.URI != "https://example.com/?a=1&b=2" {
+		t.Errorf("action didn't survive the round trip: %s", raw)
+	}
+	if strings.Contains(string(raw), `"hero"`) || strings.Contains(string(raw), `"header"`) {
+		t.Errorf("empty blocks should be left out: %s", raw)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// version of the bot, set at build time with
+// `go build -ldflags "-X main.version=v1.2.3"`
+var version = "dev"
+
+// pingTimeout bounds the reachability check of each provider
+const pingTimeout = 5 * time.Second
+
+// pingProviders are the providers checked by !ping, with the name shown
+var pingProviders = []struct {
+	key  string
+	name string
+}{
+	{providerSteam, "Steam"},
+	{providerOpenDota, "OpenDota"},
+	{providerOsu, "osu!"},
+	{providerKitsu, "Kitsu"},
+	{providerGiantBomb, "GiantBomb"},
+}
+
+// health tracks what /readyz and !ping report
+type health struct {
+	started  time.Time
+	draining int32
+}
+
+// drain makes /readyz fail so no new traffic is sent during the shutdown
+func (h *health) drain() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+func (h *health) isDraining() bool {
+	return atomic.LoadInt32(&h.draining) == 1
+}
+
+// healthz answers as long as the process serves HTTP
+func (app *TamakoBot) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz answers 503 while the bot shouldn't take webhooks: during the
+// shutdown, when the database doesn't answer or the event queue is full
+func (app *TamakoBot) readyz(w http.ResponseWriter, r *http.Request) {
+	var problems []string
+	if app.health.isDraining() {
+		problems = append(problems, "shutting down")
+	}
+	if err := app.store.Ping(); err != nil {
+		problems = append(problems, "database: "+err.Error())
+	}
+	if queued, size := app.events.Len(); queued >= size {
+		problems = append(problems, "event queue is full")
+	}
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, strings.Join(problems, "\n"))
+		return
+	}
+	fmt.Fprintln(w, "ready")
+}
+
+// pingResult is the reachability of one provider
+type pingResult struct {
+	name    string
+	status  int
+	elapsed time.Duration
+	err     error
+}
+
+func (r pingResult) String() string {
+	switch {
+	case r.err != nil:
+		return r.name + " : unreachable, " + r.err.Error()
+	case r.status == 0:
+		return r.name + " : not configured"
+	default:
+		return fmt.Sprintf("%s : ok in %s (HTTP %d)", r.name, r.elapsed.Round(time.Millisecond), r.status)
+	}
+}
+
+// pingProvider checks that the endpoint of a provider answers at all, any
+// HTTP status counts as reachable. It skips the cache and the retries of
+// app.http on purpose.
+func (app *TamakoBot) pingProvider(ctx context.Context, key, name string) pingResult {
+	result := pingResult{name: name}
+	if !app.config.ProviderConfigured(key) {
+		return result
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, app.config.Provider(key).Endpoint, nil)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	result.elapsed = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("no answer after %s", pingTimeout)
+		}
+		result.err = err
+		return result
+	}
+	resp.Body.Close()
+	result.status = resp.StatusCode
+	return result
+}
+
+func (app *TamakoBot) pingCommand(req *CommandRequest) error {
+	results := make([]pingResult, len(pingProviders))
+	var wg sync.WaitGroup
+	for i, p := range pingProviders {
+		wg.Add(1)
+		go func(i int, key, name string) {
+			defer wg.Done()
+			results[i] = app.pingProvider(req.Context(), key, name)
+		}(i, p.key, p.name)
+	}
+	wg.Wait()
+
+	queued, size := app.events.Len()
+	lines := []string{
+		"pong",
+		"Version : " + version,
+		"Uptime : " + time.Since(app.health.started).Round(time.Second).String(),
+		fmt.Sprintf("Events queued : %d/%d", queued, size),
+		"",
+	}
+	for _, result := range results {
+		lines = append(lines, result.String())
+	}
+	return app.replyText(req.ReplyToken, strings.Join(lines, "\n"))
+}
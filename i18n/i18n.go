@@ -0,0 +1,56 @@
+// Package i18n translates the replies of the bot. Messages are keyed by
+// their English text, which is also the fallback when a catalog misses one.
+package i18n
+
+import "fmt"
+
+// Default is the language messages are written in
+const Default = "en"
+
+// Languages are the codes of the supported languages, default first
+var Languages = []string{Default, "id", "ja"}
+
+// names are the languages as their speakers write them
+var names = map[string]string{
+	"en": "English",
+	"id": "Bahasa Indonesia",
+	"ja": "日本語",
+}
+
+// catalogs map the English messages to their translation, by language
+var catalogs = map[string]map[string]string{
+	"id": indonesian,
+	"ja": japanese,
+}
+
+// Supported reports whether lang is one of Languages
+func Supported(lang string) bool {
+	_, ok := names[lang]
+	return ok
+}
+
+// Name returns the name of a language, or its code when it is unknown
+func Name(lang string) string {
+	if name, ok := names[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// Text returns the translation of msg, or msg when there is none
+func Text(lang, msg string) string {
+	if translated, ok := catalogs[lang][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf translates format and formats it like fmt.Sprintf. Without args
+// the translation is returned as it is.
+func Sprintf(lang, format string, args ...interface{}) string {
+	format = Text(lang, format)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
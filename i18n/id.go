@@ -0,0 +1,215 @@
+package i18n
+
+// indonesian is the Bahasa Indonesia catalog
+var indonesian = map[string]string{
+	// descriptions of the commands
+	"Show the available keywords":       "Tampilkan keyword yang tersedia",
+	"Show how to use a keyword":         "Tampilkan cara memakai keyword",
+	"Choose a Tamako song to play":      "Pilih lagu Tamako untuk diputar",
+	"About the developer":               "Tentang developer",
+	"Write a text on Tamako's notebook": "Tulis teks di buku catatan Tamako",
+	"Dota 2 profile and recent match, of your linked steam account by default. watch posts every new match of the player in this chat": "Profil dan match terakhir Dota 2, dari akun steam yang kamu tautkan jika tidak ada nama. watch mengirim setiap match baru pemain itu ke chat ini",
+	"Search video game information":           "Cari informasi video game",
+	"Search manga information":                "Cari informasi manga",
+	"Music of the week":                       "Musik minggu ini",
+	"Answer a question with yes, no or maybe": "Jawab pertanyaan dengan ya, tidak atau mungkin",
+	"Choose one of the options":               "Pilih salah satu pilihan",
+	"osu! ranks in every mode, or the details of one mode given by name or with --mode, the most played one with a bare --mode, a chart of the rank over time, or the best or recent plays, of your linked osu! account by default. map shows the star rating and pp of a beatmap, also for the beatmap links sent in the chat": "Rank osu! di semua mode, atau detail satu mode yang disebut namanya atau dengan --mode, mode yang paling sering dimainkan jika hanya --mode, grafik rank dari waktu ke waktu, atau permainan terbaik atau terbaru, dari akun osu! yang kamu tautkan jika tidak ada nama. map menampilkan star rating dan pp sebuah beatmap, juga untuk link beatmap yang dikirim di chat",
+	"Steam profile and recently played games, of your linked steam account by default": "Profil Steam dan game yang baru dimainkan, dari akun steam yang kamu tautkan jika tidak ada nama",
+	"Urban dictionary definition": "Definisi dari Urban Dictionary",
+	"Link your steam or osu! account so steam, dota and osu work without a name, or list your linked accounts": "Tautkan akun steam atau osu! agar steam, dota dan osu bisa dipakai tanpa nama, atau lihat akun yang tertaut",
+	"Forget your linked accounts, or only the given one":                                                       "Hapus akun yang tertaut, atau hanya yang disebut",
+	"Show or change the settings of this chat, changes are for the chat admins":                                "Lihat atau ubah pengaturan chat ini, perubahan hanya untuk admin chat",
+	"Show or change the language of the replies, for this group or room or with me only for yourself":          "Lihat atau ubah bahasa balasan, untuk grup atau room ini, atau dengan me hanya untuk dirimu",
+	"Post the result of a keyword in this chat on a schedule":                                                  "Kirim hasil keyword ke chat ini sesuai jadwal",
+	"List the scheduled posts of this chat":                                                                    "Daftar kiriman terjadwal di chat ini",
+	"Stop a scheduled post of this chat":                                                                       "Hentikan kiriman terjadwal di chat ini",
+	"Top commands and users of this chat":                                                                      "Command dan pengguna teratas di chat ini",
+	"Uptime, version and whether the providers can be reached, for the owners of the bot":                      "Uptime, versi dan status provider, untuk pemilik bot",
+	"Make the bot leave this group or room":                                                                    "Keluarkan bot dari grup atau room ini",
+
+	// simple commands
+	"add me as a friend": "tambahkan aku sebagai teman dulu",
+	"Hello %s, nice to meet you ^_^ \nKeywords: %s.\n\nFor help type : \n%susage <available keyword>": "Halo %s, salam kenal ^_^ \nKeyword: %s.\n\nUntuk bantuan ketik : \n%susage <keyword yang tersedia>",
+	"Unknown keyword %s":            "Keyword %s tidak dikenal",
+	"Usage : %s":                    "Cara pakai : %s",
+	"Aliases : %s":                  "Alias : %s",
+	"Choose Tamako Song":            "Pilih Lagu Tamako",
+	"Song List":                     "Daftar Lagu",
+	"About Developer":               "Tentang Developer",
+	"Nothing to write":              "Tidak ada yang ditulis",
+	"Text too long :(":              "Teksnya terlalu panjang :(",
+	"Yes":                           "Ya",
+	"No":                            "Tidak",
+	"Maybe":                         "Mungkin",
+	"I choose %s":                   "Aku pilih %s",
+	"Too many arguments":            "Argumennya terlalu banyak",
+	"Bot can't leave from 1:1 chat": "Bot tidak bisa keluar dari chat 1:1",
+	"Leaving group":                 "Keluar dari grup",
+	"Leaving room":                  "Keluar dari room",
+
+	// handling commands
+	"Missing closing quote":                                       "Tanda kutip penutup tidak ada",
+	"Unknown option --%s":                                         "Opsi --%s tidak dikenal",
+	"Not enough arguments":                                        "Argumennya kurang",
+	"Slow down, try again in %s":                                  "Pelan-pelan, coba lagi dalam %s",
+	"%s is turned off in this chat":                               "%s dimatikan di chat ini",
+	"Only the owners of the bot can use %s":                       "Hanya pemilik bot yang bisa memakai %s",
+	"Only the admins of this chat can use %s":                     "Hanya admin chat ini yang bisa memakai %s",
+	"%s is disabled, %s is not configured":                        "%s tidak aktif, %s belum dikonfigurasi",
+	"Something went wrong":                                        "Terjadi kesalahan",
+	"%s couldn't find what you asked for":                         "%s tidak menemukan yang kamu cari",
+	"%s is busy right now, please try again in a minute":          "%s sedang sibuk, coba lagi sebentar lagi",
+	"%s service is unavailable right now, please try again later": "Layanan %s sedang tidak tersedia, coba lagi nanti",
+
+	// accounts
+	"or link your account with %s":                            "atau tautkan akunmu dengan %s",
+	"I can't see your LINE account, add me as a friend first": "Aku tidak bisa melihat akun LINE-mu, tambahkan aku sebagai teman dulu",
+	"No linked accounts":                                      "Belum ada akun yang tertaut",
+	"Linked accounts :":                                       "Akun tertaut :",
+	"Unknown service %s, use one of %s":                       "Layanan %s tidak dikenal, gunakan salah satu dari %s",
+	"Linked your %s account %s":                               "Akun %s kamu, %s, sudah ditautkan",
+	"Nothing to unlink":                                       "Tidak ada akun yang perlu dilepas",
+	"Unlinked %s":                                             "Tautan %s dilepas",
+
+	// settings
+	"Only the admins of this chat can change its settings":    "Hanya admin chat ini yang bisa mengubah pengaturannya",
+	"Settings are back to the defaults, prefix is %s":         "Pengaturan kembali ke default, prefix-nya %s",
+	"The prefix must be at most %d characters without spaces": "Prefix maksimal %d karakter tanpa spasi",
+	"Prefix is now %s":         "Prefix sekarang %s",
+	"%s can't be turned off":   "%s tidak bisa dimatikan",
+	"Turned off %s":            "%s dimatikan",
+	"Turned on %s":             "%s dinyalakan",
+	"%s is not a LINE user ID": "%s bukan user ID LINE",
+	"%s is now an admin":       "%s sekarang admin",
+	"%s is no longer an admin": "%s bukan admin lagi",
+	"Prefix : %s":              "Prefix : %s",
+	"Turned off : %s":          "Dimatikan : %s",
+	"Admins : %s":              "Admin : %s",
+	"the owners of the bot":    "pemilik bot",
+	"each user's own":          "bahasa masing-masing pengguna",
+	"Language : %s":            "Bahasa : %s",
+
+	// language
+	"Unknown language %s, use one of %s": "Bahasa %s tidak dikenal, gunakan salah satu dari %s",
+	"Only the admins of this chat can change its language, use %slang me <language> for yourself": "Hanya admin chat ini yang bisa mengubah bahasanya, gunakan %slang me <bahasa> untuk dirimu sendiri",
+	"This chat no longer has its own language, everyone gets the replies in their own":            "Chat ini tidak lagi punya bahasa sendiri, setiap orang mendapat balasan dalam bahasanya masing-masing",
+	"You use the default language again, %s":                                                      "Kamu kembali memakai bahasa default, %s",
+	"This chat now speaks %s":                                                                     "Chat ini sekarang memakai %s",
+	"I now speak %s with you":                                                                     "Sekarang aku bicara denganmu dalam %s",
+	"This chat has its own language, %s, which comes first here":                                  "Chat ini punya bahasanya sendiri, %s, yang didahulukan di sini",
+	"Set for this chat":    "Diatur untuk chat ini",
+	"Set by you":           "Diatur olehmu",
+	"The default language": "Bahasa default",
+	"Available : %s":       "Tersedia : %s",
+
+	// schedules
+	"Missing schedule":                      "Jadwalnya tidak ada",
+	"Bad schedule, %s":                      "Jadwal salah, %s",
+	"%s can't be scheduled":                 "%s tidak bisa dijadwalkan",
+	"Subscribed #%d, %s %s\nNext post : %s": "Berlangganan #%d, %s %s\nKiriman berikutnya : %s",
+	"No subscriptions in this chat":         "Tidak ada langganan di chat ini",
+	"Subscriptions :":                       "Langganan :",
+	"Stop one with %s":                      "Hentikan dengan %s",
+	"No subscription #%d in this chat":      "Tidak ada langganan #%d di chat ini",
+	"Unsubscribed #%d":                      "Langganan #%d dihentikan",
+
+	// stats
+	"No commands used here yet":      "Belum ada command yang dipakai di sini",
+	"Top commands":                   "Command teratas",
+	"%d, %s avg":                     "%d, rata-rata %s",
+	", %d failed":                    ", %d gagal",
+	"Top users":                      "Pengguna teratas",
+	"Only scheduled commands so far": "Sejauh ini hanya command terjadwal",
+	"Command usage":                  "Pemakaian command",
+	"Since %s":                       "Sejak %s",
+	"Command usage of this chat":     "Pemakaian command di chat ini",
+
+	// osu!
+	"osu information not found":              "Informasi osu tidak ditemukan",
+	"osu! information of %s":                 "Informasi osu! %s",
+	"Country Rank":                           "Rank Negara",
+	"Global Rank":                            "Rank Global",
+	"Accuracy":                               "Akurasi",
+	"Play Count":                             "Jumlah Main",
+	"Level":                                  "Level",
+	"Joined":                                 "Bergabung",
+	"%s played no %s in the last 24 hours":   "%s tidak memainkan %s dalam 24 jam terakhir",
+	"%s has no %s plays yet":                 "%s belum punya permainan %s",
+	"Best %s plays of %s":                    "Permainan %s terbaik %s",
+	"Recent %s plays of %s":                  "Permainan %s terbaru %s",
+	"Mods":                                   "Mod",
+	"Combo":                                  "Kombo",
+	"Open Beatmap":                           "Buka Beatmap",
+	"Not available right now":                "Tidak tersedia saat ini",
+	"Ranked Score":                           "Skor Ranked",
+	"Max Combo":                              "Kombo Maksimal",
+	"Play Time":                              "Waktu Main",
+	"Open Profile":                           "Buka Profil",
+	"osu! %s information of %s":              "Informasi osu! %s dari %s",
+	"Unknown mods %s, write them like +HDDT": "Mod %s tidak dikenal, tulis seperti +HDDT",
+	"Beatmap not found":                      "Beatmap tidak ditemukan",
+	"Only osu!standard beatmaps can be calculated": "Hanya beatmap osu!standard yang bisa dihitung",
+	"mapped by %s":                   "dibuat oleh %s",
+	"Length":                         "Durasi",
+	"Aim/Speed":                      "Aim/Speed",
+	"%s is too large":                "%s terlalu besar",
+	"%s is not a beatmap I can read": "%s bukan beatmap yang bisa kubaca",
+	"%s is not a replay I can read":  "%s bukan replay yang bisa kubaca",
+	"Replay of %s":                   "Replay %s",
+	"Unknown beatmap":                "Beatmap tidak dikenal",
+	"Player":                         "Pemain",
+	"Mode":                           "Mode",
+	"Score":                          "Skor",
+	"%+d since last week":            "%+d sejak minggu lalu",
+	"No change since last week":      "Tidak berubah sejak minggu lalu",
+	"Unknown mode, use one of %s":    "Mode tidak dikenal, gunakan salah satu dari %s",
+	"Not enough %s history for %s yet, snapshots are taken daily from now on": "Riwayat %s untuk %s belum cukup, snapshot diambil setiap hari mulai sekarang",
+	"%s rank of %s over %d days\n#%d → #%d":                                   "Rank %s %s selama %d hari\n#%d → #%d",
+
+	// dota
+	"Dota 2 information not found":                    "Informasi Dota 2 tidak ditemukan",
+	"Dota 2 information of %s":                        "Informasi Dota 2 %s",
+	"Hero : %s":                                       "Hero : %s",
+	"Win : %s\nTotal Match : %s\nSignature Hero : %s": "Menang : %s\nTotal Match : %s\nHero Andalan : %s",
+	"Recent Match Played":                             "Match Terakhir",
+	"Open Steam":                                      "Buka Steam",
+	"Open Dotabuff":                                   "Buka Dotabuff",
+	"Watching %s, new Dota 2 matches will be posted here": "Memantau %s, match Dota 2 yang baru akan dikirim ke sini",
+	"No Dota 2 players watched here":                      "Tidak ada pemain Dota 2 yang dipantau di sini",
+	"Stopped watching %s":                                 "Berhenti memantau %s",
+	"Dota 2 match of %s":                                  "Match Dota 2 %s",
+	"Lost in %s":                                          "Kalah dalam %s",
+	"Won in %s":                                           "Menang dalam %s",
+	"Hero":                                                "Hero",
+
+	// steam
+	"Steam information not found": "Informasi Steam tidak ditemukan",
+	"Steam information of %s":     "Informasi Steam %s",
+	"Recently Played Games":       "Game yang Baru Dimainkan",
+	"%s hours":                    "%s jam",
+	"Offline":                     "Offline",
+	"Online":                      "Online",
+	"Busy":                        "Sibuk",
+	"Away":                        "Sedang pergi",
+	"Snooze":                      "Tidur",
+	"Looking to trade":            "Mencari trade",
+	"Looking to play":             "Mencari teman main",
+
+	// games, manga, urban, music
+	"Video game information not found": "Informasi video game tidak ditemukan",
+	"Video game search: %s":            "Pencarian video game: %s",
+	"Release Date":                     "Tanggal Rilis",
+	"Platform":                         "Platform",
+	"Description":                      "Deskripsi",
+	"Open Browser":                     "Buka Browser",
+	"Manga information not found":      "Informasi manga tidak ditemukan",
+	"Manga search: %s":                 "Pencarian manga: %s",
+	"Status":                           "Status",
+	"Rating":                           "Rating",
+	"Genre":                            "Genre",
+	"Synopsis":                         "Sinopsis",
+	"Slang words not found":            "Kata slang tidak ditemukan",
+	"Word : %s\nDefinition : %s\n\nExample : %s": "Kata : %s\nDefinisi : %s\n\nContoh : %s",
+	"Preview": "Cuplikan",
+}
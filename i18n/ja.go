@@ -0,0 +1,215 @@
+package i18n
+
+// japanese is the Japanese catalog
+var japanese = map[string]string{
+	// descriptions of the commands
+	"Show the available keywords":       "使えるキーワードを表示する",
+	"Show how to use a keyword":         "キーワードの使い方を表示する",
+	"Choose a Tamako song to play":      "たまこの歌を選んで再生する",
+	"About the developer":               "開発者について",
+	"Write a text on Tamako's notebook": "たまこのノートに文字を書く",
+	"Dota 2 profile and recent match, of your linked steam account by default. watch posts every new match of the player in this chat": "Dota 2 のプロフィールと最近の試合、名前がなければ連携した steam アカウントのもの。watch でそのプレイヤーの新しい試合をこのチャットに投稿する",
+	"Search video game information":           "ゲームの情報を検索する",
+	"Search manga information":                "マンガの情報を検索する",
+	"Music of the week":                       "今週の音楽",
+	"Answer a question with yes, no or maybe": "質問に「はい」「いいえ」「たぶん」で答える",
+	"Choose one of the options":               "選択肢からひとつ選ぶ",
+	"osu! ranks in every mode, or the details of one mode given by name or with --mode, the most played one with a bare --mode, a chart of the rank over time, or the best or recent plays, of your linked osu! account by default. map shows the star rating and pp of a beatmap, also for the beatmap links sent in the chat": "全モードの osu! ランク、モード名か --mode で1モードの詳細（--mode だけなら一番プレイしたモード）、ランクの推移グラフ、またはベストや最近のプレイ、名前がなければ連携した osu! アカウントのもの。map はビートマップのスター難易度と pp を表示し、チャットに送られたビートマップのリンクにも反応する",
+	"Steam profile and recently played games, of your linked steam account by default": "Steam のプロフィールと最近遊んだゲーム、名前がなければ連携した steam アカウントのもの",
+	"Urban dictionary definition": "Urban Dictionary の定義",
+	"Link your steam or osu! account so steam, dota and osu work without a name, or list your linked accounts": "steam か osu! のアカウントを連携して steam、dota、osu を名前なしで使えるようにする、または連携中のアカウントを表示する",
+	"Forget your linked accounts, or only the given one":                                                       "連携したアカウント、または指定したものだけを解除する",
+	"Show or change the settings of this chat, changes are for the chat admins":                                "このチャットの設定を表示・変更する、変更はチャットの管理者のみ",
+	"Show or change the language of the replies, for this group or room or with me only for yourself":          "返信の言語を表示・変更する、このグループやルーム全体、または me で自分だけ",
+	"Post the result of a keyword in this chat on a schedule":                                                  "キーワードの結果をスケジュールに沿ってこのチャットに投稿する",
+	"List the scheduled posts of this chat":                                                                    "このチャットの定期投稿を一覧表示する",
+	"Stop a scheduled post of this chat":                                                                       "このチャットの定期投稿を止める",
+	"Top commands and users of this chat":                                                                      "このチャットでよく使われるコマンドとユーザー",
+	"Uptime, version and whether the providers can be reached, for the owners of the bot":                      "稼働時間、バージョン、プロバイダーへの接続状況、ボットのオーナー専用",
+	"Make the bot leave this group or room":                                                                    "ボットをこのグループまたはルームから退出させる",
+
+	// simple commands
+	"add me as a friend": "まず友だち追加してね",
+	"Hello %s, nice to meet you ^_^ \nKeywords: %s.\n\nFor help type : \n%susage <available keyword>": "こんにちは %s さん、よろしくね ^_^ \nキーワード: %s\n\nヘルプは次のように入力 : \n%susage <使えるキーワード>",
+	"Unknown keyword %s":            "キーワード %s はありません",
+	"Usage : %s":                    "使い方 : %s",
+	"Aliases : %s":                  "別名 : %s",
+	"Choose Tamako Song":            "たまこの歌を選んでね",
+	"Song List":                     "曲リスト",
+	"About Developer":               "開発者について",
+	"Nothing to write":              "書くことがありません",
+	"Text too long :(":              "テキストが長すぎます :(",
+	"Yes":                           "はい",
+	"No":                            "いいえ",
+	"Maybe":                         "たぶん",
+	"I choose %s":                   "%s にする",
+	"Too many arguments":            "引数が多すぎます",
+	"Bot can't leave from 1:1 chat": "1:1 のトークからは退出できません",
+	"Leaving group":                 "グループから退出します",
+	"Leaving room":                  "ルームから退出します",
+
+	// handling commands
+	"Missing closing quote":                                       "閉じ引用符がありません",
+	"Unknown option --%s":                                         "オプション --%s はありません",
+	"Not enough arguments":                                        "引数が足りません",
+	"Slow down, try again in %s":                                  "ちょっと待ってね、%s 後にもう一度どうぞ",
+	"%s is turned off in this chat":                               "%s はこのチャットでは無効です",
+	"Only the owners of the bot can use %s":                       "%s はボットのオーナーしか使えません",
+	"Only the admins of this chat can use %s":                     "%s はこのチャットの管理者しか使えません",
+	"%s is disabled, %s is not configured":                        "%s は使えません、%s が設定されていません",
+	"Something went wrong":                                        "問題が発生しました",
+	"%s couldn't find what you asked for":                         "%s で見つかりませんでした",
+	"%s is busy right now, please try again in a minute":          "%s は混み合っています、少し後でもう一度どうぞ",
+	"%s service is unavailable right now, please try again later": "%s は現在利用できません、後でもう一度どうぞ",
+
+	// accounts
+	"or link your account with %s":                            "または %s でアカウントを連携してね",
+	"I can't see your LINE account, add me as a friend first": "LINE アカウントが見えません、まず友だち追加してね",
+	"No linked accounts":                                      "連携中のアカウントはありません",
+	"Linked accounts :":                                       "連携中のアカウント :",
+	"Unknown service %s, use one of %s":                       "サービス %s はありません、%s のどれかを使ってね",
+	"Linked your %s account %s":                               "%s アカウント %s を連携しました",
+	"Nothing to unlink":                                       "解除するものはありません",
+	"Unlinked %s":                                             "%s の連携を解除しました",
+
+	// settings
+	"Only the admins of this chat can change its settings":    "設定を変更できるのはこのチャットの管理者だけです",
+	"Settings are back to the defaults, prefix is %s":         "設定を初期状態に戻しました、プレフィックスは %s です",
+	"The prefix must be at most %d characters without spaces": "プレフィックスは空白なしの %d 文字以内にしてね",
+	"Prefix is now %s":         "プレフィックスを %s にしました",
+	"%s can't be turned off":   "%s は無効にできません",
+	"Turned off %s":            "%s を無効にしました",
+	"Turned on %s":             "%s を有効にしました",
+	"%s is not a LINE user ID": "%s は LINE のユーザー ID ではありません",
+	"%s is now an admin":       "%s さんを管理者にしました",
+	"%s is no longer an admin": "%s さんは管理者ではなくなりました",
+	"Prefix : %s":              "プレフィックス : %s",
+	"Turned off : %s":          "無効 : %s",
+	"Admins : %s":              "管理者 : %s",
+	"the owners of the bot":    "ボットのオーナー",
+	"each user's own":          "各ユーザーの言語",
+	"Language : %s":            "言語 : %s",
+
+	// language
+	"Unknown language %s, use one of %s": "言語 %s はありません、%s のどれかを使ってね",
+	"Only the admins of this chat can change its language, use %slang me <language> for yourself": "このチャットの言語を変更できるのは管理者だけです、自分だけなら %slang me <言語> を使ってね",
+	"This chat no longer has its own language, everyone gets the replies in their own":            "このチャットの言語設定を解除しました、それぞれの言語で返信します",
+	"You use the default language again, %s":                                                      "デフォルトの言語、%s に戻しました",
+	"This chat now speaks %s":                                                                     "このチャットの言語を %s にしました",
+	"I now speak %s with you":                                                                     "これからは %s で話すね",
+	"This chat has its own language, %s, which comes first here":                                  "このチャットには独自の言語 %s が設定されていて、ここではそちらが優先されます",
+	"Set for this chat":    "このチャットの設定",
+	"Set by you":           "あなたの設定",
+	"The default language": "デフォルトの言語",
+	"Available : %s":       "選べる言語 : %s",
+
+	// schedules
+	"Missing schedule":                      "スケジュールがありません",
+	"Bad schedule, %s":                      "スケジュールが正しくありません、%s",
+	"%s can't be scheduled":                 "%s はスケジュールできません",
+	"Subscribed #%d, %s %s\nNext post : %s": "#%d を登録しました、%s %s\n次の投稿 : %s",
+	"No subscriptions in this chat":         "このチャットに定期投稿はありません",
+	"Subscriptions :":                       "定期投稿 :",
+	"Stop one with %s":                      "%s で止められます",
+	"No subscription #%d in this chat":      "このチャットに定期投稿 #%d はありません",
+	"Unsubscribed #%d":                      "#%d を止めました",
+
+	// stats
+	"No commands used here yet":      "まだコマンドが使われていません",
+	"Top commands":                   "よく使われるコマンド",
+	"%d, %s avg":                     "%d 回、平均 %s",
+	", %d failed":                    "、%d 回失敗",
+	"Top users":                      "よく使うユーザー",
+	"Only scheduled commands so far": "今のところ定期投稿のコマンドだけです",
+	"Command usage":                  "コマンドの利用状況",
+	"Since %s":                       "%s から",
+	"Command usage of this chat":     "このチャットのコマンド利用状況",
+
+	// osu!
+	"osu information not found":              "osu の情報が見つかりません",
+	"osu! information of %s":                 "%s の osu! 情報",
+	"Country Rank":                           "国内ランク",
+	"Global Rank":                            "世界ランク",
+	"Accuracy":                               "精度",
+	"Play Count":                             "プレイ回数",
+	"Level":                                  "レベル",
+	"Joined":                                 "登録日",
+	"%s played no %s in the last 24 hours":   "%[1]s さんは過去24時間に %[2]s をプレイしていません",
+	"%s has no %s plays yet":                 "%[1]s さんの %[2]s のプレイはまだありません",
+	"Best %s plays of %s":                    "%[2]s さんの %[1]s ベストプレイ",
+	"Recent %s plays of %s":                  "%[2]s さんの最近の %[1]s プレイ",
+	"Mods":                                   "Mod",
+	"Combo":                                  "コンボ",
+	"Open Beatmap":                           "ビートマップを開く",
+	"Not available right now":                "現在は利用できません",
+	"Ranked Score":                           "ランクスコア",
+	"Max Combo":                              "最大コンボ",
+	"Play Time":                              "プレイ時間",
+	"Open Profile":                           "プロフィールを開く",
+	"osu! %s information of %s":              "%[2]s の osu! %[1]s 情報",
+	"Unknown mods %s, write them like +HDDT": "Mod %s はありません、+HDDT のように書いてね",
+	"Beatmap not found":                      "ビートマップが見つかりません",
+	"Only osu!standard beatmaps can be calculated": "計算できるのは osu!standard のビートマップだけです",
+	"mapped by %s":                   "譜面作成 : %s",
+	"Length":                         "長さ",
+	"Aim/Speed":                      "エイム/スピード",
+	"%s is too large":                "%s は大きすぎます",
+	"%s is not a beatmap I can read": "%s は読み込めるビートマップではありません",
+	"%s is not a replay I can read":  "%s は読み込めるリプレイではありません",
+	"Replay of %s":                   "%s のリプレイ",
+	"Unknown beatmap":                "不明なビートマップ",
+	"Player":                         "プレイヤー",
+	"Mode":                           "モード",
+	"Score":                          "スコア",
+	"%+d since last week":            "%+d（先週比）",
+	"No change since last week":      "先週から変化なし",
+	"Unknown mode, use one of %s":    "そのモードはありません、%s のどれかを使ってね",
+	"Not enough %s history for %s yet, snapshots are taken daily from now on": "%[2]s さんの %[1]s の履歴がまだ足りません、これから毎日記録します",
+	"%s rank of %s over %d days\n#%d → #%d":                                   "%[2]s さんの %[1]s ランク（%[3]d 日間）\n#%[4]d → #%[5]d",
+
+	// dota
+	"Dota 2 information not found":                    "Dota 2 の情報が見つかりません",
+	"Dota 2 information of %s":                        "%s の Dota 2 情報",
+	"Hero : %s":                                       "ヒーロー : %s",
+	"Win : %s\nTotal Match : %s\nSignature Hero : %s": "勝利 : %s\n総試合数 : %s\n得意なヒーロー : %s",
+	"Recent Match Played":                             "最近の試合",
+	"Open Steam":                                      "Steam を開く",
+	"Open Dotabuff":                                   "Dotabuff を開く",
+	"Watching %s, new Dota 2 matches will be posted here": "%s を見守ります、新しい Dota 2 の試合をここに投稿します",
+	"No Dota 2 players watched here":                      "ここで見守っている Dota 2 プレイヤーはいません",
+	"Stopped watching %s":                                 "%s の見守りをやめました",
+	"Dota 2 match of %s":                                  "%s の Dota 2 の試合",
+	"Lost in %s":                                          "敗北（%s）",
+	"Won in %s":                                           "勝利（%s）",
+	"Hero":                                                "ヒーロー",
+
+	// steam
+	"Steam information not found": "Steam の情報が見つかりません",
+	"Steam information of %s":     "%s の Steam 情報",
+	"Recently Played Games":       "最近遊んだゲーム",
+	"%s hours":                    "%s 時間",
+	"Offline":                     "オフライン",
+	"Online":                      "オンライン",
+	"Busy":                        "取り込み中",
+	"Away":                        "退席中",
+	"Snooze":                      "スヌーズ",
+	"Looking to trade":            "トレード希望",
+	"Looking to play":             "プレイ相手募集中",
+
+	// games, manga, urban, music
+	"Video game information not found": "ゲームの情報が見つかりません",
+	"Video game search: %s":            "ゲーム検索: %s",
+	"Release Date":                     "発売日",
+	"Platform":                         "プラットフォーム",
+	"Description":                      "説明",
+	"Open Browser":                     "ブラウザで開く",
+	"Manga information not found":      "マンガの情報が見つかりません",
+	"Manga search: %s":                 "マンガ検索: %s",
+	"Status":                           "状態",
+	"Rating":                           "評価",
+	"Genre":                            "ジャンル",
+	"Synopsis":                         "あらすじ",
+	"Slang words not found":            "スラングが見つかりません",
+	"Word : %s\nDefinition : %s\n\nExample : %s": "単語 : %s\n定義 : %s\n\n例 : %s",
+	"Preview": "試聴",
+}
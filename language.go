@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/afifmakarim/go-tamako/i18n"
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+type languageKey struct{}
+
+// withLanguage returns a context whose replies are written in lang
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// languageOf returns the language of the replies, English by default
+func languageOf(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey{}).(string); ok {
+		return lang
+	}
+	return i18n.Default
+}
+
+// tr translates a reply to the language of ctx and formats it like
+// fmt.Sprintf
+func tr(ctx context.Context, format string, args ...interface{}) string {
+	return i18n.Sprintf(languageOf(ctx), format, args...)
+}
+
+// language picks the language of the replies in a chat: the one set for the
+// group or room, then the one the user chose, then DEFAULT_LANG
+func (app *TamakoBot) language(chat *store.Chat, userID string) string {
+	if chat != nil && chat.Lang != "" {
+		return chat.Lang
+	}
+	if userID != "" {
+		if user, err := app.store.User(userID); err == nil && user.Lang != "" {
+			return user.Lang
+		}
+	}
+	return app.config.DefaultLang
+}
+
+// chatLanguage is the language of the messages pushed to a chat
+func (app *TamakoBot) chatLanguage(chatID string) string {
+	// a chat that can't be read gets the default language
+	chat, _ := app.store.Chat(chatID)
+	userID := ""
+	if isLineUserID(chatID) {
+		userID = chatID
+	}
+	return app.language(chat, userID)
+}
+
+func (app *TamakoBot) langCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	if req.Source == nil || req.Source.UserID == "" {
+		return app.replyText(req.ReplyToken, tr(ctx, "I can't see your LINE account, add me as a friend first"))
+	}
+	inGroup := req.Source.Type != linebot.EventSourceTypeUser
+	args := req.Args
+	personal := !inGroup
+	if len(args) > 0 && strings.ToLower(args[0]) == "me" {
+		personal, args = true, args[1:]
+	}
+	if len(args) == 0 {
+		return app.replyText(req.ReplyToken, app.describeLanguage(req))
+	}
+	if len(args) > 1 {
+		return app.replyText(req.ReplyToken, tr(ctx, "Usage : %s", req.Command.UsageLine(req.Prefix)))
+	}
+
+	lang := strings.ToLower(args[0])
+	if lang == "reset" {
+		lang = ""
+	} else if !i18n.Supported(lang) {
+		return app.replyText(req.ReplyToken, tr(ctx, "Unknown language %s, use one of %s", args[0], strings.Join(i18n.Languages, ", ")))
+	}
+
+	if personal {
+		if err := app.store.SetUserLanguage(req.Source.UserID, lang); err != nil {
+			return err
+		}
+	} else {
+		if !app.isChatAdmin(req.Chat, req.Source) {
+			return app.replyText(req.ReplyToken, tr(ctx, "Only the admins of this chat can change its language, use %slang me <language> for yourself", req.Prefix))
+		}
+		if _, err := app.store.UpdateChat(sourceID(req.Source), func(chat *store.Chat) error {
+			chat.Lang = lang
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// the confirmation is already in the new language
+	if lang == "" && !personal {
+		ctx = withLanguage(ctx, app.language(nil, req.Source.UserID))
+		return app.replyText(req.ReplyToken, tr(ctx, "This chat no longer has its own language, everyone gets the replies in their own"))
+	}
+	if lang == "" {
+		ctx = withLanguage(ctx, app.config.DefaultLang)
+		return app.replyText(req.ReplyToken, tr(ctx, "You use the default language again, %s", i18n.Name(app.config.DefaultLang)))
+	}
+	ctx = withLanguage(ctx, lang)
+	if !personal {
+		return app.replyText(req.ReplyToken, tr(ctx, "This chat now speaks %s", i18n.Name(lang)))
+	}
+	reply := tr(ctx, "I now speak %s with you", i18n.Name(lang))
+	if inGroup && req.Chat.Lang != "" {
+		reply += "\n" + tr(ctx, "This chat has its own language, %s, which comes first here", i18n.Name(req.Chat.Lang))
+	}
+	return app.replyText(req.ReplyToken, reply)
+}
+
+// describeLanguage tells which language is used and where it comes from
+func (app *TamakoBot) describeLanguage(req *CommandRequest) string {
+	ctx := req.Context()
+	lines := []string{tr(ctx, "Language : %s", i18n.Name(languageOf(ctx)))}
+	if req.Chat.Lang != "" {
+		lines = append(lines, tr(ctx, "Set for this chat"))
+	} else if user, err := app.store.User(req.Source.UserID); err == nil && user.Lang != "" {
+		lines = append(lines, tr(ctx, "Set by you"))
+	} else {
+		lines = append(lines, tr(ctx, "The default language"))
+	}
+	lines = append(lines, tr(ctx, "Available : %s", strings.Join(i18n.Languages, ", ")), tr(ctx, "Usage : %s", req.Command.UsageLine(req.Prefix)))
+	return strings.Join(lines, "\n")
+}
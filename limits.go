@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/afifmakarim/go-tamako/ratelimit"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// rateLimitRules returns the buckets a command from source is taken from
+func (app *TamakoBot) rateLimitRules(cmd *Command, source *linebot.EventSource) []ratelimit.Rule {
+	limits := app.config.RateLimits
+	var rules []ratelimit.Rule
+	if source != nil {
+		if source.UserID != "" {
+			rules = append(rules, ratelimit.Rule{Key: "user:" + source.UserID, Limit: limits.User})
+		}
+		switch {
+		case source.GroupID != "":
+			rules = append(rules, ratelimit.Rule{Key: "group:" + source.GroupID, Limit: limits.Group})
+		case source.RoomID != "":
+			rules = append(rules, ratelimit.Rule{Key: "room:" + source.RoomID, Limit: limits.Group})
+		}
+	}
+	if limit, ok := limits.Commands[cmd.Name]; ok {
+		rules = append(rules, ratelimit.Rule{Key: "command:" + cmd.Name, Limit: limit})
+	}
+	return rules
+}
+
+// allowCommand reports whether the command may run. The first command
+// refused by a bucket gets a "slow down" reply, the next ones are ignored
+// until the bucket refills.
+func (app *TamakoBot) allowCommand(ctx context.Context, cmd *Command, replyToken string, source *linebot.EventSource) (bool, error) {
+	decision := app.limiter.Allow(app.rateLimitRules(cmd, source)...)
+	if decision.Allowed {
+		return true, nil
+	}
+	if !decision.Notify {
+		return false, nil
+	}
+	return false, app.replyText(replyToken, tr(ctx, "Slow down, try again in %s", decision.RetryAfter))
+}
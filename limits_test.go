@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/ratelimit"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+func TestRateLimitSkipsRefusedCommands(t *testing.T) {
+	const owner = "U00000000000000000000000000000001"
+	app, messenger := newTestBot(t, http.NotFoundHandler(), func(cfg *Config) {
+		cfg.Owners = []string{owner}
+		cfg.RateLimits = RateLimitConfig{User: ratelimit.Limit{Count: 2, Per: time.Hour}}
+	})
+	messenger.SetProfile("U2", "Kanna")
+	if replies := sendTextFrom(t, app, messenger, &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "G1", UserID: owner}, "!config disable write"); len(replies) != 1 || replies[0] != "Turned off write" {
+		t.Fatalf("got %q", replies)
+	}
+	member := &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "G1", UserID: "U2"}
+	for _, text := range []string{
+		"!nope",         // unknown
+		"!ping",         // owners only
+		"!bye",          // admins only
+		"!write hello",  // turned off
+		"!steam gaben",  // no credentials
+		"!usage",        // bad arguments
+		"!nope again",   // unknown again
+		"!steam dendi",  // no credentials again
+		"!write hello!", // turned off again
+	} {
+		replies := sendTextFrom(t, app, messenger, member, text)
+		if len(replies) != 1 || strings.HasPrefix(replies[0], "Slow down") {
+			t.Errorf("%q: got %q", text, replies)
+		}
+	}
+	for i, want := range []string{"Hello Kanna", "Hello Kanna", "Slow down, try again in"} {
+		replies := sendTextFrom(t, app, messenger, member, "!help")
+		if len(replies) != 1 || !strings.HasPrefix(replies[0], want) {
+			t.Errorf("!help #%d: got %q, want %q", i+1, replies, want)
+		}
+	}
+}
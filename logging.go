@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// logAttrsKey is the context key of the attributes added to every log line
+// written while handling an event, such as its correlation ID
+type logAttrsKey struct{}
+
+// withLogAttrs returns a context whose log lines carry the given key value
+// pairs on top of those ctx already carries
+func withLogAttrs(ctx context.Context, args ...interface{}) context.Context {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	record := slog.Record{}
+	record.Add(args...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, logAttrsKey{}, attrs[:len(attrs):len(attrs)])
+}
+
+// newCorrelationID returns a short random ID tying together the log lines
+// of one event
+func newCorrelationID() string {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// contextHandler adds the attributes of withLogAttrs to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(logAttrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// secretParams matches the query parameters holding API keys in provider URLs
+var secretParams = regexp.MustCompile(`(?i)([?&](?:k|key|api_key|apikey|token|access_token|client_secret)=)[^&\s"']+`)
+
+// redactor hides secrets in logged values: every known secret, wherever it
+// appears, and the value of the query parameters that usually hold one
+type redactor struct {
+	secrets *strings.Replacer
+}
+
+func newRedactor(secrets []string) *redactor {
+	var pairs []string
+	for _, secret := range secrets {
+		// very short values would mangle unrelated text
+		if len(secret) >= 4 {
+			pairs = append(pairs, secret, "[REDACTED]")
+		}
+	}
+	return &redactor{secrets: strings.NewReplacer(pairs...)}
+}
+
+func (r *redactor) redact(s string) string {
+	return secretParams.ReplaceAllString(r.secrets.Replace(s), "${1}[REDACTED]")
+}
+
+// replaceAttr is the slog ReplaceAttr hook, errors and other values are
+// logged as their redacted text
+func (r *redactor) replaceAttr(_ []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(r.redact(attr.Value.String()))
+	case slog.KindAny:
+		switch v := attr.Value.Any().(type) {
+		case error:
+			attr.Value = slog.StringValue(r.redact(v.Error()))
+		case fmt.Stringer:
+			attr.Value = slog.StringValue(r.redact(v.String()))
+		}
+	}
+	return attr
+}
+
+// parseLogLevel reads debug, info, warn or error
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return level, fmt.Errorf("%q must be debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// newLogger returns the logger of the bot, writing to w at the configured
+// level with the secrets of cfg redacted
+func newLogger(w io.Writer, cfg *Config) (*slog.Logger, error) {
+	level, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: newRedactor(cfg.Secrets()).replaceAttr}
+	var handler slog.Handler
+	if cfg.LogFormat == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
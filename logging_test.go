@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// testLogger logs as JSON into a buffer, with the secrets of cfg redacted
+func testLogger(t *testing.T, cfg *Config) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "debug"
+	}
+	cfg.LogFormat = "json"
+	logger, err := newLogger(&out, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger, &out
+}
+
+// logRecords decodes the records logged as JSON
+func logRecords(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestLogRedaction(t *testing.T) {
+	cfg := &Config{
+		ChannelSecret: "channel-secret-1234",
+		ChannelToken:  "channel-token-5678",
+		AdminToken:    "0123456789abcdef-admin",
+		Providers: map[string]ProviderConfig{
+			providerSteam: {Key: "STEAMKEY0000"},
+			providerOsu:   {ClientID: "1234", Key: "osu-client-secret"},
+			providerUrban: {Key: "abc"},
+		},
+	}
+	logger, out := testLogger(t, cfg)
+	urlErr := &url.Error{Op: "Get", URL: "https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/?key=STEAMKEY0000&steamids=1", Err: errors.New("timeout")}
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"https://api.steampowered.com/x/?key=STEAMKEY0000&steamids=1", "https://api.steampowered.com/x/?key=[REDACTED]&steamids=1"},
+		{"https://www.giantbomb.com/api/search/?query=zelda&api_key=unknown-key", "https://www.giantbomb.com/api/search/?query=zelda&api_key=[REDACTED]"},
+		{"https://example.com/?q=1&k=some-key&page=2", "https://example.com/?q=1&k=[REDACTED]&page=2"},
+		{"grant_type=client_credentials&client_id=1234&client_secret=osu-client-secret", "grant_type=client_credentials&client_id=1234&client_secret=[REDACTED]"},
+		{"https://osu.ppy.sh/?ACCESS_TOKEN=abc.def", "https://osu.ppy.sh/?ACCESS_TOKEN=[REDACTED]"},
+		{"Bearer 0123456789abcdef-admin", "Bearer [REDACTED]"},
+		{"token channel-token-5678 and channel-secret-1234", "token [REDACTED] and [REDACTED]"},
+		{urlErr, `Get "https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/?key=[REDACTED]&steamids=1": timeout`},
+		{fmt.Errorf("steam: %w", urlErr), `steam: Get "https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/?key=[REDACTED]&steamids=1": timeout`},
+		{stringer("https://x/?token=t0k3n"), "https://x/?token=[REDACTED]"},
+		// secrets too short to be told apart from other text are left alone
+		{"abc def", "abc def"},
+		// and so are the parameters that only start like a secret one
+		{"https://example.com/?keyword=zelda", "https://example.com/?keyword=zelda"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		logger.Info("Request", "value", tt.value)
+		records := logRecords(t, out)
+		if len(records) != 1 || records[0]["value"] != tt.want {
+			t.Errorf("logged %v as %q, want %q", tt.value, out, tt.want)
+		}
+	}
+
+	out.Reset()
+	logger.WithGroup("http").Warn("Failed on ?key=STEAMKEY0000", "err", urlErr)
+	if strings.Contains(out.String(), "STEAMKEY0000") {
+		t.Errorf("the key leaked: %s", out)
+	}
+	for _, format := range []string{"text", "json"} {
+		out.Reset()
+		cfg.LogFormat = format
+		logger, err := newLogger(out, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Error("Provider failed", "err", urlErr, "url", urlErr.URL)
+		if strings.Contains(out.String(), "STEAMKEY0000") || strings.Count(out.String(), "[REDACTED]") != 2 {
+			t.Errorf("%s: %s", format, out)
+		}
+	}
+}
+
+func TestLogCorrelation(t *testing.T) {
+	logger, out := testLogger(t, &Config{})
+	id := newCorrelationID()
+	if len(id) != 12 || id == newCorrelationID() {
+		t.Errorf("correlation ID %q", id)
+	}
+	event := withLogAttrs(context.Background(), "event_id", id, "source", "G1")
+	osu := withLogAttrs(event, "command", "osu")
+	steam := withLogAttrs(event, "command", "steam")
+
+	logger.InfoContext(event, "Event")
+	logger.InfoContext(osu, "Command done")
+	logger.With("attempt", 1).WarnContext(steam, "Provider failed")
+	logger.InfoContext(context.Background(), "Unrelated")
+
+	records := logRecords(t, out)
+	if len(records) != 4 {
+		t.Fatalf("got %d records", len(records))
+	}
+	commands := []interface{}{nil, "osu", "steam", nil}
+	for i, record := range records[:3] {
+		if record["event_id"] != id || record["source"] != "G1" || record["command"] != commands[i] {
+			t.Errorf("record %d is %v, want the event_id %s and the command %v", i, record, id, commands[i])
+		}
+	}
+	if records[2]["attempt"] != 1.0 {
+		t.Errorf("record 2 lost the attributes of the logger: %v", records[2])
+	}
+	if _, ok := records[3]["event_id"]; ok {
+		t.Errorf("a record without event has an event_id: %v", records[3])
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for value, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		if got, err := parseLogLevel(value); err != nil || got != want {
+			t.Errorf("parseLogLevel(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "loud", "verbose", "fatal", "5"} {
+		if _, err := parseLogLevel(value); err == nil {
+			t.Errorf("parseLogLevel(%q) didn't fail", value)
+		}
+	}
+	if _, err := newLogger(&bytes.Buffer{}, &Config{LogLevel: "loud"}); err == nil {
+		t.Error("newLogger accepted a bad level")
+	}
+
+	logger, out := testLogger(t, &Config{LogLevel: "warn"})
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if records := logRecords(t, out); len(records) != 1 || records[0]["msg"] != "shown" {
+		t.Errorf("got %v, want only the warning", records)
+	}
+}
@@ -1,29 +1,65 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
-	"math/rand"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/i18n"
+	"github.com/afifmakarim/go-tamako/provider"
+	"github.com/afifmakarim/go-tamako/ratelimit"
+	"github.com/afifmakarim/go-tamako/store"
 	"github.com/line/line-bot-sdk-go/linebot"
 )
 
+// shutdownTimeout bounds each step of the graceful shutdown. Heroku kills
+// the dyno 30 seconds after SIGTERM, webhooks are answered right away so
+// nearly all of it is left for draining the events.
+const shutdownTimeout = 25 * time.Second
+
 func main() {
-	app, err := NewTamakoBot(
-		os.Getenv("CHANNEL_SECRET"),
-		os.Getenv("CHANNEL_TOKEN"),
-		os.Getenv("APP_BASE_URL"),
-	)
+	// `go-tamako simulate` chats with the bot from the terminal
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		if err := runSimulator(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	// `go-tamako config check` reports what is missing in the config
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		if !runConfigCheck(os.Stdout, os.Getenv) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	logger, err := newLogger(os.Stderr, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+	app, err := NewTamakoBot(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -32,58 +68,203 @@ func main() {
 	staticFileServer := http.FileServer(http.Dir("static"))
 	http.HandleFunc("/static/", http.StripPrefix("/static/", staticFileServer).ServeHTTP)
 	// serve /downloaded/** files
-	downloadedFileServer := http.FileServer(http.Dir(app.downloadDir))
+	downloadedFileServer := http.FileServer(http.Dir(cfg.DownloadDir))
 	http.HandleFunc("/downloaded/", http.StripPrefix("/downloaded/", downloadedFileServer).ServeHTTP)
 
 	http.HandleFunc("/callback", app.Callback)
+	// Prometheus metrics of the commands and upstream providers, behind the
+	// admin token
+	http.HandleFunc("/metrics", app.metricsHandler)
+	http.HandleFunc("/healthz", app.healthz)
+	http.HandleFunc("/readyz", app.readyz)
+	// the admin API is off unless ADMIN_TOKEN is set
+	http.HandleFunc("/admin/", app.adminAPI)
 	// This is just a sample code.
 	// For actually use, you must support HTTPS by using `ListenAndServeTLS`, reverse proxy or etc.
-	if err := http.ListenAndServe(":"+os.Getenv("PORT"), nil); err != nil {
+	server := &http.Server{Addr: ":" + cfg.Port}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		// fail /readyz, stop taking webhooks, then let the workers finish
+		// the queued events
+		app.health.drain()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("HTTP server shutdown", "err", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
+	// ListenAndServe returns as soon as Shutdown starts, the running
+	// webhooks may still be queueing events
+	<-shutdown
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := app.Close(drainCtx); err != nil {
+		slog.Error("Events still running at shutdown", "err", err)
+	}
 
 }
 
 // TamakoBot app
 type TamakoBot struct {
-	bot         *linebot.Client
-	appBaseURL  string
-	downloadDir string
+	bot      Messenger
+	config   *Config
+	commands *CommandRegistry
+	http     *provider.Client
+	limiter  *ratelimit.Limiter
+	store    *store.Store
+	replies  *replyFallback
+	events   *eventQueue
+	location *time.Location
+	loops    []*loop
+	metrics  *botMetrics
+	health   *health
+	osuToken *provider.ClientCredentials
 }
 
 // NewTamakoBot function
-func NewTamakoBot(channelSecret, channelToken, appBaseURL string) (*TamakoBot, error) {
+func NewTamakoBot(cfg *Config) (*TamakoBot, error) {
 
-	apiEndpointBase := os.Getenv("ENDPOINT_BASE")
+	apiEndpointBase := cfg.EndpointBase
 	if apiEndpointBase == "" {
 		apiEndpointBase = linebot.APIEndpointBase
 	}
-	fmt.Println(apiEndpointBase)
+	slog.Debug("LINE API", "endpoint", apiEndpointBase)
 	bot, err := linebot.New(
-		channelSecret,
-		channelToken,
+		cfg.ChannelSecret,
+		cfg.ChannelToken,
 		linebot.WithEndpointBase(apiEndpointBase), // Usually you omit this.
 	)
 	if err != nil {
 		return nil, err
 	}
-	downloadDir := filepath.Join(filepath.Dir(os.Args[0]), "line-bot")
-	_, err = os.Stat(downloadDir)
+	_, err = os.Stat(cfg.DownloadDir)
 	if err != nil {
-		if err := os.Mkdir(downloadDir, 0777); err != nil {
+		if err := os.Mkdir(cfg.DownloadDir, 0777); err != nil {
 			return nil, err
 		}
 	}
-	return &TamakoBot{
-		bot:         bot,
-		appBaseURL:  appBaseURL,
-		downloadDir: downloadDir,
-	}, nil
+	return NewTamakoBotWithMessenger(NewLineMessenger(bot), cfg)
+}
+
+// NewTamakoBotWithMessenger creates the bot on top of any Messenger, e.g. a
+// RecordingMessenger in tests
+func NewTamakoBotWithMessenger(messenger Messenger, cfg *Config) (*TamakoBot, error) {
+	client, err := newProviderClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	location, err := cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+	db, err := store.Open(cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", cfg.DBPath, err)
+	}
+	metrics := newBotMetrics()
+	client.Observe = metrics.observeUpstream
+	replies := newReplyFallback(messenger)
+	app := &TamakoBot{
+		bot:      replies,
+		config:   cfg,
+		commands: NewCommandRegistry(),
+		http:     client,
+		limiter:  ratelimit.New(),
+		store:    db,
+		replies:  replies,
+		location: location,
+		metrics:  metrics,
+		health:   &health{started: time.Now()},
+		osuToken: newOsuToken(client, cfg),
+	}
+	if err := app.commands.Register(app.commandList()...); err != nil {
+		db.Close()
+		return nil, err
+	}
+	app.events = newEventQueue(cfg.Workers, cfg.QueueSize, app.handleEvent)
+	app.loops = []*loop{
+		startLoop(schedulerTick, app.runDueSubscriptions),
+		startLoop(dotaWatchInterval, app.pollDotaWatches),
+		startLoop(osuHistoryInterval, app.snapshotOsuPlayers),
+	}
+	return app, nil
+}
+
+// Close stops the background loops and taking events, waits for the queued
+// ones to be handled and closes the database
+func (app *TamakoBot) Close(ctx context.Context) error {
+	for _, l := range app.loops {
+		l.stop()
+	}
+	err := app.events.Shutdown(ctx)
+	if cerr := app.store.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// cacheRules are the TTLs of the upstream endpoints worth caching, keyed by
+// the provider names passed to GetJSON
+var cacheRules = []provider.CacheRule{
+	{Provider: "Steam", Match: "/ResolveVanityURL/", TTL: 24 * time.Hour},
+	{Provider: "Steam", Match: "/GetPlayerSummaries/", TTL: 10 * time.Minute},
+	{Provider: "Steam", Match: "/GetRecentlyPlayedGames/", TTL: 10 * time.Minute},
+	// shorter than dotaWatchInterval, so no poll reads the answer of the last one
+	{Provider: "OpenDota", Match: "/recentMatches", TTL: dotaWatchInterval / 2},
+	{Provider: "OpenDota", Match: "/wl", TTL: 10 * time.Minute},
+	{Provider: "OpenDota", Match: "/heroes", TTL: time.Hour},
+	{Provider: "OpenDota", Match: "/players/", TTL: time.Hour},
+	{Provider: "Kitsu", Match: "/genres", TTL: 24 * time.Hour},
+	{Provider: "Kitsu", Match: "/manga", TTL: time.Hour},
+	{Provider: "GiantBomb", Match: "/search/", TTL: 6 * time.Hour},
+	{Provider: "iTunes", Match: "/top-songs/", TTL: 6 * time.Hour},
+	{Provider: "osu!", Match: "/scores/recent", TTL: time.Minute},
+	{Provider: "osu!", Match: "/api/v2/users/", TTL: 5 * time.Minute},
+	{Provider: "osu!", Match: "/osu/", TTL: 24 * time.Hour},
+	{Provider: "osu!", Match: "/beatmaps/lookup", TTL: 24 * time.Hour},
+	{Provider: "Urban Dictionary", Match: "/define", TTL: 24 * time.Hour},
+}
+
+// cacheSize is the number of responses kept by the in-memory cache
+const cacheSize = 512
+
+// maxStale is how long cached responses are kept around for when a provider
+// is down
+const maxStale = 7 * 24 * time.Hour
+
+// newProviderClient returns the client used to call the upstream APIs. Its
+// cache lives in memory, or in cfg.CacheDir when set so it survives restarts.
+func newProviderClient(cfg *Config) (*provider.Client, error) {
+	client := provider.NewClient()
+	client.CacheRules = cacheRules
+	client.MaxStale = maxStale
+	if cfg.CacheDir == "" {
+		client.Cache = provider.NewLRUCache(cacheSize)
+		return client, nil
+	}
+	cache, err := provider.NewDiskCache(cfg.CacheDir, maxStale)
+	if err != nil {
+		return nil, err
+	}
+	client.Cache = cache
+	return client, nil
+}
+
+// providerURL joins the configured endpoint of a provider with path
+func (app *TamakoBot) providerURL(provider, path string) string {
+	return app.config.Provider(provider).Endpoint + path
 }
 
 // Callback function for http server
 func (app *TamakoBot) Callback(w http.ResponseWriter, r *http.Request) {
-	events, err := app.bot.ParseRequest(r)
+	events, err := linebot.ParseRequest(app.config.ChannelSecret, r)
 	if err != nil {
 		if err == linebot.ErrInvalidSignature {
 			w.WriteHeader(400)
@@ -92,264 +273,244 @@ func (app *TamakoBot) Callback(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	// LINE only waits a few seconds for the webhook, the events are handled
+	// after answering it
+	dropped := 0
 	for _, event := range events {
-		log.Printf("Got event %v", event)
-		switch event.Type {
-		case linebot.EventTypeMessage:
-			switch message := event.Message.(type) {
-			case *linebot.TextMessage:
-				if err := app.handleText(message, event.ReplyToken, event.Source); err != nil {
-					log.Print(err)
-				}
-			case *linebot.ImageMessage:
-				if err := app.handleImage(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			case *linebot.VideoMessage:
-				if err := app.handleVideo(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			case *linebot.AudioMessage:
-				if err := app.handleAudio(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			case *linebot.FileMessage:
-				if err := app.handleFile(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			case *linebot.LocationMessage:
-				if err := app.handleLocation(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			case *linebot.StickerMessage:
-				if err := app.handleSticker(message, event.ReplyToken); err != nil {
-					log.Print(err)
-				}
-			default:
-				log.Printf("Unknown message: %v", message)
-			}
-		case linebot.EventTypeFollow:
-			if err := app.replyText(event.ReplyToken, "Got followed event"); err != nil {
-				log.Print(err)
-			}
-		case linebot.EventTypeUnfollow:
-			log.Printf("Unfollowed this bot: %v", event)
-		case linebot.EventTypeJoin:
-			if err := app.replyText(event.ReplyToken, "Joined "+string(event.Source.Type)); err != nil {
-				log.Print(err)
-			}
-		case linebot.EventTypeLeave:
-			log.Printf("Left: %v", event)
-		case linebot.EventTypePostback:
-			data := event.Postback.Data
-			if data == "dmr" {
-				song := "https://sites.google.com/site/untukaudio1/directory/Dramatic%20Market%20Ride.m4a"
-				if _, err := app.bot.ReplyMessage(
-					event.ReplyToken,
-					linebot.NewAudioMessage(song, 100),
-					linebot.NewTextMessage("Tamako Market Opening Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
-				).Do(); err != nil {
-					log.Print(err)
-				}
-			}
-			if data == "neguse" {
-				song := "https://sites.google.com/site/untukaudio1/directory/Neguse.m4a"
-				if _, err := app.bot.ReplyMessage(
-					event.ReplyToken,
-					linebot.NewAudioMessage(song, 100),
-					linebot.NewTextMessage("Tamako Market Ending Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
-				).Do(); err != nil {
-					log.Print(err)
-				}
-			}
-			if data == "principle" {
-				song := "https://sites.google.com/site/untukaudio1/directory/principle%20half.m4a"
-				if _, err := app.bot.ReplyMessage(
-					event.ReplyToken,
-					linebot.NewAudioMessage(song, 100),
-					linebot.NewTextMessage("Tamako Love Story\nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
-				).Do(); err != nil {
-					log.Print(err)
-				}
-			}
-			if data == "koinouta" {
-				song := "https://sites.google.com/site/untukaudio1/directory/koinouta%20half.m4a"
-				if _, err := app.bot.ReplyMessage(
-					event.ReplyToken,
-					linebot.NewAudioMessage(song, 100),
-					linebot.NewTextMessage("Tamako Love Story Insert Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
-				).Do(); err != nil {
-					log.Print(err)
-				}
-			}
-			if data == "DATE" || data == "TIME" || data == "DATETIME" {
-				data += fmt.Sprintf("(%v)", *event.Postback.Params)
-			}
-			if err := app.replyText(event.ReplyToken, "Got postback: "+data); err != nil {
-				log.Print(err)
-			}
-		case linebot.EventTypeBeacon:
-			if err := app.replyText(event.ReplyToken, "Got beacon: "+event.Beacon.Hwid); err != nil {
-				log.Print(err)
-			}
-		default:
-			log.Printf("Unknown event: %v", event)
+		if err := app.events.Enqueue(event); err != nil {
+			slog.Warn("Dropped event", "type", event.Type, "source", sourceID(event.Source), "err", err)
+			dropped++
 		}
 	}
+	// LINE redelivers the webhook when it doesn't get a 200
+	if dropped > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 }
 
-func (app *TamakoBot) handleText(message *linebot.TextMessage, replyToken string, source *linebot.EventSource) error {
-	prefix := "!"
-	lowerText := strings.ToLower(message.Text)
-	if strings.HasPrefix(lowerText, prefix) {
-		keyword := string(lowerText[1:])
-		arg1 := strings.Split(keyword, " ")
-		//arg2 := arg1[1]
-		switch arg1[0] {
-		case "help":
-			profile, err := app.bot.GetProfile(source.UserID).Do()
-			if err != nil {
-				return app.replyText(replyToken, "add me as a friend")
+// handleEvent handles a webhook event on one of the workers. Every log line
+// written meanwhile carries the correlation ID of the event.
+func (app *TamakoBot) handleEvent(ctx context.Context, event *linebot.Event) {
+	ctx = withLogAttrs(ctx, "event_id", newCorrelationID(), "event", event.Type, "source", sourceID(event.Source))
+	app.replies.track(event)
+	defer app.replies.forget(event.ReplyToken)
+	slog.InfoContext(ctx, "Got event")
+	app.trackMembership(ctx, event)
+	switch event.Type {
+	case linebot.EventTypeMessage:
+		switch message := event.Message.(type) {
+		case *linebot.TextMessage:
+			if err := app.handleText(ctx, message, event.ReplyToken, event.Source); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-
-			var help = "Hello " + profile.DisplayName + ", nice to meet you ^_^ \nKeywords: help, sing, about, write, dota, games, manga, motw, ynm, chs, osu, steam, urban, lovecalc, anime, weather, stalk, music, youtubemp3, yt-dl, usage, leave.\n\n	For help type : \n!usage <available keyword>"
-			if _, err := app.bot.ReplyMessage(replyToken, linebot.NewTextMessage(help)).Do(); err != nil {
-				return err
+		case *linebot.ImageMessage:
+			if err := app.handleImage(message, event.ReplyToken); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "sing":
-			imageURL := "https://s-media-cache-ak0.pinimg.com/564x/9e/fa/18/9efa18b56cd5057101bf72a0b023ad7f.jpg"
-			template := linebot.NewButtonsTemplate(
-				imageURL, "Choose Tamako Song", "CV : Suzaki Aya",
-				linebot.NewPostbackAction("Dramatic Market Ride", "dmr", "", ""),
-				linebot.NewPostbackAction("Principle", "principle", "", ""),
-				linebot.NewPostbackAction("Koi no Uta", "koinouta", "", ""),
-				linebot.NewPostbackAction("Neguse", "neguse", "", ""),
-			)
-			if _, err := app.bot.ReplyMessage(
-				replyToken,
-				linebot.NewTemplateMessage("Song List", template),
-			).Do(); err != nil {
-				return err
+		case *linebot.VideoMessage:
+			if err := app.handleVideo(message, event.ReplyToken); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "about":
-			imageURL := "https://01d54fec-a-62cb3a1a-s-sites.googlegroups.com/site/untukaudio1/directory/tamakomem.jpg"
-			template := linebot.NewButtonsTemplate(
-				imageURL, "About Developer", "Mr. Rojokundo",
-				linebot.NewURIAction("Youtube", "https://www.youtube.com/rojofactory"),
-				linebot.NewURIAction("Line@", "https://line.me/R/ti/p/%40wfq6948b"),
-				linebot.NewURIAction("Instagram", "https://www.instagram.com/afifmakarim88"),
-			)
-			if _, err := app.bot.ReplyMessage(
-				replyToken,
-				linebot.NewTemplateMessage("About Developer", template),
-			).Do(); err != nil {
-				return err
+		case *linebot.AudioMessage:
+			if err := app.handleAudio(message, event.ReplyToken); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "write":
-			sentence := string(keyword[5:])
-			rawEncoded := Rawurlencode(sentence)
-			var imageUrl string
-
-			if rawEncoded == "" {
-				return app.replyText(replyToken, "Nothing to write")
+		case *linebot.FileMessage:
+			if err := app.handleFile(ctx, message, event.ReplyToken, event.Source); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-
-			if len(rawEncoded) >= 8 && len(rawEncoded) <= 55 {
-				imageUrl = "https://res.cloudinary.com/dftovjqdo/image/upload/a_-27,g_west,l_text:dark_name:" + rawEncoded + ",w_450,x_280,y_100/anime_notebook_yhekwa.jpg"
-			} else if len(rawEncoded) <= 8 && len(rawEncoded) <= 55 {
-				imageUrl = "https://res.cloudinary.com/dftovjqdo/image/upload/a_-27,g_west,l_text:dark_name:" + rawEncoded + ",w_200,x_250,y_100/anime_notebook_yhekwa.jpg"
-			} else {
-				return app.replyText(replyToken, "Text too long :(")
+		case *linebot.LocationMessage:
+			if err := app.handleLocation(message, event.ReplyToken); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-
-			if _, err := app.bot.ReplyMessage(
-				replyToken,
-				linebot.NewImageMessage(imageUrl, imageUrl),
-			).Do(); err != nil {
-				return err
+		case *linebot.StickerMessage:
+			if err := app.handleSticker(message, event.ReplyToken); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "dota":
-			username := arg1[1]
-			if err := app.dotaMessage(username, replyToken); err != nil {
-				log.Print(err)
+		default:
+			slog.WarnContext(ctx, "Unknown message", "message", fmt.Sprintf("%T", message))
+		}
+	case linebot.EventTypeFollow:
+		if err := app.replyText(event.ReplyToken, "Got followed event"); err != nil {
+			slog.ErrorContext(ctx, "Event failed", "err", err)
+		}
+	case linebot.EventTypeUnfollow:
+		slog.InfoContext(ctx, "Unfollowed this bot")
+	case linebot.EventTypeJoin:
+		if err := app.replyText(event.ReplyToken, "Joined "+string(event.Source.Type)); err != nil {
+			slog.ErrorContext(ctx, "Event failed", "err", err)
+		}
+	case linebot.EventTypeLeave:
+		slog.InfoContext(ctx, "Left")
+	case linebot.EventTypePostback:
+		data := event.Postback.Data
+		if data == "dmr" {
+			song := "https://sites.google.com/site/untukaudio1/directory/Dramatic%20Market%20Ride.m4a"
+			if err := app.bot.ReplyMessage(
+				event.ReplyToken,
+				linebot.NewAudioMessage(song, 100),
+				linebot.NewTextMessage("Tamako Market Opening Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
+			); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-
-		case "games":
-			gamesKeyword := string(keyword[6:])
-			if err := app.gameMessage(gamesKeyword, replyToken); err != nil {
-				log.Print(err)
+		}
+		if data == "neguse" {
+			song := "https://sites.google.com/site/untukaudio1/directory/Neguse.m4a"
+			if err := app.bot.ReplyMessage(
+				event.ReplyToken,
+				linebot.NewAudioMessage(song, 100),
+				linebot.NewTextMessage("Tamako Market Ending Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
+			); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "manga":
-			mangaKeyword := string(keyword[6:])
-			if err := app.mangaMessage(mangaKeyword, replyToken); err != nil {
-				log.Print(err)
+		}
+		if data == "principle" {
+			song := "https://sites.google.com/site/untukaudio1/directory/principle%20half.m4a"
+			if err := app.bot.ReplyMessage(
+				event.ReplyToken,
+				linebot.NewAudioMessage(song, 100),
+				linebot.NewTextMessage("Tamako Love Story\nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
+			); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
-		case "motw":
-
-			if err := app.motwMessage(replyToken); err != nil {
-				log.Print(err)
+		}
+		if data == "koinouta" {
+			song := "https://sites.google.com/site/untukaudio1/directory/koinouta%20half.m4a"
+			if err := app.bot.ReplyMessage(
+				event.ReplyToken,
+				linebot.NewAudioMessage(song, 100),
+				linebot.NewTextMessage("Tamako Love Story Insert Song \nPerformed by : \nKitashirakawa Tamako (CV: Suzaki Aya)"),
+			); err != nil {
+				slog.ErrorContext(ctx, "Event failed", "err", err)
 			}
+		}
+		if data == "DATE" || data == "TIME" || data == "DATETIME" {
+			data += fmt.Sprintf("(%v)", *event.Postback.Params)
+		}
+		if err := app.replyText(event.ReplyToken, "Got postback: "+data); err != nil {
+			slog.ErrorContext(ctx, "Event failed", "err", err)
+		}
+	case linebot.EventTypeBeacon:
+		if err := app.replyText(event.ReplyToken, "Got beacon: "+event.Beacon.Hwid); err != nil {
+			slog.ErrorContext(ctx, "Event failed", "err", err)
+		}
+	default:
+		slog.WarnContext(ctx, "Unknown event")
+	}
+}
 
-		case "ynm":
-			ynmKeyword := string(keyword[4:])
-			array := []string{"Yes", "No", "Maybe"}
-			rand.Seed(time.Now().UnixNano())
-			randomInt := randomInt(0, 3)
-			return app.replyText(replyToken, ynmKeyword+"\n"+array[randomInt])
-		case "chs":
-			chsKeyword := string(keyword[4:])
-			explode := strings.Split(chsKeyword, "-")
-			rand.Seed(time.Now().UnixNano())
-			randomInt := randomInt(0, len(explode))
-			random_str := explode[randomInt]
-			return app.replyText(replyToken, "I choose "+random_str)
-		case "osu":
-			osuUsername := string(keyword[4:])
-			if err := app.osuMessage(osuUsername, replyToken); err != nil {
-				log.Print(err)
-			}
-		case "steam":
-			steamUsername := string(keyword[6:])
-			if err := app.steamMessage(steamUsername, replyToken); err != nil {
-				log.Print(err)
-			}
-		case "urban":
-			urbanUsername := string(keyword[6:])
-			if err := app.urbanMessage(urbanUsername, replyToken); err != nil {
-				log.Print(err)
-			}
-		case "bye":
-			switch source.Type {
-			case linebot.EventSourceTypeUser:
-				return app.replyText(replyToken, "Bot can't leave from 1:1 chat")
-			case linebot.EventSourceTypeGroup:
-				if err := app.replyText(replyToken, "Leaving group"); err != nil {
-					return err
-				}
-				if _, err := app.bot.LeaveGroup(source.GroupID).Do(); err != nil {
-					return app.replyText(replyToken, err.Error())
-				}
-			case linebot.EventSourceTypeRoom:
-				if err := app.replyText(replyToken, "Leaving room"); err != nil {
-					return err
-				}
-				if _, err := app.bot.LeaveRoom(source.RoomID).Do(); err != nil {
-					return app.replyText(replyToken, err.Error())
-				}
-			}
-		default:
-			log.Printf("Echo message to %s: %s", replyToken, message.Text)
-			if _, err := app.bot.ReplyMessage(
-				replyToken,
-				linebot.NewTextMessage(message.Text),
-			).Do(); err != nil {
-				return err
-			}
+func (app *TamakoBot) handleText(ctx context.Context, message *linebot.TextMessage, replyToken string, source *linebot.EventSource) error {
+	chat, err := app.store.Chat(sourceID(source))
+	if err != nil {
+		return err
+	}
+	prefix := app.chatPrefix(chat)
+	text := message.Text
+	detected := false
+	if !strings.HasPrefix(text, prefix) {
+		// a beatmap link sent in the chat is answered like osu map
+		link := beatmapLinkPattern.FindString(text)
+		if link == "" {
+			return nil
+		}
+		text, detected = prefix+"osu map "+link, true
+	}
+	ctx = withLanguage(ctx, app.language(chat, source.UserID))
+	tokens, err := tokenize(strings.TrimPrefix(text, prefix))
+	if err != nil {
+		return app.replyText(replyToken, argErrorText(ctx, err))
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	cmd, ok := app.commands.Lookup(tokens[0])
+	// nobody asked, so a detected link is left alone where osu can't answer
+	if detected && (!ok || chat.IsDisabled(cmd.Name) || len(app.config.MissingProviders(cmd)) > 0) {
+		return nil
+	}
+	if !ok {
+		slog.DebugContext(ctx, "Echo message", "text", message.Text)
+		return app.replyText(replyToken, message.Text)
+	}
+	if chat.IsDisabled(cmd.Name) {
+		return app.replyText(replyToken, tr(ctx, "%s is turned off in this chat", prefix+cmd.Name))
+	}
+	if cmd.Owner && !app.config.IsOwner(source.UserID) {
+		return app.replyText(replyToken, tr(ctx, "Only the owners of the bot can use %s", prefix+cmd.Name))
+	}
+	if cmd.Admin && !app.isChatAdmin(chat, source) {
+		return app.replyText(replyToken, tr(ctx, "Only the admins of this chat can use %s", prefix+cmd.Name))
+	}
+	if missing := app.config.MissingProviders(cmd); len(missing) > 0 {
+		return app.replyText(replyToken, tr(ctx, "%s is disabled, %s is not configured", prefix+cmd.Name, strings.Join(missing, ", ")))
+	}
+	args, err := parseArgs(tokens[1:], cmd.Args)
+	if err != nil {
+		return app.replyText(replyToken, argErrorText(ctx, err)+"\n"+tr(ctx, "Usage : %s", cmd.UsageLine(prefix)))
+	}
+	// only the commands that will run use up the quotas
+	if allowed, err := app.allowCommand(ctx, cmd, replyToken, source); !allowed {
+		return err
+	}
+	return app.runCommand(cmd, &CommandRequest{
+		ctx:        ctx,
+		Command:    cmd,
+		Prefix:     prefix,
+		Chat:       chat,
+		Args:       args.Positional,
+		Flags:      args.Flags,
+		Text:       strings.Join(args.Positional, " "),
+		ReplyToken: replyToken,
+		Source:     source,
+		Message:    message,
+	})
+}
+
+// chatPrefix is the command prefix of a chat
+func (app *TamakoBot) chatPrefix(chat *store.Chat) string {
+	if chat.Prefix != "" {
+		return chat.Prefix
+	}
+	return app.config.Prefix
+}
+
+// runCommand calls the command handler, a panicking handler is reported
+// instead of taking the webhook goroutine down with it. Every run is
+// recorded for !stats and /metrics.
+func (app *TamakoBot) runCommand(cmd *Command, req *CommandRequest) (err error) {
+	req.ctx = withLogAttrs(req.Context(), "command", cmd.Name)
+	start := time.Now()
+	outcome := "panic"
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(req.Context(), "Command panicked", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			err = app.replyText(req.ReplyToken, tr(req.Context(), "Something went wrong")+"\n"+tr(req.Context(), "Usage : %s", cmd.UsageLine(req.Prefix)))
 		}
+		elapsed := time.Since(start)
+		slog.InfoContext(req.Context(), "Command done", "outcome", outcome, "elapsed", elapsed)
+		app.recordCommand(cmd, req, elapsed, outcome)
+	}()
+	err = cmd.Handler(req)
+	outcome = commandOutcome(err)
+	if perr, ok := provider.AsError(err); ok {
+		slog.WarnContext(req.Context(), "Provider failed", "err", perr)
+		return app.replyText(req.ReplyToken, providerErrorText(req.Context(), perr))
+	}
+	return err
+}
 
+// providerErrorText is the reply sent when an upstream provider failed
+func providerErrorText(ctx context.Context, err *provider.Error) string {
+	switch err.Kind {
+	case provider.ErrNotFound:
+		return tr(ctx, "%s couldn't find what you asked for", err.Provider)
+	case provider.ErrRateLimited:
+		return tr(ctx, "%s is busy right now, please try again in a minute", err.Provider)
+	default:
+		return tr(ctx, "%s service is unavailable right now, please try again later", err.Provider)
 	}
-	return nil
 }
+
 func (app *TamakoBot) handleImage(message *linebot.ImageMessage, replyToken string) error {
 	return app.handleHeavyContent(message.ID, func(originalContent *os.File) error {
 		// You need to install ImageMagick.
@@ -360,12 +521,12 @@ func (app *TamakoBot) handleImage(message *linebot.ImageMessage, replyToken stri
 			return err
 		}
 
-		originalContentURL := app.appBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
-		previewImageURL := app.appBaseURL + "/downloaded/" + filepath.Base(previewImagePath)
-		if _, err := app.bot.ReplyMessage(
+		originalContentURL := app.config.AppBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
+		previewImageURL := app.config.AppBaseURL + "/downloaded/" + filepath.Base(previewImagePath)
+		if err := app.bot.ReplyMessage(
 			replyToken,
 			linebot.NewImageMessage(originalContentURL, previewImageURL),
-		).Do(); err != nil {
+		); err != nil {
 			return err
 		}
 		return nil
@@ -382,12 +543,12 @@ func (app *TamakoBot) handleVideo(message *linebot.VideoMessage, replyToken stri
 			return err
 		}
 
-		originalContentURL := app.appBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
-		previewImageURL := app.appBaseURL + "/downloaded/" + filepath.Base(previewImagePath)
-		if _, err := app.bot.ReplyMessage(
+		originalContentURL := app.config.AppBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
+		previewImageURL := app.config.AppBaseURL + "/downloaded/" + filepath.Base(previewImagePath)
+		if err := app.bot.ReplyMessage(
 			replyToken,
 			linebot.NewVideoMessage(originalContentURL, previewImageURL),
-		).Do(); err != nil {
+		); err != nil {
 			return err
 		}
 		return nil
@@ -396,36 +557,51 @@ func (app *TamakoBot) handleVideo(message *linebot.VideoMessage, replyToken stri
 
 func (app *TamakoBot) handleAudio(message *linebot.AudioMessage, replyToken string) error {
 	return app.handleHeavyContent(message.ID, func(originalContent *os.File) error {
-		originalContentURL := app.appBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
-		if _, err := app.bot.ReplyMessage(
+		originalContentURL := app.config.AppBaseURL + "/downloaded/" + filepath.Base(originalContent.Name())
+		if err := app.bot.ReplyMessage(
 			replyToken,
 			linebot.NewAudioMessage(originalContentURL, 100),
-		).Do(); err != nil {
+		); err != nil {
 			return err
 		}
 		return nil
 	})
 }
 
-func (app *TamakoBot) handleFile(message *linebot.FileMessage, replyToken string) error {
+func (app *TamakoBot) handleFile(ctx context.Context, message *linebot.FileMessage, replyToken string, source *linebot.EventSource) error {
+	chat, err := app.store.Chat(sourceID(source))
+	if err != nil {
+		return err
+	}
+	// replays and beatmaps are answered like osu, unless it is turned off
+	ext := strings.ToLower(filepath.Ext(message.FileName))
+	if (ext == ".osr" || ext == ".osu") && !chat.IsDisabled("osu") {
+		ctx = withLanguage(ctx, app.language(chat, source.UserID))
+		if cmd, ok := app.commands.Lookup("osu"); ok {
+			if allowed, err := app.allowCommand(ctx, cmd, replyToken, source); !allowed {
+				return err
+			}
+		}
+		return app.handleOsuFile(ctx, message, replyToken)
+	}
 	return app.replyText(replyToken, fmt.Sprintf("File `%s` (%d bytes) received.", message.FileName, message.FileSize))
 }
 
 func (app *TamakoBot) handleLocation(message *linebot.LocationMessage, replyToken string) error {
-	if _, err := app.bot.ReplyMessage(
+	if err := app.bot.ReplyMessage(
 		replyToken,
 		linebot.NewLocationMessage(message.Title, message.Address, message.Latitude, message.Longitude),
-	).Do(); err != nil {
+	); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (app *TamakoBot) handleSticker(message *linebot.StickerMessage, replyToken string) error {
-	if _, err := app.bot.ReplyMessage(
+	if err := app.bot.ReplyMessage(
 		replyToken,
 		linebot.NewStickerMessage(message.PackageID, message.StickerID),
-	).Do(); err != nil {
+	); err != nil {
 		return err
 	}
 	return nil
@@ -437,14 +613,10 @@ func (app *TamakoBot) handleSticker(message *linebot.StickerMessage, replyToken
 // }
 
 func defaultValue(message string) string {
-
-	if len(message) == 0 {
+	if strings.TrimSpace(message) == "" {
 		return "-"
 	}
-	potong := strings.ReplaceAll(message, "\n", "")
-	potongLagi := strings.ReplaceAll(potong, `"`, "")
-	potonglagilagi := strings.ReplaceAll(potongLagi, "\r", "")
-	return potonglagilagi
+	return message
 }
 
 func defaultImage(message string) string {
@@ -454,507 +626,252 @@ func defaultImage(message string) string {
 	return message
 }
 
-func (app *TamakoBot) osuMessage(message string, replyToken string) error {
-
-	var osuStd []OsuStd
-	var osuMania []OsuMania
-	var osuTaiko []OsuTaiko
-	var osuCtb []OsuCtb
-
-	// get osu standard api
-	stdApi := getData("https://osu.ppy.sh/api/get_user?u=" + message + "&m=0&k=1958afa9967f399f1cd22f52be34d93bcf755212")
-	json.Unmarshal([]byte(stdApi), &osuStd)
-	stdAkurasi := defaultValue(osuStd[0].Accuracy)
-	stdCountryRank := defaultValue(osuStd[0].Pp_country_rank)
-	stdGlobalRank := defaultValue(osuStd[0].Pp_rank)
-
-	username := osuStd[0].Username
-	imageUrl := "https://a.ppy.sh/" + osuStd[0].User_id
-
-	if len(username) == 0 || message == "" {
-		return app.replyText(replyToken, "osu information not found")
-	}
-
-	maniaApi := getData("https://osu.ppy.sh/api/get_user?u=" + message + "&m=3&k=1958afa9967f399f1cd22f52be34d93bcf755212")
-	json.Unmarshal([]byte(maniaApi), &osuMania)
-	maniaAkurasi := defaultValue(osuMania[0].Accuracy)
-	maniaCountryRank := defaultValue(osuMania[0].Pp_country_rank)
-	maniaGlobalRank := defaultValue(osuMania[0].Pp_rank)
-
-	taikoApi := getData("https://osu.ppy.sh/api/get_user?u=" + message + "&m=1&k=1958afa9967f399f1cd22f52be34d93bcf755212")
-	json.Unmarshal([]byte(taikoApi), &osuTaiko)
-	taikoAkurasi := defaultValue(osuTaiko[0].Accuracy)
-	taikoCountryRank := defaultValue(osuTaiko[0].Pp_country_rank)
-	taikoGlobalRank := defaultValue(osuTaiko[0].Pp_rank)
-
-	ctbApi := getData("https://osu.ppy.sh/api/get_user?u=" + message + "&m=2&k=1958afa9967f399f1cd22f52be34d93bcf755212")
-	json.Unmarshal([]byte(ctbApi), &osuCtb)
-	ctbAkurasi := defaultValue(osuCtb[0].Accuracy)
-	ctbCountryRank := defaultValue(osuCtb[0].Pp_country_rank)
-	ctbGlobalRank := defaultValue(osuCtb[0].Pp_rank)
-
-	jsonString := `{
-		"type": "carousel",
-		"contents": [
-		  {
-			"type": "bubble",
-			"body": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "contents": [
-				{
-				  "type": "text",
-				  "text": "osu!",
-				  "weight": "bold",
-				  "color": "#dc98a4",
-				  "size": "sm"
-				},
-				{
-				  "type": "text",
-				  "text": "` + username + `",
-				  "weight": "bold",
-				  "size": "xxl",
-				  "margin": "md"
-				},
-				{
-				  "type": "text",
-				  "text": "Miraina Tower, 4-1-6 Shinjuku, Tokyo",
-				  "size": "xs",
-				  "color": "#aaaaaa",
-				  "wrap": true
-				},
-				{
-				  "type": "separator",
-				  "margin": "xxl"
-				},
-				{
-				  "type": "box",
-				  "layout": "vertical",
-				  "margin": "xxl",
-				  "spacing": "sm",
-				  "contents": [
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Standard",
-						  "size": "md",
-						  "color": "#555555",
-						  "flex": 0,
-						  "weight": "bold"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Country Rank",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + stdCountryRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Global Rank",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + stdGlobalRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Accuracy",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + stdAkurasi + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "separator",
-					  "margin": "xxl"
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Mania",
-						  "size": "md",
-						  "color": "#555555",
-						  "flex": 0,
-						  "weight": "bold"
-						}
-					  ],
-					  "margin": "xxl"
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Country Rank",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + maniaCountryRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Global Rank",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + maniaGlobalRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Accuracy",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + maniaAkurasi + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					}
-				  ]
-				},
-				{
-				  "type": "separator",
-				  "margin": "xxl"
-				},
-				{
-				  "type": "box",
-				  "layout": "horizontal",
-				  "margin": "md",
-				  "contents": [
-					{
-					  "type": "text",
-					  "text": "Country",
-					  "size": "xs",
-					  "color": "#aaaaaa",
-					  "flex": 0
-					},
-					{
-					  "type": "text",
-					  "text": "aaaa",
-					  "color": "#aaaaaa",
-					  "size": "xs",
-					  "align": "end"
-					}
-				  ]
-				}
-			  ]
-			},
-			"styles": {
-			  "footer": {
-				"separator": true
-			  }
-			}
-		  },
-		  {
-			"type": "bubble",
-			"body": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "contents": [{
-				"type": "box",
-				"layout": "vertical",
-				"contents": [
-				  {
-					"type": "image",
-					"url": "` + imageUrl + `"
-				  }
-				]
-			  },
-				{
-				  "type": "separator",
-				  "margin": "xxl"
-				},
-				{
-				  "type": "box",
-				  "layout": "vertical",
-				  "margin": "xxl",
-				  "spacing": "sm",
-				  "contents": [
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Taiko",
-						  "size": "md",
-						  "color": "#555555",
-						  "flex": 0,
-						  "weight": "bold"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Country Rank",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + taikoCountryRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Global Rank",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + taikoGlobalRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Accuracy",
-						  "size": "sm",
-						  "color": "#555555",
-						  "flex": 0
-						},
-						{
-						  "type": "text",
-						  "text": "` + taikoAkurasi + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "separator",
-					  "margin": "xxl"
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Catch the beat",
-						  "size": "md",
-						  "color": "#555555",
-						  "flex": 0,
-						  "weight": "bold"
-						}
-					  ],
-					  "margin": "xxl"
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Country Rank",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + ctbCountryRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Global Rank",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + ctbGlobalRank + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					},
-					{
-					  "type": "box",
-					  "layout": "horizontal",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Accuracy",
-						  "size": "sm",
-						  "color": "#555555"
-						},
-						{
-						  "type": "text",
-						  "text": "` + ctbAkurasi + `",
-						  "size": "sm",
-						  "color": "#111111",
-						  "align": "end"
-						}
-					  ]
-					}
-				  ]
-				},
-				{
-				  "type": "separator",
-				  "margin": "xxl"
-				}
-			  ]
-			},
-			"styles": {
-			  "footer": {
-				"separator": true
-			  }
-			}
-		  }
-		]
-	  }`
-	contents, err := linebot.UnmarshalFlexMessageJSON([]byte(jsonString))
-	if err != nil {
+// replyFlex checks the flex contents against the LINE limits and replies with
+// them, the alt text is cut to its limit
+func (app *TamakoBot) replyFlex(replyToken, altText string, contents flex.Container) error {
+	altText = flex.AltText(altText)
+	if err := flex.Validate(altText, contents); err != nil {
 		return err
 	}
-	if _, err := app.bot.ReplyMessage(
-		replyToken,
-		linebot.NewFlexMessage("osu! information", contents),
-	).Do(); err != nil {
+	return app.bot.ReplyMessage(replyToken, linebot.NewFlexMessage(altText, contents))
+}
+
+// pushFlex checks the flex contents against the LINE limits and pushes them to
+// a chat, the alt text is cut to its limit
+func (app *TamakoBot) pushFlex(to, altText string, contents flex.Container) error {
+	altText = flex.AltText(altText)
+	if err := flex.Validate(altText, contents); err != nil {
 		return err
 	}
+	return app.bot.PushMessage(to, linebot.NewFlexMessage(altText, contents))
+}
 
-	return nil
+// rankRow is a "label ... value" line of the osu! card
+func rankRow(label, value string) *flex.Box {
+	return flex.HBox(
+		&flex.Text{Text: label, Size: "sm", Color: "#555555", Flex: flex.Int(0)},
+		&flex.Text{Text: defaultValue(value), Size: "sm", Color: "#111111", Align: "end"},
+	)
+}
 
+// detailRow is a "label value" line of the game and manga cards
+func detailRow(label, value string) *flex.Box {
+	row := flex.BaselineBox(
+		&flex.Text{Text: label, Color: "#aaaaaa", Size: "sm", Flex: flex.Int(3), Wrap: true},
+		&flex.Text{Text: defaultValue(value), Wrap: true, Color: "#666666", Size: "sm", Flex: flex.Int(5)},
+	)
+	row.Spacing = "sm"
+	return row
 }
 
-func (app *TamakoBot) urbanMessage(message string, replyToken string) error {
+// osuModeSection lists the stats of one osu! game mode, change is how the
+// global rank moved lately and is left out when empty
+func osuModeSection(ctx context.Context, mode osuMode, stats OsuStatistics, change string) []flex.Component {
+	title := flex.HBox(&flex.Text{Text: mode.Name, Size: "md", Color: "#555555", Flex: flex.Int(0), Weight: "bold"})
+	title.Margin = "xxl"
+	section := []flex.Component{
+		title,
+		rankRow(tr(ctx, "Country Rank"), osuRankText(stats.CountryRank)),
+		rankRow(tr(ctx, "Global Rank"), osuRankText(stats.GlobalRank)),
+	}
+	if change != "" {
+		color := "#aaaaaa"
+		switch change[0] {
+		case '+':
+			color = "#2e7d32"
+		case '-':
+			color = "#c62828"
+		}
+		section = append(section, &flex.Text{Text: change, Size: "xs", Color: color, Align: "end"})
+	}
+	return append(section,
+		rankRow(tr(ctx, "Accuracy"), fmt.Sprintf("%.2f%%", stats.HitAccuracy)),
+		rankRow("PP", strconv.FormatFloat(stats.PP, 'f', 0, 64)),
+		rankRow(tr(ctx, "Play Count"), strconv.Itoa(stats.PlayCount)),
+		rankRow(tr(ctx, "Level"), fmt.Sprintf("%d (%d%%)", stats.Level.Current, stats.Level.Progress)),
+	)
+}
 
-	var urbanApi UrbanApi
-	urban := RequestMashape("https://mashape-community-urban-dictionary.p.mashape.com/define?term=" + Rawurlencode(message))
-	json.Unmarshal([]byte(urban), &urbanApi)
+// osuRankText shows a rank, unranked players have none
+func osuRankText(rank int) string {
+	if rank == 0 {
+		return ""
+	}
+	return "#" + strconv.Itoa(rank)
+}
 
-	word := urbanApi.List[0].Word
-	definition := urbanApi.List[0].Definition
-	example := urbanApi.List[0].Example
+// osuProfileHeader is the top of the osu! cards: the name and country of
+// the player
+func osuProfileHeader(user *OsuUser) []flex.Component {
+	country := user.Country.Name
+	if country == "" {
+		country = user.CountryCode
+	}
+	return []flex.Component{
+		&flex.Text{Text: "osu!", Weight: "bold", Color: "#dc98a4", Size: "sm"},
+		&flex.Text{Text: user.Username, Weight: "bold", Size: "xxl", Margin: "md"},
+		&flex.Text{Text: defaultValue(country), Size: "xs", Color: "#aaaaaa", Wrap: true},
+	}
+}
 
-	fmt.Println("ASDCASDA " + word)
+// osuJoinedRow is the bottom line of the osu! cards
+func osuJoinedRow(ctx context.Context, user *OsuUser) *flex.Box {
+	joined := flex.HBox(
+		&flex.Text{Text: tr(ctx, "Joined"), Size: "xs", Color: "#aaaaaa", Flex: flex.Int(0)},
+		&flex.Text{Text: user.JoinDate.Format("2006-01-02"), Color: "#aaaaaa", Size: "xs", Align: "end"},
+	)
+	joined.Margin = "md"
+	return joined
+}
 
-	if len(urbanApi.List) > 0 {
+// recordOsuLookup feeds the rank history with a lookup and returns the
+// player for the weekly change, nil when the history can't be read
+func (app *TamakoBot) recordOsuLookup(ctx context.Context, user *OsuUser, now time.Time) *store.OsuPlayer {
+	player, err := app.recordOsuSnapshot(strconv.Itoa(user.ID), user.Username, osuSnapshot(user, now), true)
+	if err != nil {
+		slog.ErrorContext(ctx, "osu! history", "user", user.Username, "err", err)
+	}
+	return player
+}
 
-		slang := "Word : " + word + "\nDefinition : " + definition + "\n\nExample : " + example
-		if _, err := app.bot.ReplyMessage(replyToken, linebot.NewTextMessage(slang)).Do(); err != nil {
-			return err
+func (app *TamakoBot) osuMessage(ctx context.Context, message string, replyToken string) error {
+	if message == "" {
+		return app.replyText(replyToken, tr(ctx, "osu information not found"))
+	}
+	user, err := app.osuUser(ctx, osuUserByName(message), osuModes...)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return app.replyText(replyToken, tr(ctx, "osu information not found"))
+	}
+
+	// every lookup feeds the rank history, the card shows the weekly change
+	now := time.Now()
+	player := app.recordOsuLookup(ctx, user, now)
+	section := func(key string) []flex.Component {
+		mode, _ := findOsuMode(key)
+		stats, ok := user.Rulesets[key]
+		if !ok {
+			title := flex.HBox(&flex.Text{Text: mode.Name, Size: "md", Color: "#555555", Flex: flex.Int(0), Weight: "bold"})
+			title.Margin = "xxl"
+			return []flex.Component{title, &flex.Text{Text: tr(ctx, "Not available right now"), Size: "sm", Color: "#aaaaaa"}}
 		}
+		return osuModeSection(ctx, mode, stats, osuWeeklyChange(ctx, player, key, now))
+	}
 
-	} else {
-		return app.replyText(replyToken, "Slang words not found")
+	ranks := flex.VBox()
+	ranks.Margin = "xxl"
+	ranks.Spacing = "sm"
+	ranks.Add(section("standard")...)
+	ranks.Add(&flex.Separator{Margin: "xxl"})
+	ranks.Add(section("mania")...)
+
+	profile := &flex.Bubble{
+		Body: flex.VBox(osuProfileHeader(user)...).Add(
+			&flex.Separator{Margin: "xxl"},
+			ranks,
+			&flex.Separator{Margin: "xxl"},
+			osuJoinedRow(ctx, user),
+		),
+		Styles: &flex.BubbleStyles{Footer: &flex.BlockStyle{Separator: true}},
+	}
+
+	otherRanks := flex.VBox()
+	otherRanks.Margin = "xxl"
+	otherRanks.Spacing = "sm"
+	otherRanks.Add(section("taiko")...)
+	otherRanks.Add(&flex.Separator{Margin: "xxl"})
+	otherRanks.Add(section("ctb")...)
+
+	avatar := &flex.Bubble{
+		Body: flex.VBox(
+			flex.VBox(&flex.Image{URL: defaultImage(user.AvatarURL)}),
+			&flex.Separator{Margin: "xxl"},
+			otherRanks,
+			&flex.Separator{Margin: "xxl"},
+		),
+		Styles: &flex.BubbleStyles{Footer: &flex.BlockStyle{Separator: true}},
 	}
-	return nil
 
+	carousel := &flex.Carousel{Contents: []*flex.Bubble{profile, avatar}}
+	return app.replyFlex(replyToken, tr(ctx, "osu! information of %s", user.Username), carousel)
 }
 
-func (app *TamakoBot) motwMessage(replyToken string) error {
+// osuModeCommand replies with the detailed card of a player in one game
+// mode, the one of --mode or the player's own
+func (app *TamakoBot) osuModeCommand(req *CommandRequest, name string) error {
+	ctx := req.Context()
+	if _, _, ok := osuModeFlag(req); !ok {
+		return app.replyText(req.ReplyToken, tr(ctx, "Unknown mode, use one of %s", strings.Join(osuModeKeys(), ", ")))
+	}
+	user, mode, err := app.osuUserInMode(ctx, req, name)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return app.replyText(req.ReplyToken, tr(ctx, "osu information not found"))
+	}
+	now := time.Now()
+	player := app.recordOsuLookup(ctx, user, now)
+	stats := user.Rulesets[mode.Key]
+
+	ranks := flex.VBox(osuModeSection(ctx, mode, stats, osuWeeklyChange(ctx, player, mode.Key, now))...)
+	ranks.Margin = "xxl"
+	ranks.Spacing = "sm"
+	grades := stats.GradeCounts
+	ranks.Add(
+		rankRow(tr(ctx, "Ranked Score"), strconv.FormatInt(stats.RankedScore, 10)),
+		rankRow(tr(ctx, "Max Combo"), strconv.Itoa(stats.MaximumCombo)+"x"),
+		rankRow(tr(ctx, "Play Time"), tr(ctx, "%s hours", strconv.Itoa(stats.PlayTime/3600))),
+		rankRow("SS/S/A", fmt.Sprintf("%d/%d/%d", grades.SS+grades.SSH, grades.S+grades.SH, grades.A)),
+	)
+
+	bubble := &flex.Bubble{
+		Hero: &flex.Image{URL: defaultImage(user.AvatarURL), Size: "full", AspectMode: "cover", AspectRatio: "1:1"},
+		Body: flex.VBox(osuProfileHeader(user)...).Add(
+			&flex.Separator{Margin: "xxl"},
+			ranks,
+			&flex.Separator{Margin: "xxl"},
+			osuJoinedRow(ctx, user),
+		),
+		Footer: flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Profile"), URI: "https://osu.ppy.sh/users/" + strconv.Itoa(user.ID) + "/" + mode.Ruleset},
+			Style:  "primary",
+			Color:  "#dc98a4",
+		}),
+	}
+	return app.replyFlex(req.ReplyToken, tr(ctx, "osu! %s information of %s", mode.Name, user.Username), &flex.Carousel{Contents: []*flex.Bubble{bubble}})
+}
+
+func (app *TamakoBot) urbanMessage(ctx context.Context, message string, replyToken string) error {
+
+	var urbanApi UrbanApi
+	url := app.providerURL(providerUrban, "/define?term="+Rawurlencode(message))
+	header := http.Header{"X-Mashape-Key": {app.config.Provider(providerUrban).Key}}
+	if err := app.http.GetJSON(ctx, "Urban Dictionary", url, header, &urbanApi); err != nil {
+		return err
+	}
+
+	if len(urbanApi.List) == 0 {
+		return app.replyText(replyToken, tr(ctx, "Slang words not found"))
+	}
+
+	word := urbanApi.List[0].Word
+	definition := urbanApi.List[0].Definition
+	example := urbanApi.List[0].Example
+
+	slang := tr(ctx, "Word : %s\nDefinition : %s\n\nExample : %s", word, definition, example)
+	return app.replyText(replyToken, slang)
+}
+
+func (app *TamakoBot) motwMessage(ctx context.Context, replyToken string) error {
 	var motwApi MotwApi
 
-	motw := getData("https://rss.itunes.apple.com/api/v1/id/apple-music/top-songs/all/10/explicit.json")
-	json.Unmarshal([]byte(motw), &motwApi)
+	url := app.providerURL(providerITunes, "/id/apple-music/top-songs/all/10/explicit.json")
+	if err := app.http.GetJSON(ctx, "iTunes", url, nil, &motwApi); err != nil {
+		return err
+	}
 	//var id []string
 	var columns []*linebot.CarouselColumn
 	//var actions []linebot.TemplateAction
@@ -965,211 +882,98 @@ func (app *TamakoBot) motwMessage(replyToken string) error {
 		artistName := details.ArtistName
 		titleName := details.Name
 		id := details.Id
-		columns = append(columns, linebot.NewCarouselColumn(imgUrl, artistName, titleName, linebot.NewMessageAction("Preview", "!details "+id)))
+		columns = append(columns, linebot.NewCarouselColumn(imgUrl, artistName, titleName, linebot.NewMessageAction(tr(ctx, "Preview"), "!details "+id)))
 
 	}
 
 	template := linebot.NewCarouselTemplate(columns...)
-	if _, err := app.bot.ReplyMessage(
+	if err := app.bot.ReplyMessage(
 		replyToken,
-		linebot.NewTemplateMessage("Music of the week", template),
-	).Do(); err != nil {
+		linebot.NewTemplateMessage(tr(ctx, "Music of the week"), template),
+	); err != nil {
 		return err
 	}
 	return nil
 	// return app.replyText(replyToken, "Video game information not found")
 }
 
-func (app *TamakoBot) gameMessage(message string, replyToken string) error {
+func (app *TamakoBot) gameMessage(ctx context.Context, message string, replyToken string) error {
 	var gameList GameList
 	queryGame := Rawurlencode(message)
-	url := "https://www.giantbomb.com/api/search/?api_key=a0bede1760f86f2f59ff3ac477c953fed643ea0b&resources=game&query=" + queryGame + "&format=json&limit=5"
-	gameApi := Request(url, "lashaparesha api script")
-	json.Unmarshal([]byte(gameApi), &gameList)
-
-	var jsonString string
-
-	hitung := len(gameList.Results)
-	if hitung > 0 {
-		result := make([]string, hitung)
-
-		// exe := string(runes[hitung:]) // hapus comma
-
-		for _, details := range gameList.Results {
-
-			title := defaultValue(details.Name)
-			release_date := defaultValue(details.Original_release_date)
-			small_url := defaultImage(details.Image.Small_url)
-			deck := defaultValue(details.Deck)
-			link := details.Site_detail_url
-
-			platforms := []string{}
+	url := app.providerURL(providerGiantBomb, "/search/?api_key="+app.config.Provider(providerGiantBomb).Key+"&resources=game&query="+queryGame+"&format=json&limit=5")
+	header := http.Header{"User-Agent": {"lashaparesha api script"}}
+	if err := app.http.GetJSON(ctx, "GiantBomb", url, header, &gameList); err != nil {
+		return err
+	}
 
-			for _, plat := range details.Platforms {
-				platform := plat.Name
-				platforms = append(platforms, platform)
-			}
+	if len(gameList.Results) == 0 {
+		return app.replyText(replyToken, tr(ctx, "Video game information not found"))
+	}
 
-			joinPlat := strings.Join(platforms, ", ")
-
-			jsonString = `{
-		  "type": "bubble",
-		  "hero": {
-			"type": "image",
-			"url": "` + small_url + `",
-			"size": "full",
-			"aspectRatio": "8:9",
-			"aspectMode": "cover"
-		  },
-		  "body": {
-			"type": "box",
-			"layout": "vertical",
-			"contents": [
-			  {
-				"type": "text",
-				"text": "` + title + `",
-				"weight": "bold",
-				"size": "xl",
-				"wrap": true
-			  },
-			  {
-				"type": "box",
-				"layout": "vertical",
-				"margin": "lg",
-				"spacing": "sm",
-				"contents": [
-				  {
-					"type": "box",
-					"layout": "baseline",
-					"spacing": "sm",
-					"contents": [
-					  {
-						"type": "text",
-						"text": "Release Date",
-						"color": "#aaaaaa",
-						"size": "sm",
-						"flex": 3,
-						"wrap": true
-					  },
-					  {
-						"type": "text",
-						"text": "` + release_date + `",
-						"wrap": true,
-						"color": "#666666",
-						"size": "sm",
-						"flex": 5
-					  }
-					]
-				  },
-				  {
-					"type": "box",
-					"layout": "baseline",
-					"spacing": "sm",
-					"contents": [
-					  {
-						"type": "text",
-						"text": "Platform",
-						"color": "#aaaaaa",
-						"size": "sm",
-						"flex": 3
-					  },
-					  {
-						"type": "text",
-						"text": "` + joinPlat + `",
-						"wrap": true,
-						"color": "#666666",
-						"size": "sm",
-						"flex": 5
-					  }
-					]
-				  }
-				]
-			  },
-			  {
-				"type": "box",
-				"layout": "vertical",
-				"contents": [
-				  {
-					"type": "text",
-					"text": "Description",
-					"weight": "bold",
-					"size": "sm"
-				  },
-				  {
-					"type": "box",
-					"layout": "vertical",
-					"contents": [
-					  {
-						"type": "text",
-						"text": "` + deck + `",
-						"margin": "lg",
-						"size": "sm",
-						"wrap": true
-					  }
-					],
-					"paddingTop": "5px"
-				  }
-				],
-				"margin": "xl",
-				"cornerRadius": "2px"
-			  }
-			],
-			"backgroundColor": "#aaaaaa"
-		  },
-		  "footer": {
-			"type": "box",
-			"layout": "vertical",
-			"spacing": "sm",
-			"contents": [
-			  {
-				"type": "button",
-				"style": "link",
-				"height": "sm",
-				"action": {
-				  "type": "uri",
-				  "label": "Open Browser",
-				  "uri": "` + link + `"
-				}
-			  },
-			  {
-				"type": "spacer",
-				"size": "sm"
-			  }
-			],
-			"flex": 0
-		  }
-		}`
-
-			result = append(result, jsonString)
+	carousel := &flex.Carousel{}
+	for _, details := range gameList.Results {
+		if len(carousel.Contents) == flex.MaxCarouselBubbles {
+			break
 		}
 
-		joinString := strings.Join(result, ",") // join string
-		runes := []rune(joinString)
-		exe := string(runes[hitung:]) // hapus comma
-		resultz := fmt.Sprintf(`{ 
-		"type": "carousel",
-		"contents": [%s]
-	  }`, exe) // gabung json string ke type carousel
-
-		fmt.Print("WADOOOHHH" + exe)
-		fmt.Println("BAAASATTTT: " + url)
-		contents, err := linebot.UnmarshalFlexMessageJSON([]byte(resultz))
-		if err != nil {
-			return err
+		platforms := []string{}
+		for _, plat := range details.Platforms {
+			platforms = append(platforms, plat.Name)
 		}
-		if _, err := app.bot.ReplyMessage(
-			replyToken,
-			linebot.NewFlexMessage("Flex message alt text", contents),
-		).Do(); err != nil {
-			return err
+
+		info := flex.VBox(
+			detailRow(tr(ctx, "Release Date"), details.Original_release_date),
+			detailRow(tr(ctx, "Platform"), strings.Join(platforms, ", ")),
+		)
+		info.Margin = "lg"
+		info.Spacing = "sm"
+
+		deck := flex.VBox(&flex.Text{Text: defaultValue(details.Deck), Margin: "lg", Size: "sm", Wrap: true})
+		deck.PaddingTop = "5px"
+		description := flex.VBox(
+			&flex.Text{Text: tr(ctx, "Description"), Weight: "bold", Size: "sm"},
+			deck,
+		)
+		description.Margin = "xl"
+		description.CornerRadius = "2px"
+
+		body := flex.VBox(
+			&flex.Text{Text: defaultValue(details.Name), Weight: "bold", Size: "xl", Wrap: true},
+			info,
+			description,
+		)
+		body.BackgroundColor = "#aaaaaa"
+
+		footer := flex.VBox()
+		// a game without a page only loses its button
+		if details.Site_detail_url != "" {
+			footer.Add(&flex.Button{Style: "link", Height: "sm", Action: &flex.URIAction{Label: tr(ctx, "Open Browser"), URI: details.Site_detail_url}})
 		}
-	} else {
-		return app.replyText(replyToken, "Video game information not found")
+		footer.Add(&flex.Spacer{Size: "sm"})
+		footer.Spacing = "sm"
+		footer.Flex = flex.Int(0)
+
+		carousel.Add(&flex.Bubble{
+			Hero:   &flex.Image{URL: defaultImage(details.Image.Small_url), Size: "full", AspectRatio: "8:9", AspectMode: "cover"},
+			Body:   body,
+			Footer: footer,
+		})
 	}
-	return nil
+
+	return app.replyFlex(replyToken, tr(ctx, "Video game search: %s", message), carousel)
 }
 
-func (app *TamakoBot) dotaMessage(message string, replyToken string) error {
+// steamURL returns the URL of a Steam Web API method with the API key set
+func (app *TamakoBot) steamURL(method, query string) string {
+	return app.providerURL(providerSteam, method+"?key="+app.config.Provider(providerSteam).Key+"&"+query)
+}
+
+// steamVanityURL resolves a steamcommunity.com/id/<vanity> name to a SteamID
+func (app *TamakoBot) steamVanityURL(vanity string) string {
+	return app.steamURL("/ISteamUser/ResolveVanityURL/v0001/", "vanityurl="+Rawurlencode(vanity))
+}
+
+func (app *TamakoBot) dotaMessage(ctx context.Context, message string, replyToken string) error {
 
 	var steam Steam
 	var dotaProfile DotaProfile
@@ -1178,35 +982,47 @@ func (app *TamakoBot) dotaMessage(message string, replyToken string) error {
 	var recentMatch []DotaMatch
 
 	if message == "" {
-		return app.replyText(replyToken, "Dota 2 information not found")
+		return app.replyText(replyToken, tr(ctx, "Dota 2 information not found"))
 	}
 
 	// Get 64bit SteamId
-	steamJson := getData("https://api.steampowered.com/ISteamUser/ResolveVanityURL/v0001/?key=7834436769DDB41F2D14A2F312377946&vanityurl=" + message)
-	json.Unmarshal([]byte(steamJson), &steam)
+	if err := app.http.GetJSON(ctx, "Steam", app.steamVanityURL(message), nil, &steam); err != nil {
+		return err
+	}
+	if steam.Response.Steamid == "" {
+		return app.replyText(replyToken, tr(ctx, "Dota 2 information not found"))
+	}
 	steam_64 := convert32bit(steam.Response.Steamid)
+	player := app.providerURL(providerOpenDota, "/players/"+steam_64)
 
 	// Get Dota 2 Player Profile
-	get_info_dota := getData("https://api.opendota.com/api/players/" + steam_64)
-	json.Unmarshal([]byte(get_info_dota), &dotaProfile)
+	if err := app.http.GetJSON(ctx, "OpenDota", player, nil, &dotaProfile); err != nil {
+		return err
+	}
 
 	// Get Dota 2 Win Rate
-	get_winrate := getData("https://api.opendota.com/api/players/" + steam_64 + "/wl")
-	json.Unmarshal([]byte(get_winrate), &dotaWinrate)
+	if err := app.http.GetJSON(ctx, "OpenDota", player+"/wl", nil, &dotaWinrate); err != nil {
+		return err
+	}
 	win := strconv.Itoa(dotaWinrate.Win)
 	// lose := strconv.Itoa(dotaWinrate.Lose)
 	totalMatch := strconv.Itoa(dotaWinrate.Win + dotaWinrate.Lose)
 
 	// Get Dota 2 Signature Hero
-	get_signature_hero := getData("https://api.opendota.com/api/players/" + steam_64 + "/heroes")
-	json.Unmarshal([]byte(get_signature_hero), &signatureHero)
+	if err := app.http.GetJSON(ctx, "OpenDota", player+"/heroes", nil, &signatureHero); err != nil {
+		return err
+	}
+	// Get Dota 2 Recent Match
+	if err := app.http.GetJSON(ctx, "OpenDota", player+"/recentMatches", nil, &recentMatch); err != nil {
+		return err
+	}
+	if len(signatureHero) == 0 || len(recentMatch) == 0 {
+		return app.replyText(replyToken, tr(ctx, "Dota 2 information not found"))
+	}
 	signature_hero := hero_id_to_names(signatureHero[0].Hero_id)
 
-	// Get Dota 2 Recent Match
-	get_recent_match := getData("https://api.opendota.com/api/players/" + steam_64 + "/recentMatches")
-	json.Unmarshal([]byte(get_recent_match), &recentMatch)
 	matchId := "https://www.dotabuff.com/matches/" + strconv.Itoa(recentMatch[0].Match_id)
-	hero := "Hero : " + hero_id_to_names(strconv.Itoa(recentMatch[0].Hero_id))
+	hero := tr(ctx, "Hero : %s", hero_id_to_names(strconv.Itoa(recentMatch[0].Hero_id)))
 	kda := "K/D/A : " + strconv.Itoa(recentMatch[0].Kills) + "/" + strconv.Itoa(recentMatch[0].Deaths) + "/" + strconv.Itoa(recentMatch[0].Assists)
 	lh_gpm := "LH/GPM : " + strconv.Itoa(recentMatch[0].Last_hits) + "/" + strconv.Itoa(recentMatch[0].Gold_per_min)
 
@@ -1215,262 +1031,117 @@ func (app *TamakoBot) dotaMessage(message string, replyToken string) error {
 	imageURL := dotaProfile.Profile.Avatarfull
 	template := linebot.NewCarouselTemplate(
 		linebot.NewCarouselColumn(
-			imageURL, dotaProfile.Profile.Personaname, "Win : "+win+"\nTotal Match : "+totalMatch+"\nSignature Hero : "+signature_hero,
-			linebot.NewURIAction("Open Steam", steamUrl),
+			imageURL, dotaProfile.Profile.Personaname, tr(ctx, "Win : %s\nTotal Match : %s\nSignature Hero : %s", win, totalMatch, signature_hero),
+			linebot.NewURIAction(tr(ctx, "Open Steam"), steamUrl),
 		),
 		linebot.NewCarouselColumn(
-			imageURL, "Recent Match Played", hero+"\n"+kda+"\n"+lh_gpm,
-			linebot.NewURIAction("Open Dotabuff", matchId),
+			imageURL, tr(ctx, "Recent Match Played"), hero+"\n"+kda+"\n"+lh_gpm,
+			linebot.NewURIAction(tr(ctx, "Open Dotabuff"), matchId),
 		),
 	)
 
-	if _, err := app.bot.ReplyMessage(
+	if err := app.bot.ReplyMessage(
 		replyToken,
-		linebot.NewTemplateMessage("Info Dota", template),
-	).Do(); err != nil {
+		linebot.NewTemplateMessage(tr(ctx, "Dota 2 information of %s", defaultValue(dotaProfile.Profile.Personaname)), template),
+	); err != nil {
 		return err
 	}
 	return nil
 }
 
-func defaultJson(message string) string {
-	if len(message) > 0 {
-		return message
-	}
-	json_content := `{
-		"type": "box",
-		"layout": "baseline",
-		"contents": [
-		  {
-			"type": "text",
-			"text": "-",
-			"size": "xs",
-			"color": "#8c8c8c",
-			"margin": "md",
-			"flex": 1,
-			"wrap": true
-		  },
-		  {
-			"type": "text",
-			"text": "-",
-			"flex": 0,
-			"margin": "md",
-			"size": "xs",
-			"color": "#8c8c8c"
-		  }
-		]
-	  }`
-	return json_content
-}
-
-func (app *TamakoBot) steamMessage(message string, replyToken string) error {
+func (app *TamakoBot) steamMessage(ctx context.Context, message string, replyToken string) error {
 	var steam Steam
 	// var gameCount Responses
 	var steamProfile Res
 	var gameSteam Responses
-	var gameName string
-	var toHrs string
-	steamJson := getData("https://api.steampowered.com/ISteamUser/ResolveVanityURL/v0001/?key=7834436769DDB41F2D14A2F312377946&vanityurl=" + message)
-	json.Unmarshal([]byte(steamJson), &steam)
+	if err := app.http.GetJSON(ctx, "Steam", app.steamVanityURL(message), nil, &steam); err != nil {
+		return err
+	}
 	steam_32 := steam.Response.Steamid
 	if len(steam_32) == 0 || message == "" {
-		return app.replyText(replyToken, "Steam information not found")
+		return app.replyText(replyToken, tr(ctx, "Steam information not found"))
 	}
-	// getGameCount := getData("http://api.steampowered.com/IPlayerService/GetOwnedGames/v0001/?key=7834436769DDB41F2D14A2F312377946&steamid=" + steam_32 + "&format=json")
+	// getGameCount := getData(app.steamURL("/IPlayerService/GetOwnedGames/v0001/", "steamid="+steam_32+"&format=json"))
 	// json.Unmarshal([]byte(getGameCount), &gameCount)
 
-	getSteamProfile := getData("http://api.steampowered.com/ISteamUser/GetPlayerSummaries/v0002/?key=7834436769DDB41F2D14A2F312377946&steamids=" + steam_32)
-	json.Unmarshal([]byte(getSteamProfile), &steamProfile)
+	summariesURL := app.steamURL("/ISteamUser/GetPlayerSummaries/v0002/", "steamids="+steam_32)
+	if err := app.http.GetJSON(ctx, "Steam", summariesURL, nil, &steamProfile); err != nil {
+		return err
+	}
+	if len(steamProfile.Response.Players) == 0 {
+		return app.replyText(replyToken, tr(ctx, "Steam information not found"))
+	}
 
-	getRecentGames := getData("https://api.steampowered.com/IPlayerService/GetRecentlyPlayedGames/v0001/?key=7834436769DDB41F2D14A2F312377946&steamid=" + steam_32 + "&count=3&format=json")
-	json.Unmarshal([]byte(getRecentGames), &gameSteam)
+	recentURL := app.steamURL("/IPlayerService/GetRecentlyPlayedGames/v0001/", "steamid="+steam_32+"&count=3&format=json")
+	if err := app.http.GetJSON(ctx, "Steam", recentURL, nil, &gameSteam); err != nil {
+		return err
+	}
 
-	get_nickname := defaultValue(steamProfile.Response.Players[0].Personaname)
-	get_avatar := defaultValue(steamProfile.Response.Players[0].Avatarfull)
-	get_realname := defaultValue(steamProfile.Response.Players[0].Realname)
-	get_state := state_profile_steam(strconv.Itoa(steamProfile.Response.Players[0].Personastate))
-	get_profile := steamProfile.Response.Players[0].Profileurl
+	player := steamProfile.Response.Players[0]
+	get_state := i18n.Text(languageOf(ctx), state_profile_steam(strconv.Itoa(player.Personastate)))
 
-	ListRecent := []string{}
+	recentTitle := flex.BaselineBox(&flex.Text{Text: tr(ctx, "Recently Played Games"), Wrap: true, Color: "#111111", Size: "xs", Flex: flex.Int(5), Weight: "bold"})
+	recentTitle.Spacing = "sm"
+	recent := flex.VBox(&flex.Spacer{}, recentTitle)
 
 	for _, detailRecent := range gameSteam.Response.Games {
-
-		gameName = defaultValue(detailRecent.Name)
-		toHrs = defaultValue(strconv.Itoa(detailRecent.Playtime_forever / 60))
-		//gamePlaytime := strconv.Itoa(detailRecent.Playtime_forever)
-
-		json_content := `{
-			"type": "box",
-			"layout": "baseline",
-			"contents": [
-			  {
-				"type": "text",
-				"text": "` + gameName + `",
-				"size": "xs",
-				"color": "#8c8c8c",
-				"margin": "md",
-				"flex": 1,
-				"wrap": true
-			  },
-			  {
-				"type": "text",
-				"text": "` + toHrs + ` hours",
-				"flex": 0,
-				"margin": "md",
-				"size": "xs",
-				"color": "#8c8c8c"
-			  }
-			]
-		  }`
-		fmt.Println("XXCSADACX " + json_content)
-		ListRecent = append(ListRecent, json_content)
-	}
-	join_json := defaultJson(strings.Join(ListRecent, ", "))
-
-	fmt.Println("XSASDASAXSXA" + join_json)
-	resultz := `{
-		"type": "carousel",
-		"contents": [
-		  {
-			"type": "bubble",
-			"size": "kilo",
-			"hero": {
-			  "type": "image",
-			  "url": "` + get_avatar + `",
-			  "size": "full",
-			  "aspectMode": "cover",
-			  "aspectRatio": "1:1"
-			},
-			"body": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "contents": [
-				{
-				  "type": "text",
-				  "text": "` + get_nickname + `",
-				  "weight": "bold",
-				  "size": "md",
-				  "wrap": true
-				},
-				{
-				  "type": "box",
-				  "layout": "baseline",
-				  "contents": [
-					{
-					  "type": "text",
-					  "text": "` + get_realname + `",
-					  "size": "xs",
-					  "color": "#8c8c8c",
-					  "margin": "sm",
-					  "flex": 0
-					}
-				  ]
-				},
-				{
-				  "type": "box",
-				  "layout": "baseline",
-				  "contents": [
-					{
-					  "type": "text",
-					  "text": "` + get_state + `",
-					  "size": "xs",
-					  "color": "#8c8c8c",
-					  "margin": "md",
-					  "flex": 0
-					}
-				  ]
-				},
-				{
-				  "type": "box",
-				  "layout": "vertical",
-				  "contents": [
-					{
-					  "type": "spacer"
-					},
-					{
-					  "type": "box",
-					  "layout": "baseline",
-					  "spacing": "sm",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Recently Played Games",
-						  "wrap": true,
-						  "color": "#111111",
-						  "size": "xs",
-						  "flex": 5,
-						  "weight": "bold"
-						}
-					  ]
-					},
-					` + join_json + `
-				  ]
-				}
-			  ],
-			  "spacing": "sm",
-			  "paddingAll": "13px"
-			},
-			"footer": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "contents": [
-				{
-				  "type": "button",
-				  "action": {
-					"type": "uri",
-					"label": "Open Steam",
-					"uri": "` + get_profile + `"
-				  },
-				  "style": "primary",
-				  "color": "#1b2838"
-				}
-			  ]
-			}
-		  }
-		]
-	  }`
-	fmt.Println(resultz)
-	contents, err := linebot.UnmarshalFlexMessageJSON([]byte(resultz))
-	if err != nil {
-		return err
+		toHrs := strconv.Itoa(detailRecent.Playtime_forever / 60)
+		recent.Add(flex.BaselineBox(
+			&flex.Text{Text: defaultValue(detailRecent.Name), Size: "xs", Color: "#8c8c8c", Margin: "md", Flex: flex.Int(1), Wrap: true},
+			&flex.Text{Text: tr(ctx, "%s hours", toHrs), Flex: flex.Int(0), Margin: "md", Size: "xs", Color: "#8c8c8c"},
+		))
 	}
-	if _, err := app.bot.ReplyMessage(
-		replyToken,
-		linebot.NewFlexMessage("Steam Information", contents),
-	).Do(); err != nil {
-		return err
+	if len(gameSteam.Response.Games) == 0 {
+		recent.Add(flex.BaselineBox(
+			&flex.Text{Text: "-", Size: "xs", Color: "#8c8c8c", Margin: "md", Flex: flex.Int(1), Wrap: true},
+			&flex.Text{Text: "-", Flex: flex.Int(0), Margin: "md", Size: "xs", Color: "#8c8c8c"},
+		))
 	}
 
-	return nil
-	// fmt.Println("aaaaaaxxx " + get_game_2)
-	// return app.replyText(replyToken, get_game_2)
+	body := flex.VBox(
+		&flex.Text{Text: defaultValue(player.Personaname), Weight: "bold", Size: "md", Wrap: true},
+		flex.BaselineBox(&flex.Text{Text: defaultValue(player.Realname), Size: "xs", Color: "#8c8c8c", Margin: "sm", Flex: flex.Int(0)}),
+		flex.BaselineBox(&flex.Text{Text: defaultValue(get_state), Size: "xs", Color: "#8c8c8c", Margin: "md", Flex: flex.Int(0)}),
+		recent,
+	)
+	body.Spacing = "sm"
+	body.PaddingAll = "13px"
+
+	bubble := &flex.Bubble{
+		Size: "kilo",
+		Hero: &flex.Image{URL: defaultImage(player.Avatarfull), Size: "full", AspectMode: "cover", AspectRatio: "1:1"},
+		Body: body,
+		Footer: flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Steam"), URI: player.Profileurl},
+			Style:  "primary",
+			Color:  "#1b2838",
+		}),
+	}
+
+	return app.replyFlex(replyToken, tr(ctx, "Steam information of %s", defaultValue(player.Personaname)), &flex.Carousel{Contents: []*flex.Bubble{bubble}})
 }
 
-func (app *TamakoBot) mangaMessage(message string, replyToken string) error {
+func (app *TamakoBot) mangaMessage(ctx context.Context, message string, replyToken string) error {
 	var getManga MangaApi
-	var getGenre GenreApi
 
 	queryManga := Rawurlencode(message)
 
-	get_manga := getData("https://kitsu.io/api/edge/manga?filter[text]=" + queryManga + "&page[limit]=3&page[offset]=0")
-	json.Unmarshal([]byte(get_manga), &getManga)
-
-	result := []string{}
+	mangaURL := app.providerURL(providerKitsu, "/manga?filter[text]="+queryManga+"&page[limit]=3&page[offset]=0")
+	if err := app.http.GetJSON(ctx, "Kitsu", mangaURL, nil, &getManga); err != nil {
+		return err
+	}
 
 	if len(getManga.Data) == 0 || message == "" {
-		return app.replyText(replyToken, "Manga information not found")
+		return app.replyText(replyToken, tr(ctx, "Manga information not found"))
 	}
 
+	carousel := &flex.Carousel{}
 	for _, details := range getManga.Data {
-		title := defaultValue(details.Attributes.CanonicalTitle)
-		image := details.Attributes.PosterImage.Medium
-		status := defaultValue(details.Attributes.Status)
-		rating := defaultValue(details.Attributes.AverageRating)
-		synopsis := defaultValue(details.Attributes.Synopsis)
-
+		var getGenre GenreApi
 		get_genre_endpoint := details.Relationships.Genres.Links.Related
-		get_genre := getData(get_genre_endpoint)
-		json.Unmarshal([]byte(get_genre), &getGenre)
+		if err := app.http.GetJSON(ctx, "Kitsu", get_genre_endpoint, nil, &getGenre); err != nil {
+			return err
+		}
 
 		genresArray := []string{}
 
@@ -1478,189 +1149,59 @@ func (app *TamakoBot) mangaMessage(message string, replyToken string) error {
 			genres := getGenre.Data[i].Attributes.Name
 			genresArray = append(genresArray, genres)
 		}
-		join_genre := defaultValue(strings.Join(genresArray, ", "))
-
-		// fmt.Println(join_genre)
-		// fmt.Println(get_genre_endpoint)
-
-		jsonString := `{
-			"type": "bubble",
-			"hero": {
-			  "type": "image",
-			  "url": "` + image + `",
-			  "size": "full",
-			  "aspectRatio": "7:9",
-			  "aspectMode": "cover"
-			},
-			"body": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "contents": [
-				{
-				  "type": "text",
-				  "text": "` + title + `",
-				  "weight": "bold",
-				  "size": "xl",
-				  "wrap": true
-				},
-				{
-				  "type": "box",
-				  "layout": "vertical",
-				  "margin": "lg",
-				  "spacing": "sm",
-				  "contents": [
-					{
-					  "type": "box",
-					  "layout": "baseline",
-					  "spacing": "sm",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Status",
-						  "color": "#aaaaaa",
-						  "size": "sm",
-						  "flex": 3,
-						  "wrap": true
-						},
-						{
-						  "type": "text",
-						  "text": "` + status + `",
-						  "wrap": true,
-						  "color": "#666666",
-						  "size": "sm",
-						  "flex": 5
-						}
-					  ]
-					},{
-						"type": "box",
-						"layout": "baseline",
-						"spacing": "sm",
-						"contents": [
-						  {
-							"type": "text",
-							"text": "Rating",
-							"color": "#aaaaaa",
-							"size": "sm",
-							"flex": 3,
-							"wrap": true
-						  },
-						  {
-							"type": "text",
-							"text": "` + rating + `",
-							"wrap": true,
-							"color": "#666666",
-							"size": "sm",
-							"flex": 5
-						  }
-						]
-					  },
-					{
-					  "type": "box",
-					  "layout": "baseline",
-					  "spacing": "sm",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "Genre",
-						  "color": "#aaaaaa",
-						  "size": "sm",
-						  "flex": 3
-						},
-						{
-						  "type": "text",
-						  "text": "` + join_genre + `",
-						  "wrap": true,
-						  "color": "#666666",
-						  "size": "sm",
-						  "flex": 5
-						}
-					  ]
-					}
-				  ]
-				},
-				{
-				  "type": "box",
-				  "layout": "vertical",
-				  "contents": [
-					{
-					  "type": "text",
-					  "text": "Synopsis",
-					  "weight": "bold",
-					  "size": "sm"
-					},
-					{
-					  "type": "box",
-					  "layout": "vertical",
-					  "contents": [
-						{
-						  "type": "text",
-						  "text": "` + synopsis + `",
-						  "margin": "lg",
-						  "size": "xs",
-						  "wrap": true,
-						  "align": "center"
-						}
-					  ],
-					  "paddingTop": "5px"
-					}
-				  ],
-				  "margin": "xl",
-				  "cornerRadius": "2px"
-				}
-			  ]
-			},
-			"footer": {
-			  "type": "box",
-			  "layout": "vertical",
-			  "spacing": "sm",
-			  "contents": [
-				{
-				  "type": "spacer",
-				  "size": "sm"
-				}
-			  ],
-			  "flex": 0
-			}
-		  }`
-		result = append(result, jsonString)
-	}
-	join_string := strings.Join(result, ", ")
-	result_carousel := fmt.Sprintf(`{ 
-		"type": "carousel",
-		"contents": [%s]
-	  }`, join_string) // gabung json string ke type carousel
 
-	contents, err := linebot.UnmarshalFlexMessageJSON([]byte(result_carousel))
-	if err != nil {
-		return err
-	}
-	if _, err := app.bot.ReplyMessage(
-		replyToken,
-		linebot.NewFlexMessage("Manga Information", contents),
-	).Do(); err != nil {
-		return err
+		info := flex.VBox(
+			detailRow(tr(ctx, "Status"), details.Attributes.Status),
+			detailRow(tr(ctx, "Rating"), details.Attributes.AverageRating),
+			detailRow(tr(ctx, "Genre"), strings.Join(genresArray, ", ")),
+		)
+		info.Margin = "lg"
+		info.Spacing = "sm"
+
+		synopsisText := flex.VBox(&flex.Text{Text: defaultValue(details.Attributes.Synopsis), Margin: "lg", Size: "xs", Wrap: true, Align: "center"})
+		synopsisText.PaddingTop = "5px"
+		synopsis := flex.VBox(
+			&flex.Text{Text: tr(ctx, "Synopsis"), Weight: "bold", Size: "sm"},
+			synopsisText,
+		)
+		synopsis.Margin = "xl"
+		synopsis.CornerRadius = "2px"
+
+		footer := flex.VBox(&flex.Spacer{Size: "sm"})
+		footer.Spacing = "sm"
+		footer.Flex = flex.Int(0)
+
+		carousel.Add(&flex.Bubble{
+			Hero: &flex.Image{URL: defaultImage(details.Attributes.PosterImage.Medium), Size: "full", AspectRatio: "7:9", AspectMode: "cover"},
+			Body: flex.VBox(
+				&flex.Text{Text: defaultValue(details.Attributes.CanonicalTitle), Weight: "bold", Size: "xl", Wrap: true},
+				info,
+				synopsis,
+			),
+			Footer: footer,
+		})
 	}
 
-	return nil
+	return app.replyFlex(replyToken, tr(ctx, "Manga search: %s", message), carousel)
 }
 
 func (app *TamakoBot) replyText(replyToken, text string) error {
-	if _, err := app.bot.ReplyMessage(
+	if err := app.bot.ReplyMessage(
 		replyToken,
 		linebot.NewTextMessage(text),
-	).Do(); err != nil {
+	); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (app *TamakoBot) handleHeavyContent(messageID string, callback func(*os.File) error) error {
-	content, err := app.bot.GetMessageContent(messageID).Do()
+	content, err := app.bot.GetMessageContent(messageID)
 	if err != nil {
 		return err
 	}
 	defer content.Content.Close()
-	log.Printf("Got file: %s", content.ContentType)
+	slog.Debug("Got content", "type", content.ContentType)
 	originalConent, err := app.saveContent(content.Content)
 	if err != nil {
 		return err
@@ -1669,7 +1210,7 @@ func (app *TamakoBot) handleHeavyContent(messageID string, callback func(*os.Fil
 }
 
 func (app *TamakoBot) saveContent(content io.ReadCloser) (*os.File, error) {
-	file, err := ioutil.TempFile(app.downloadDir, "")
+	file, err := ioutil.TempFile(app.config.DownloadDir, "")
 	if err != nil {
 		return nil, err
 	}
@@ -1679,6 +1220,6 @@ func (app *TamakoBot) saveContent(content io.ReadCloser) (*os.File, error) {
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("Saved %s", file.Name())
+	slog.Debug("Saved content", "file", file.Name())
 	return file, nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// lastFlex returns the alt text and the JSON of the last flex message sent
+func lastFlex(t *testing.T, messenger *RecordingMessenger) (string, string) {
+	t.Helper()
+	replies := messenger.Replies()
+	if len(replies) == 0 {
+		t.Fatal("nothing was sent")
+	}
+	messages := replies[len(replies)-1].Messages
+	message, ok := messages[len(messages)-1].(*linebot.FlexMessage)
+	if !ok {
+		t.Fatalf("last message is a %T, not a flex message", messages[len(messages)-1])
+	}
+	raw, err := json.Marshal(message.Contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message.AltText, string(raw)
+}
+
+func TestGameSearch(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[
+			{"name":"Portal","site_detail_url":"https://www.giantbomb.com/portal/3030-21170/","image":{"small_url":"https://www.giantbomb.com/a/portal.jpg"}},
+			{"name":"Lost Prototype","deck":"No page for this one"}
+		]}`)
+	})
+	app, messenger := newTestBot(t, upstream, func(cfg *Config) {
+		cfg.Providers[providerGiantBomb] = ProviderConfig{Key: "key", Endpoint: cfg.Providers[providerGiantBomb].Endpoint}
+	})
+
+	query := strings.Repeat("portal ", 100)
+	sendText(t, app, messenger, "!games "+query)
+	altText, raw := lastFlex(t, messenger)
+	if n := len([]rune(altText)); n > 400 || !strings.HasPrefix(altText, "Video game search: portal portal") {
+		t.Errorf("alt text %q has %d characters", altText, n)
+	}
+	if !strings.Contains(raw, "Lost Prototype") {
+		t.Errorf("the game without a page is missing: %s", raw)
+	}
+	if n := strings.Count(raw, `"type":"button"`); n != 1 {
+		t.Errorf("got %d buttons, want one for the game with a page: %s", n, raw)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// Messenger is the part of the LINE Messaging API used by the bot
+type Messenger interface {
+	ReplyMessage(replyToken string, messages ...linebot.SendingMessage) error
+	PushMessage(to string, messages ...linebot.SendingMessage) error
+	GetProfile(userID string) (*linebot.UserProfileResponse, error)
+	LeaveGroup(groupID string) error
+	LeaveRoom(roomID string) error
+	GetMessageContent(messageID string) (*linebot.MessageContentResponse, error)
+}
+
+// lineMessenger talks to the real LINE API
+type lineMessenger struct {
+	client *linebot.Client
+}
+
+// NewLineMessenger function
+func NewLineMessenger(client *linebot.Client) Messenger {
+	return &lineMessenger{client: client}
+}
+
+func (m *lineMessenger) ReplyMessage(replyToken string, messages ...linebot.SendingMessage) error {
+	_, err := m.client.ReplyMessage(replyToken, messages...).Do()
+	return err
+}
+
+func (m *lineMessenger) PushMessage(to string, messages ...linebot.SendingMessage) error {
+	_, err := m.client.PushMessage(to, messages...).Do()
+	return err
+}
+
+func (m *lineMessenger) GetProfile(userID string) (*linebot.UserProfileResponse, error) {
+	return m.client.GetProfile(userID).Do()
+}
+
+func (m *lineMessenger) LeaveGroup(groupID string) error {
+	_, err := m.client.LeaveGroup(groupID).Do()
+	return err
+}
+
+func (m *lineMessenger) LeaveRoom(roomID string) error {
+	_, err := m.client.LeaveRoom(roomID).Do()
+	return err
+}
+
+func (m *lineMessenger) GetMessageContent(messageID string) (*linebot.MessageContentResponse, error) {
+	return m.client.GetMessageContent(messageID).Do()
+}
+
+// RecordedReply is a reply or a push captured by RecordingMessenger. To is
+// only set for pushes.
+type RecordedReply struct {
+	ReplyToken string
+	To         string
+	Messages   []linebot.SendingMessage
+}
+
+// RecordingMessenger keeps everything the bot sends in memory so command
+// behavior can be checked without LINE
+type RecordingMessenger struct {
+	mu       sync.Mutex
+	replies  []RecordedReply
+	left     []string
+	profiles map[string]*linebot.UserProfileResponse
+	contents map[string][]byte
+	replyErr error
+}
+
+// NewRecordingMessenger function
+func NewRecordingMessenger() *RecordingMessenger {
+	return &RecordingMessenger{
+		profiles: make(map[string]*linebot.UserProfileResponse),
+		contents: make(map[string][]byte),
+	}
+}
+
+// SetProfile makes GetProfile return a profile with the given display name
+func (m *RecordingMessenger) SetProfile(userID, displayName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles[userID] = &linebot.UserProfileResponse{UserID: userID, DisplayName: displayName}
+}
+
+// SetContent makes GetMessageContent return data for the given message
+func (m *RecordingMessenger) SetContent(messageID string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contents[messageID] = data
+}
+
+// SetReplyError makes ReplyMessage fail with err, e.g. to act like an
+// expired reply token. A nil err makes replies succeed again.
+func (m *RecordingMessenger) SetReplyError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replyErr = err
+}
+
+// Replies returns a copy of the replies and pushes sent so far
+func (m *RecordingMessenger) Replies() []RecordedReply {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]RecordedReply(nil), m.replies...)
+}
+
+// Left returns the IDs of the groups and rooms the bot left
+func (m *RecordingMessenger) Left() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.left...)
+}
+
+// ReplyMessage records the reply
+func (m *RecordingMessenger) ReplyMessage(replyToken string, messages ...linebot.SendingMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.replyErr != nil {
+		return m.replyErr
+	}
+	m.replies = append(m.replies, RecordedReply{ReplyToken: replyToken, Messages: messages})
+	return nil
+}
+
+// PushMessage records the push
+func (m *RecordingMessenger) PushMessage(to string, messages ...linebot.SendingMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies = append(m.replies, RecordedReply{To: to, Messages: messages})
+	return nil
+}
+
+// GetProfile returns a profile set with SetProfile
+func (m *RecordingMessenger) GetProfile(userID string) (*linebot.UserProfileResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profile, ok := m.profiles[userID]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found", userID)
+	}
+	return profile, nil
+}
+
+// LeaveGroup records the group left
+func (m *RecordingMessenger) LeaveGroup(groupID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.left = append(m.left, groupID)
+	return nil
+}
+
+// LeaveRoom records the room left
+func (m *RecordingMessenger) LeaveRoom(roomID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.left = append(m.left, roomID)
+	return nil
+}
+
+// GetMessageContent returns content set with SetContent
+func (m *RecordingMessenger) GetMessageContent(messageID string) (*linebot.MessageContentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.contents[messageID]
+	if !ok {
+		return nil, fmt.Errorf("content %s not found", messageID)
+	}
+	return &linebot.MessageContentResponse{
+		Content:       ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		ContentType:   "application/octet-stream",
+	}, nil
+}
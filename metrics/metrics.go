@@ -0,0 +1,236 @@
+// Package metrics keeps counters and histograms in memory and writes them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 10ms to 30s
+var DefaultBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry holds the metrics of the bot. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry function
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// family is the part shared by counters and histograms, a metric name with
+// one series per combination of label values
+type family struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+}
+
+// key joins label values, \xff can't appear in valid UTF-8
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats `name="value",...`, with extra appended as is
+func (f *family) labelPairs(key string, extra string) string {
+	var pairs []string
+	if len(f.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, f.labels[i]+`="`+escape(value)+`"`)
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (f *family) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, kind)
+	return err
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter is a value that only goes up, per label values
+type Counter struct {
+	family
+	values map[string]float64
+}
+
+// Counter registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: family{name: name, help: help, labels: labels}, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to the series of the given label values
+func (c *Counter) Add(v float64, values ...string) {
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] += v
+}
+
+// Value returns the current value of a series
+func (c *Counter) Value(values ...string) float64 {
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(key, ""), formatFloat(c.values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram counts observations in buckets, per label values
+type Histogram struct {
+	family
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram registers a histogram with the given upper bounds, sorted, and
+// label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		family:  family{name: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records v in the series of the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, `le="`+formatFloat(bound)+`"`), s.counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(key, `le="+Inf"`), s.count,
+			h.name, h.labelPairs(key, ""), formatFloat(s.sum),
+			h.name, h.labelPairs(key, ""), s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes every metric in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
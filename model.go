@@ -1,11 +1,8 @@
 package main
 
 import (
-	"io/ioutil"
-	"log"
 	"math/big"
 	"math/rand"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -41,6 +38,10 @@ type DotaHero struct {
 
 type DotaMatch struct {
 	Match_id     int
+	Player_slot  int
+	Radiant_win  bool
+	Duration     int
+	Start_time   int64
 	Kills        int
 	Deaths       int
 	Assists      int
@@ -49,6 +50,12 @@ type DotaMatch struct {
 	Last_hits    int
 }
 
+// Won reports whether the player was on the winning side, slots from 128
+// are on Dire
+func (m DotaMatch) Won() bool {
+	return (m.Player_slot < 128) == m.Radiant_win
+}
+
 type GameList struct {
 	Results []GameObject
 }
@@ -138,38 +145,102 @@ type ListResult struct {
 	ArtworkUrl100  string
 }
 
-type OsuStd struct {
-	User_id         string
-	Username        string
-	Join_date       string
-	Accuracy        string
-	Pp_rank         string
-	Pp_country_rank string
-	Country         string
-}
-
-type OsuMania struct {
-	User_id         string
-	Join_date       string
-	Accuracy        string
-	Pp_rank         string
-	Pp_country_rank string
-}
-
-type OsuTaiko struct {
-	User_id         string
-	Join_date       string
-	Accuracy        string
-	Pp_rank         string
-	Pp_country_rank string
-}
-
-type OsuCtb struct {
-	User_id         string
-	Join_date       string
-	Accuracy        string
-	Pp_rank         string
-	Pp_country_rank string
+// OsuUser is a user of the osu! API v2. Statistics are those of the
+// ruleset asked for, Rulesets collects them by osuMode key.
+type OsuUser struct {
+	ID          int                      `json:"id"`
+	Username    string                   `json:"username"`
+	AvatarURL   string                   `json:"avatar_url"`
+	CountryCode string                   `json:"country_code"`
+	Country     OsuCountry               `json:"country"`
+	JoinDate    time.Time                `json:"join_date"`
+	Playmode    string                   `json:"playmode"`
+	Statistics  OsuStatistics            `json:"statistics"`
+	Rulesets    map[string]OsuStatistics `json:"-"`
+}
+
+type OsuCountry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// OsuStatistics are the stats of a user in one ruleset, the ranks are 0
+// for inactive or unranked players. PlayTime is in seconds.
+type OsuStatistics struct {
+	GlobalRank   int            `json:"global_rank"`
+	CountryRank  int            `json:"country_rank"`
+	PP           float64        `json:"pp"`
+	PlayCount    int            `json:"play_count"`
+	PlayTime     int            `json:"play_time"`
+	HitAccuracy  float64        `json:"hit_accuracy"`
+	RankedScore  int64          `json:"ranked_score"`
+	MaximumCombo int            `json:"maximum_combo"`
+	Level        OsuLevel       `json:"level"`
+	GradeCounts  OsuGradeCounts `json:"grade_counts"`
+}
+
+// OsuGradeCounts are how many plays got each grade, the silver ones apart
+type OsuGradeCounts struct {
+	SS  int `json:"ss"`
+	SSH int `json:"ssh"`
+	S   int `json:"s"`
+	SH  int `json:"sh"`
+	A   int `json:"a"`
+}
+
+// OsuLevel is the level of a user, Progress is the percentage towards the
+// next one
+type OsuLevel struct {
+	Current  int `json:"current"`
+	Progress int `json:"progress"`
+}
+
+// OsuScore is a play of a user. Accuracy is between 0 and 1, PP is 0 for
+// failed plays and unranked maps.
+type OsuScore struct {
+	ID         int64              `json:"id"`
+	Accuracy   float64            `json:"accuracy"`
+	MaxCombo   int                `json:"max_combo"`
+	Mods       []string           `json:"mods"`
+	Passed     bool               `json:"passed"`
+	PP         float64            `json:"pp"`
+	Rank       string             `json:"rank"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Statistics OsuScoreStatistics `json:"statistics"`
+	Beatmap    OsuBeatmap         `json:"beatmap"`
+	Beatmapset OsuBeatmapset      `json:"beatmapset"`
+}
+
+type OsuScoreStatistics struct {
+	Count300  int `json:"count_300"`
+	Count100  int `json:"count_100"`
+	Count50   int `json:"count_50"`
+	CountMiss int `json:"count_miss"`
+}
+
+// OsuBeatmap is one difficulty of a beatmapset. The beatmapset is only
+// there when the beatmap is looked up on its own.
+type OsuBeatmap struct {
+	ID               int           `json:"id"`
+	Version          string        `json:"version"`
+	DifficultyRating float64       `json:"difficulty_rating"`
+	URL              string        `json:"url"`
+	MaxCombo         int           `json:"max_combo"`
+	Beatmapset       OsuBeatmapset `json:"beatmapset"`
+}
+
+type OsuBeatmapset struct {
+	ID      int       `json:"id"`
+	Artist  string    `json:"artist"`
+	Title   string    `json:"title"`
+	Creator string    `json:"creator"`
+	Covers  OsuCovers `json:"covers"`
+}
+
+type OsuCovers struct {
+	Cover string `json:"cover"`
+	Card  string `json:"card"`
+	List  string `json:"list"`
 }
 
 type Response struct {
@@ -212,60 +283,6 @@ type Lists struct {
 	Example    string
 }
 
-func getData(url string) []byte {
-
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	log.Println(string(body))
-	return body
-}
-
-func Request(url string, contentType string) []byte {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	req.Header.Set("User-Agent", contentType)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatal(err)
-	}
-	//fmt.Println(body)
-	return body
-}
-
-func RequestMashape(url string) []byte {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	req.Header.Set("X-Mashape-Key", "fwx7jEDlMlmshhIVORiiHcodhetxp1Tv9vsjsn0ZMUT0CXNAfp")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatal(err)
-	}
-	//fmt.Println(body)
-	return body
-}
-
 func Rawurlencode(str string) string {
 	return strings.Replace(url.QueryEscape(str), "+", "%20", -1)
 }
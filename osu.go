@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"image/color"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/afifmakarim/go-tamako/provider"
+)
+
+// osuMode is an osu! game mode
+type osuMode struct {
+	// Ruleset names the mode in the osu! API
+	Ruleset string
+	// Key names the mode in the database and in --mode
+	Key   string
+	Name  string
+	Color color.RGBA
+}
+
+var osuModes = []osuMode{
+	{Ruleset: "osu", Key: "standard", Name: "Standard", Color: color.RGBA{0xdc, 0x98, 0xa4, 0xff}},
+	{Ruleset: "taiko", Key: "taiko", Name: "Taiko", Color: color.RGBA{0xe8, 0x6a, 0x3c, 0xff}},
+	{Ruleset: "fruits", Key: "ctb", Name: "Catch the beat", Color: color.RGBA{0x3b, 0xa5, 0x5d, 0xff}},
+	{Ruleset: "mania", Key: "mania", Name: "Mania", Color: color.RGBA{0x6a, 0x5a, 0xcd, 0xff}},
+}
+
+// osuModeAliases are the other names accepted by --mode
+var osuModeAliases = map[string]string{
+	"std":   "standard",
+	"catch": "ctb",
+}
+
+// findOsuMode looks a game mode up by key, alias or ruleset
+func findOsuMode(name string) (osuMode, bool) {
+	name = strings.ToLower(name)
+	if key, ok := osuModeAliases[name]; ok {
+		name = key
+	}
+	for _, mode := range osuModes {
+		if mode.Key == name || mode.Ruleset == name {
+			return mode, true
+		}
+	}
+	return osuMode{}, false
+}
+
+// osuModeKeys returns the keys of the game modes, for usage messages
+func osuModeKeys() []string {
+	keys := make([]string, len(osuModes))
+	for i, mode := range osuModes {
+		keys[i] = mode.Key
+	}
+	return keys
+}
+
+// newOsuToken returns the OAuth token of the bot for the osu! API v2
+func newOsuToken(client *provider.Client, cfg *Config) *provider.ClientCredentials {
+	osu := cfg.Provider(providerOsu)
+	return &provider.ClientCredentials{
+		Client:       client,
+		Provider:     "osu!",
+		TokenURL:     osu.Endpoint + "/oauth/token",
+		ClientID:     osu.ClientID,
+		ClientSecret: osu.Key,
+		Scope:        "public",
+	}
+}
+
+// osuGet calls the osu! API v2 with the token of the bot. A token the API
+// rejects, e.g. revoked before it expired, is fetched again once.
+func (app *TamakoBot) osuGet(ctx context.Context, path string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := app.osuToken.Token(ctx)
+		if err != nil {
+			return err
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+		err = app.http.GetJSON(ctx, "osu!", app.providerURL(providerOsu, "/api/v2"+path), header, v)
+		if perr, ok := provider.AsError(err); ok && perr.Status == http.StatusUnauthorized && attempt == 0 {
+			app.osuToken.Invalidate()
+			continue
+		}
+		return err
+	}
+}
+
+// osuUserByName is how osuUser looks a user up by username rather than ID
+func osuUserByName(username string) string {
+	return "@" + username
+}
+
+// osuUser fetches a user with the statistics of the given game modes, by ID
+// or by a name from osuUserByName. The modes are fetched at once, one that
+// fails is left out of Rulesets as long as another one worked. The user is
+// nil when there is no such user.
+func (app *TamakoBot) osuUser(ctx context.Context, user string, modes ...osuMode) (*OsuUser, error) {
+	users := make([]OsuUser, len(modes))
+	errs := make([]error, len(modes))
+	var wg sync.WaitGroup
+	for i, mode := range modes {
+		wg.Add(1)
+		go func(i int, ruleset string) {
+			defer wg.Done()
+			errs[i] = app.osuGet(ctx, "/users/"+url.PathEscape(user)+"/"+ruleset, &users[i])
+		}(i, mode.Ruleset)
+	}
+	wg.Wait()
+
+	var found *OsuUser
+	notFound := false
+	var firstErr error
+	for i, mode := range modes {
+		if provider.IsKind(errs[i], provider.ErrNotFound) {
+			notFound = true
+			continue
+		}
+		if errs[i] != nil {
+			slog.WarnContext(ctx, "osu! user", "user", user, "mode", mode.Key, "err", errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if found == nil {
+			found = &users[i]
+			found.Rulesets = make(map[string]OsuStatistics)
+		}
+		found.Rulesets[mode.Key] = users[i].Statistics
+	}
+	if found == nil && notFound {
+		return nil, nil
+	}
+	if found == nil {
+		return nil, firstErr
+	}
+	return found, nil
+}
+
+// defaultOsuMode is the mode a player plays: the one of Rulesets with the
+// most plays, else the one set on their profile
+func defaultOsuMode(user *OsuUser) osuMode {
+	best, plays := osuModes[0], 0
+	for _, mode := range osuModes {
+		if count := user.Rulesets[mode.Key].PlayCount; count > plays {
+			best, plays = mode, count
+		}
+	}
+	if plays > 0 {
+		return best
+	}
+	if mode, ok := findOsuMode(user.Playmode); ok {
+		return mode
+	}
+	return best
+}
+
+// osuModeFlag reads the --mode of a command. auto is true when the player's
+// own mode is wanted, without --mode or with a bare one, and ok is false
+// for an unknown mode.
+func osuModeFlag(req *CommandRequest) (mode osuMode, auto, ok bool) {
+	name, set := req.Flags["mode"]
+	if !set || name == "true" {
+		return osuMode{}, true, true
+	}
+	mode, ok = findOsuMode(name)
+	return mode, false, ok
+}
+
+// osuRulesetArg turns a leading mode argument, as in !osu taiko <name>, into
+// --mode unless --mode is given. It reports whether there was one.
+func osuRulesetArg(req *CommandRequest) bool {
+	if _, set := req.Flags["mode"]; set || len(req.Args) == 0 {
+		return false
+	}
+	mode, ok := findOsuMode(req.Args[0])
+	if !ok {
+		return false
+	}
+	flags := map[string]string{"mode": mode.Key}
+	for name, value := range req.Flags {
+		flags[name] = value
+	}
+	req.Flags = flags
+	req.Args = req.Args[1:]
+	req.Text = strings.Join(req.Args, " ")
+	return true
+}
+
+// osuUserInMode fetches a user in the mode asked for with --mode, or in the
+// mode they play the most
+func (app *TamakoBot) osuUserInMode(ctx context.Context, req *CommandRequest, name string) (*OsuUser, osuMode, error) {
+	mode, auto, _ := osuModeFlag(req)
+	if !auto {
+		user, err := app.osuUser(ctx, osuUserByName(name), mode)
+		return user, mode, err
+	}
+	user, err := app.osuUser(ctx, osuUserByName(name), osuModes...)
+	if err != nil || user == nil {
+		return user, mode, err
+	}
+	return user, defaultOsuMode(user), nil
+}
@@ -0,0 +1,305 @@
+// Package osu reads osu! beatmaps and replays, and computes the star rating
+// and the performance points of osu!standard plays without calling the
+// osu! API.
+// The difficulty follows the 2019 version of the ranking algorithm and
+// sliders count by their head only, so the values are close to the ones of
+// the website but not always the same.
+package osu
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game modes of a beatmap
+const (
+	ModeStandard = 0
+	ModeTaiko    = 1
+	ModeCatch    = 2
+	ModeMania    = 3
+)
+
+// Bits of the type of a hit object
+const (
+	TypeCircle  = 1 << 0
+	TypeSlider  = 1 << 1
+	TypeSpinner = 1 << 3
+)
+
+// ErrNoHitObjects is returned by Parse for a file without hit objects, which
+// is also what the website serves for a beatmap that doesn't exist
+var ErrNoHitObjects = errors.New("osu: no hit objects")
+
+// Beatmap is one difficulty of a beatmapset as read from its .osu file
+type Beatmap struct {
+	FormatVersion int
+	Mode          int
+
+	Title        string
+	Artist       string
+	Creator      string
+	Version      string
+	BeatmapID    int
+	BeatmapSetID int
+
+	HP, CS, OD, AR   float64
+	SliderMultiplier float64
+	SliderTickRate   float64
+
+	TimingPoints []TimingPoint
+	HitObjects   []HitObject
+}
+
+// TimingPoint changes the tempo, or the slider velocity when it is
+// inherited. BeatLength is in ms, or -100 divided by the velocity multiplier
+// of an inherited point.
+type TimingPoint struct {
+	Time        float64
+	BeatLength  float64
+	Uninherited bool
+}
+
+// HitObject is a circle, slider or spinner. Time is in ms, Slides and
+// Length are those of a slider.
+type HitObject struct {
+	X, Y   float64
+	Time   float64
+	Type   int
+	Slides int
+	Length float64
+}
+
+// Parse reads a .osu file
+func Parse(r io.Reader) (*Beatmap, error) {
+	b := &Beatmap{CS: 5, OD: 5, HP: 5, AR: -1, SliderMultiplier: 1.4, SliderTickRate: 1}
+	scanner := bufio.NewScanner(r)
+	// the points of long sliders make long lines
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	section := ""
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+			if v := strings.TrimPrefix(line, "osu file format v"); v != line {
+				b.FormatVersion, _ = strconv.Atoi(v)
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		var err error
+		switch section {
+		case "General", "Metadata", "Difficulty":
+			err = b.parseSetting(line)
+		case "TimingPoints":
+			err = b.parseTimingPoint(line)
+		case "HitObjects":
+			err = b.parseHitObject(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("osu: line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.HitObjects) == 0 {
+		return nil, ErrNoHitObjects
+	}
+	// old beatmaps have a single setting for both
+	if b.AR < 0 {
+		b.AR = b.OD
+	}
+	return b, nil
+}
+
+func (b *Beatmap) parseSetting(line string) error {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return nil
+	}
+	key, value := strings.TrimSpace(line[:colon]), strings.TrimSpace(line[colon+1:])
+	var err error
+	switch key {
+	case "Mode":
+		b.Mode, err = strconv.Atoi(value)
+		if b.Mode < ModeStandard || b.Mode > ModeMania {
+			err = errors.New("unknown mode")
+		}
+	case "Title":
+		b.Title = value
+	case "Artist":
+		b.Artist = value
+	case "Creator":
+		b.Creator = value
+	case "Version":
+		b.Version = value
+	case "BeatmapID":
+		b.BeatmapID, err = strconv.Atoi(value)
+	case "BeatmapSetID":
+		b.BeatmapSetID, err = strconv.Atoi(value)
+	case "HPDrainRate":
+		b.HP, err = strconv.ParseFloat(value, 64)
+	case "CircleSize":
+		b.CS, err = strconv.ParseFloat(value, 64)
+	case "OverallDifficulty":
+		b.OD, err = strconv.ParseFloat(value, 64)
+	case "ApproachRate":
+		b.AR, err = strconv.ParseFloat(value, 64)
+	case "SliderMultiplier":
+		b.SliderMultiplier, err = strconv.ParseFloat(value, 64)
+	case "SliderTickRate":
+		b.SliderTickRate, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("bad %s %q", key, value)
+	}
+	return nil
+}
+
+func (b *Beatmap) parseTimingPoint(line string) error {
+	fields := strings.Split(line, ",")
+	if len(fields) < 2 {
+		return fmt.Errorf("bad timing point %q", line)
+	}
+	t, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return fmt.Errorf("bad timing point %q", line)
+	}
+	beatLength, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return fmt.Errorf("bad timing point %q", line)
+	}
+	// before the uninherited field, only a positive beat length sets the tempo
+	uninherited := beatLength > 0
+	if len(fields) > 6 {
+		uninherited = strings.TrimSpace(fields[6]) == "1"
+	}
+	b.TimingPoints = append(b.TimingPoints, TimingPoint{Time: t, BeatLength: beatLength, Uninherited: uninherited})
+	return nil
+}
+
+func (b *Beatmap) parseHitObject(line string) error {
+	fields := strings.Split(line, ",")
+	if len(fields) < 4 {
+		return fmt.Errorf("bad hit object %q", line)
+	}
+	var values [4]float64
+	for i := range values {
+		v, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil {
+			return fmt.Errorf("bad hit object %q", line)
+		}
+		values[i] = v
+	}
+	obj := HitObject{X: values[0], Y: values[1], Time: values[2], Type: int(values[3])}
+	if obj.Type&TypeSlider != 0 {
+		if len(fields) < 8 {
+			return fmt.Errorf("bad slider %q", line)
+		}
+		slides, err := strconv.Atoi(strings.TrimSpace(fields[6]))
+		if err != nil || slides < 1 {
+			return fmt.Errorf("bad slider %q", line)
+		}
+		length, err := strconv.ParseFloat(strings.TrimSpace(fields[7]), 64)
+		if err != nil {
+			return fmt.Errorf("bad slider %q", line)
+		}
+		obj.Slides, obj.Length = slides, length
+	}
+	b.HitObjects = append(b.HitObjects, obj)
+	return nil
+}
+
+// Counts returns the number of circles, sliders and spinners
+func (b *Beatmap) Counts() (circles, sliders, spinners int) {
+	for _, obj := range b.HitObjects {
+		switch {
+		case obj.Type&TypeCircle != 0:
+			circles++
+		case obj.Type&TypeSlider != 0:
+			sliders++
+		case obj.Type&TypeSpinner != 0:
+			spinners++
+		}
+	}
+	return circles, sliders, spinners
+}
+
+// velocityAt is the slider velocity multiplier of the inherited timing
+// point in effect at t
+func (b *Beatmap) velocityAt(t float64) float64 {
+	velocity := 1.0
+	for _, tp := range b.TimingPoints {
+		if tp.Time > t {
+			break
+		}
+		velocity = 1
+		if !tp.Uninherited && tp.BeatLength < 0 {
+			velocity = -100 / tp.BeatLength
+		}
+	}
+	return velocity
+}
+
+// MaxCombo is the combo of a full combo: one per circle and spinner, and
+// the head, ticks, repeats and tail of every slider
+func (b *Beatmap) MaxCombo() int {
+	combo := 0
+	for _, obj := range b.HitObjects {
+		if obj.Type&TypeSlider == 0 {
+			combo++
+			continue
+		}
+		velocity := 1.0
+		if b.FormatVersion >= 8 {
+			velocity = b.velocityAt(obj.Time)
+		}
+		pxPerBeat := b.SliderMultiplier * 100 * velocity
+		beats := obj.Length * float64(obj.Slides) / pxPerBeat
+		ticks := int(math.Ceil((beats-0.1)/float64(obj.Slides)*b.SliderTickRate)) - 1
+		ticks = ticks*obj.Slides + obj.Slides + 1
+		if ticks > 0 {
+			combo += ticks
+		}
+	}
+	return combo
+}
+
+// BPM is the tempo that lasts the longest
+func (b *Beatmap) BPM() float64 {
+	end := b.HitObjects[len(b.HitObjects)-1].Time
+	longest, bpm := -1.0, 0.0
+	for i, tp := range b.TimingPoints {
+		if !tp.Uninherited || tp.BeatLength <= 0 {
+			continue
+		}
+		until := end
+		for _, next := range b.TimingPoints[i+1:] {
+			if next.Uninherited {
+				until = next.Time
+				break
+			}
+		}
+		if d := until - tp.Time; d > longest {
+			longest, bpm = d, 60000/tp.BeatLength
+		}
+	}
+	return bpm
+}
+
+// Length is the time from the start of the song to the last hit object
+func (b *Beatmap) Length() time.Duration {
+	return time.Duration(b.HitObjects[len(b.HitObjects)-1].Time) * time.Millisecond
+}
@@ -0,0 +1,196 @@
+package osu
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrNotStandard is returned by Calculate for the beatmaps of the other
+// game modes
+var ErrNotStandard = errors.New("osu: not an osu!standard beatmap")
+
+const (
+	playfieldWidth  = 512.0
+	playfieldHeight = 384.0
+
+	// circles smaller than this radius get a bonus
+	circleSizeBuffThreshold = 30.0
+	starScalingFactor       = 0.0675
+	extremeScalingFactor    = 0.5
+	// strainStep is the length of the sections whose peak strains are summed
+	strainStep  = 400.0
+	decayWeight = 0.9
+
+	singleSpacing         = 125.0
+	minSpeedBonus         = 75.0 // 200 bpm 1/4
+	maxSpeedBonus         = 45.0 // 330 bpm 1/4
+	angleBonusScale       = 90.0
+	aimTimingThreshold    = 107.0
+	speedAngleBonusBegin  = 5 * math.Pi / 6
+	aimAngleBonusBegin    = math.Pi / 3
+	minDeltaTime          = 50.0
+	speedBonusDenominator = 40.0
+)
+
+// the two skills: tapping fast and moving the cursor
+const (
+	skillSpeed = iota
+	skillAim
+)
+
+var (
+	decayBase     = [2]float64{0.3, 0.15}
+	weightScaling = [2]float64{1400, 26.25}
+)
+
+// Difficulty is the difficulty of a beatmap with some mods
+type Difficulty struct {
+	Stars float64
+	Aim   float64
+	Speed float64
+	Mods  Mods
+	// Stats are the settings of the beatmap with the mods
+	Stats    Stats
+	MaxCombo int
+
+	Circles, Sliders, Spinners int
+}
+
+// Objects is the number of hit objects
+func (d *Difficulty) Objects() int {
+	return d.Circles + d.Sliders + d.Spinners
+}
+
+// diffObject is a hit object as the strain sees it: its time with the
+// speed of the mods and its position scaled by the circle size
+type diffObject struct {
+	time     float64
+	x, y     float64
+	typ      int
+	strains  [2]float64
+	delta    float64
+	distance float64
+	angle    float64
+}
+
+// Calculate computes the star rating of an osu!standard beatmap with mods
+func Calculate(b *Beatmap, mods Mods) (*Difficulty, error) {
+	if b.Mode != ModeStandard {
+		return nil, ErrNotStandard
+	}
+	d := &Difficulty{Mods: mods, Stats: mods.Apply(b.Stats()), MaxCombo: b.MaxCombo()}
+	d.Circles, d.Sliders, d.Spinners = b.Counts()
+
+	radius := (playfieldWidth / 16) * (1 - 0.7*(d.Stats.CS-5)/5)
+	scale := 52 / radius
+	if radius < circleSizeBuffThreshold {
+		scale *= 1 + min(circleSizeBuffThreshold-radius, 5)/50
+	}
+	speed := mods.SpeedMultiplier()
+	objects := make([]diffObject, len(b.HitObjects))
+	for i, h := range b.HitObjects {
+		x, y := h.X, h.Y
+		// spinners are spun around the center
+		if h.Type&TypeSpinner != 0 {
+			x, y = playfieldWidth/2, playfieldHeight/2
+		}
+		o := &objects[i]
+		*o = diffObject{time: h.Time / speed, x: x * scale, y: y * scale, typ: h.Type, strains: [2]float64{1, 1}, angle: math.NaN()}
+		if i == 0 {
+			continue
+		}
+		prev := &objects[i-1]
+		o.delta = o.time - prev.time
+		o.distance = math.Hypot(o.x-prev.x, o.y-prev.y)
+		if i >= 2 {
+			prev2 := &objects[i-2]
+			v1x, v1y := prev2.x-prev.x, prev2.y-prev.y
+			v2x, v2y := o.x-prev.x, o.y-prev.y
+			o.angle = math.Abs(math.Atan2(v1x*v2y-v1y*v2x, v1x*v2x+v1y*v2y))
+		}
+	}
+
+	d.Aim = math.Sqrt(skillValue(objects, skillAim)) * starScalingFactor
+	d.Speed = math.Sqrt(skillValue(objects, skillSpeed)) * starScalingFactor
+	if mods&TouchDevice != 0 {
+		d.Aim = math.Pow(d.Aim, 0.8)
+	}
+	d.Stars = d.Aim + d.Speed + math.Abs(d.Speed-d.Aim)*extremeScalingFactor
+	return d, nil
+}
+
+// skillValue sums the peak strains of the sections of the beatmap, the
+// hardest first and each weighing less than the one before
+func skillValue(objects []diffObject, skill int) float64 {
+	var peaks []float64
+	intervalEnd := math.Ceil(objects[0].time/strainStep) * strainStep
+	peak := 0.0
+	for i := range objects {
+		cur := &objects[i]
+		if i > 0 {
+			prev := &objects[i-1]
+			value := 0.0
+			if cur.typ&(TypeCircle|TypeSlider) != 0 {
+				value = spacingWeight(skill, cur, prev) * weightScaling[skill]
+			}
+			cur.strains[skill] = prev.strains[skill]*math.Pow(decayBase[skill], cur.delta/1000) + value
+		}
+		for cur.time > intervalEnd {
+			peaks = append(peaks, peak)
+			if i > 0 {
+				prev := &objects[i-1]
+				peak = prev.strains[skill] * math.Pow(decayBase[skill], (intervalEnd-prev.time)/1000)
+			}
+			intervalEnd += strainStep
+		}
+		peak = max(peak, cur.strains[skill])
+	}
+	peaks = append(peaks, peak)
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(peaks)))
+	total, weight := 0.0, 1.0
+	for _, p := range peaks {
+		total += p * weight
+		weight *= decayWeight
+	}
+	return total
+}
+
+// spacingWeight is how hard it is to hit cur after prev
+func spacingWeight(skill int, cur, prev *diffObject) float64 {
+	distance, delta, angle := cur.distance, cur.delta, cur.angle
+	if skill == skillSpeed {
+		distance = min(distance, singleSpacing)
+		delta = max(delta, maxSpeedBonus)
+		speedBonus := 1.0
+		if delta < minSpeedBonus {
+			speedBonus += math.Pow((minSpeedBonus-delta)/speedBonusDenominator, 2)
+		}
+		angleBonus := 1.0
+		if !math.IsNaN(angle) && angle < speedAngleBonusBegin {
+			s := math.Sin(1.5 * (speedAngleBonusBegin - angle))
+			angleBonus += s * s / 3.57
+			if angle < math.Pi/2 {
+				angleBonus = 1.28
+				if distance < angleBonusScale && angle < math.Pi/4 {
+					angleBonus += (1 - angleBonus) * min((angleBonusScale-distance)/10, 1)
+				} else if distance < angleBonusScale {
+					angleBonus += (1 - angleBonus) * min((angleBonusScale-distance)/10, 1) * math.Sin((math.Pi/2-angle)*4/math.Pi)
+				}
+			}
+		}
+		return (1 + (speedBonus-1)*0.75) * angleBonus * (0.95 + speedBonus*math.Pow(distance/singleSpacing, 3.5)) / delta
+	}
+
+	delta = max(delta, minDeltaTime)
+	prevDelta := max(prev.delta, minDeltaTime)
+	result := 0.0
+	if !math.IsNaN(angle) && angle > aimAngleBonusBegin {
+		s := math.Sin(angle - aimAngleBonusBegin)
+		angleBonus := math.Sqrt(max(prev.distance-angleBonusScale, 0) * s * s * max(distance-angleBonusScale, 0))
+		result = 1.5 * math.Pow(max(0, angleBonus), 0.99) / max(aimTimingThreshold, prevDelta)
+	}
+	weighted := math.Pow(distance, 0.99)
+	return max(result+weighted/max(aimTimingThreshold, delta), weighted/delta)
+}
@@ -0,0 +1,170 @@
+package osu
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testBeatmap writes a two minute beatmap of jumps of distance px at bpm,
+// with hits every 1/divisor of a beat and a slider every 8 objects
+func testBeatmap(t *testing.T, mode int, bpm, divisor, distance float64) *Beatmap {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "osu file format v14\n\n[General]\nMode: %d\n\n[Metadata]\nTitle:Title\nArtist:Artist\nCreator:Creator\nVersion:Version\nBeatmapID:1\nBeatmapSetID:2\n\n", mode)
+	fmt.Fprintf(&b, "[Difficulty]\nHPDrainRate:5\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\nSliderMultiplier:1.8\nSliderTickRate:1\n\n")
+	fmt.Fprintf(&b, "[TimingPoints]\n0,%v,4,2,0,60,1,0\n\n[HitObjects]\n", 60000/bpm)
+	step := 60000 / bpm / divisor
+	for i := 0; i < int(2*60000/step); i++ {
+		x := 256 + distance/2
+		if i%2 == 1 {
+			x = 256 - distance/2
+		}
+		y := 192 + (i%3)*20
+		if i%8 == 7 {
+			fmt.Fprintf(&b, "%d,%d,%d,2,0,B|%d:%d,1,100\n", int(x), y, int(float64(i)*step), int(x)+100, y)
+		} else {
+			fmt.Fprintf(&b, "%d,%d,%d,1,0,0:0:0:0:\n", int(x), y, int(float64(i)*step))
+		}
+	}
+	beatmap, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return beatmap
+}
+
+func calculate(t *testing.T, b *Beatmap, mods string) *Difficulty {
+	t.Helper()
+	m, err := ParseMods(mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Calculate(b, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestCalculateNotStandard(t *testing.T) {
+	for mode := ModeTaiko; mode <= ModeMania; mode++ {
+		if _, err := Calculate(testBeatmap(t, mode, 180, 2, 200), 0); err != ErrNotStandard {
+			t.Errorf("mode %d: got %v, want ErrNotStandard", mode, err)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	jumps := testBeatmap(t, ModeStandard, 180, 2, 200)
+	nomod := calculate(t, jumps, "")
+	if nomod.Stars < 2 || nomod.Stars > 10 {
+		t.Errorf("%.2f stars for 180 bpm jumps", nomod.Stars)
+	}
+	if nomod.Objects() != len(jumps.HitObjects) || nomod.Sliders == 0 || nomod.MaxCombo < nomod.Objects() {
+		t.Errorf("got %d objects, %d sliders and a max combo of %d for %d hit objects", nomod.Objects(), nomod.Sliders, nomod.MaxCombo, len(jumps.HitObjects))
+	}
+
+	easy := calculate(t, testBeatmap(t, ModeStandard, 120, 1, 100), "")
+	stream := calculate(t, testBeatmap(t, ModeStandard, 180, 4, 40), "")
+	if easy.Stars >= nomod.Stars {
+		t.Errorf("slow jumps are %.2f stars, more than the %.2f of fast ones", easy.Stars, nomod.Stars)
+	}
+	if stream.Speed <= stream.Aim {
+		t.Errorf("a stream is more aim (%.2f) than speed (%.2f)", stream.Aim, stream.Speed)
+	}
+
+	dt := calculate(t, jumps, "DT")
+	if dt.Stars <= nomod.Stars || dt.Stats.AR <= 10 || dt.Stats.OD <= nomod.Stats.OD {
+		t.Errorf("DT: %.2f stars AR %.2f OD %.2f, NM: %.2f stars AR %.2f OD %.2f", dt.Stars, dt.Stats.AR, dt.Stats.OD, nomod.Stars, nomod.Stats.AR, nomod.Stats.OD)
+	}
+	ht := calculate(t, jumps, "HT")
+	if ht.Stars >= nomod.Stars {
+		t.Errorf("HT is %.2f stars, NM %.2f", ht.Stars, nomod.Stars)
+	}
+	hr := calculate(t, jumps, "HR")
+	if want := (Stats{CS: 5.2, AR: 10, OD: 10, HP: 7}); !closeStats(hr.Stats, want) {
+		t.Errorf("HR stats are %+v, want %+v", hr.Stats, want)
+	}
+	ez := calculate(t, jumps, "EZ")
+	if want := (Stats{CS: 2, AR: 4.5, OD: 4, HP: 2.5}); !closeStats(ez.Stats, want) {
+		t.Errorf("EZ stats are %+v, want %+v", ez.Stats, want)
+	}
+	if hd := calculate(t, jumps, "HD"); hd.Stars != nomod.Stars {
+		t.Errorf("HD changes the stars from %.2f to %.2f", nomod.Stars, hd.Stars)
+	}
+}
+
+func closeStats(a, b Stats) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return near(a.CS, b.CS) && near(a.AR, b.AR) && near(a.OD, b.OD) && near(a.HP, b.HP)
+}
+
+func TestScoreFor(t *testing.T) {
+	d := calculate(t, testBeatmap(t, ModeStandard, 180, 2, 200), "")
+	objects := d.Objects()
+	tests := []struct {
+		accuracy float64
+		misses   int
+	}{
+		{100, 0},
+		{99, 0},
+		{95, 0},
+		{90, 0},
+		{70, 0},
+		{98, 5},
+		{100, 5},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		s := d.ScoreFor(tt.accuracy, tt.misses)
+		if s.N300+s.N100+s.N50+s.Misses != objects || s.Misses != tt.misses {
+			t.Errorf("ScoreFor(%v, %d) = %+v, want %d objects", tt.accuracy, tt.misses, s, objects)
+		}
+		if s.N300 < 0 || s.N100 < 0 || s.N50 < 0 {
+			t.Errorf("ScoreFor(%v, %d) = %+v", tt.accuracy, tt.misses, s)
+		}
+		best := Score{N300: objects - tt.misses, Misses: tt.misses}.Accuracy() * 100
+		if want := min(tt.accuracy, best); tt.accuracy > 0 && math.Abs(s.Accuracy()*100-want) > 0.1 {
+			t.Errorf("ScoreFor(%v, %d) is %.2f%% accurate", tt.accuracy, tt.misses, s.Accuracy()*100)
+		}
+	}
+	if s := d.ScoreFor(100, objects+1); s.Misses != objects || s.N300 != 0 {
+		t.Errorf("more misses than objects: %+v", s)
+	}
+}
+
+func TestPP(t *testing.T) {
+	d := calculate(t, testBeatmap(t, ModeStandard, 180, 2, 200), "")
+	previous := 0.0
+	for _, accuracy := range []float64{90, 95, 98, 99, 100} {
+		pp := d.PP(d.ScoreFor(accuracy, 0))
+		if pp.Total <= previous {
+			t.Errorf("%v%% is worth %.1fpp, less than a lower accuracy (%.1fpp)", accuracy, pp.Total, previous)
+		}
+		if pp.Aim <= 0 || pp.Speed <= 0 || pp.Accuracy <= 0 || pp.Total < pp.Aim {
+			t.Errorf("%v%%: %+v", accuracy, pp)
+		}
+		previous = pp.Total
+	}
+
+	fc := d.PP(d.ScoreFor(99, 0))
+	if missed := d.PP(d.ScoreFor(99, 3)); missed.Total >= fc.Total {
+		t.Errorf("3 misses are worth %.1fpp, a full combo %.1fpp", missed.Total, fc.Total)
+	}
+	broken := d.ScoreFor(99, 0)
+	broken.Combo = d.MaxCombo / 2
+	if pp := d.PP(broken); pp.Total >= fc.Total {
+		t.Errorf("half the combo is worth %.1fpp, a full combo %.1fpp", pp.Total, fc.Total)
+	}
+
+	hddt := calculate(t, testBeatmap(t, ModeStandard, 180, 2, 200), "HDDT")
+	if pp := hddt.PP(hddt.ScoreFor(99, 0)); pp.Total <= fc.Total {
+		t.Errorf("HDDT is worth %.1fpp, NM %.1fpp", pp.Total, fc.Total)
+	}
+	nf := calculate(t, testBeatmap(t, ModeStandard, 180, 2, 200), "NF")
+	if pp := nf.PP(nf.ScoreFor(99, 0)); pp.Total >= fc.Total {
+		t.Errorf("NF is worth %.1fpp, NM %.1fpp", pp.Total, fc.Total)
+	}
+}
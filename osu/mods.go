@@ -0,0 +1,164 @@
+package osu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mods are the game modifiers of a play, as the bits the game uses
+type Mods int
+
+const (
+	NoFail Mods = 1 << iota
+	Easy
+	TouchDevice
+	Hidden
+	HardRock
+	SuddenDeath
+	DoubleTime
+	Relax
+	HalfTime
+	// Nightcore is always set with DoubleTime
+	Nightcore
+	Flashlight
+	Autoplay
+	SpunOut
+	Autopilot
+	// Perfect is always set with SuddenDeath
+	Perfect
+)
+
+// modNames are the acronyms of the mods, in the order the game shows them
+var modNames = []struct {
+	mod  Mods
+	name string
+}{
+	{NoFail, "NF"},
+	{Easy, "EZ"},
+	{TouchDevice, "TD"},
+	{Hidden, "HD"},
+	{HardRock, "HR"},
+	{SuddenDeath, "SD"},
+	{DoubleTime, "DT"},
+	{Relax, "RX"},
+	{HalfTime, "HT"},
+	{Nightcore, "NC"},
+	{Flashlight, "FL"},
+	{Autoplay, "AT"},
+	{SpunOut, "SO"},
+	{Autopilot, "AP"},
+	{Perfect, "PF"},
+}
+
+// ParseMods reads mods written like the game shows them, e.g. +HDDT
+func ParseMods(s string) (Mods, error) {
+	s = strings.ToUpper(strings.TrimPrefix(s, "+"))
+	if len(s)%2 != 0 {
+		return 0, fmt.Errorf("osu: bad mods %q", s)
+	}
+	var mods Mods
+next:
+	for i := 0; i < len(s); i += 2 {
+		for _, m := range modNames {
+			if s[i:i+2] == m.name {
+				mods |= m.mod
+				continue next
+			}
+		}
+		return 0, fmt.Errorf("osu: unknown mod %q", s[i:i+2])
+	}
+	if mods&Nightcore != 0 {
+		mods |= DoubleTime
+	}
+	if mods&Perfect != 0 {
+		mods |= SuddenDeath
+	}
+	return mods, nil
+}
+
+// String returns the acronyms of the mods, e.g. HDDT, or NM for no mod
+func (m Mods) String() string {
+	if m == 0 {
+		return "NM"
+	}
+	var b strings.Builder
+	for _, mod := range modNames {
+		if m&mod.mod == 0 {
+			continue
+		}
+		// the game shows NC and PF alone
+		if (mod.mod == DoubleTime && m&Nightcore != 0) || (mod.mod == SuddenDeath && m&Perfect != 0) {
+			continue
+		}
+		b.WriteString(mod.name)
+	}
+	return b.String()
+}
+
+// SpeedMultiplier is how much faster the song plays
+func (m Mods) SpeedMultiplier() float64 {
+	switch {
+	case m&DoubleTime != 0:
+		return 1.5
+	case m&HalfTime != 0:
+		return 0.75
+	}
+	return 1
+}
+
+// Stats are the difficulty settings of a beatmap
+type Stats struct {
+	CS, AR, OD, HP float64
+}
+
+// timing windows of the approach rate and of the 300s of the overall
+// difficulty, in ms
+const (
+	ar0Ms     = 1800.0
+	ar5Ms     = 1200.0
+	ar10Ms    = 450.0
+	arMsStep1 = (ar0Ms - ar5Ms) / 5
+	arMsStep2 = (ar5Ms - ar10Ms) / 5
+	od0Ms     = 80.0
+	od10Ms    = 20.0
+	odMsStep  = (od0Ms - od10Ms) / 10
+)
+
+// Stats returns the settings of the beatmap
+func (b *Beatmap) Stats() Stats {
+	return Stats{CS: b.CS, AR: b.AR, OD: b.OD, HP: b.HP}
+}
+
+// Apply returns the settings as they play with the mods, the speed of DT
+// and HT shows in AR and OD
+func (m Mods) Apply(s Stats) Stats {
+	multiplier := 1.0
+	switch {
+	case m&HardRock != 0:
+		multiplier = 1.4
+		s.CS *= 1.3
+	case m&Easy != 0:
+		multiplier = 0.5
+		s.CS *= 0.5
+	}
+	s.CS = min(s.CS, 10)
+	s.HP = min(s.HP*multiplier, 10)
+	speed := m.SpeedMultiplier()
+
+	ar := min(s.AR*multiplier, 10)
+	arMs := ar5Ms - arMsStep2*(ar-5)
+	if ar < 5 {
+		arMs = ar0Ms - arMsStep1*ar
+	}
+	arMs = max(ar10Ms, min(ar0Ms, arMs)) / speed
+	if arMs > ar5Ms {
+		s.AR = (ar0Ms - arMs) / arMsStep1
+	} else {
+		s.AR = 5 + (ar5Ms-arMs)/arMsStep2
+	}
+
+	od := min(s.OD*multiplier, 10)
+	odMs := max(od10Ms, min(od0Ms, od0Ms-odMsStep*od)) / speed
+	s.OD = (od0Ms - odMs) / odMsStep
+	return s
+}
@@ -0,0 +1,135 @@
+package osu
+
+import "math"
+
+// Score is the result of a play, a Combo of 0 is a full combo
+type Score struct {
+	N300, N100, N50, Misses int
+	Combo                   int
+}
+
+// Accuracy is the accuracy of the score between 0 and 1
+func (s Score) Accuracy() float64 {
+	hits := s.N300 + s.N100 + s.N50 + s.Misses
+	if hits == 0 {
+		return 0
+	}
+	return float64(s.N300*300+s.N100*100+s.N50*50) / float64(hits*300)
+}
+
+// ScoreFor spreads the hits of a play with an accuracy in percent and
+// misses on the beatmap of d, as 100s and then 50s when 100s aren't enough
+func (d *Difficulty) ScoreFor(accuracy float64, misses int) Score {
+	objects := d.Objects()
+	misses = min(misses, objects)
+	max300 := objects - misses
+	best := Score{N300: max300, Misses: misses}.Accuracy() * 100
+	accuracy = max(0, min(best, accuracy))
+
+	s := Score{Misses: misses}
+	s.N100 = int(math.Round(-3 * ((accuracy*0.01-1)*float64(objects) + float64(misses)) * 0.5))
+	if s.N100 > max300 {
+		s.N100 = 0
+		s.N50 = min(max300, int(math.Round(-6*((accuracy*0.01-1)*float64(objects)+float64(misses))*0.2)))
+	}
+	s.N300 = objects - s.N100 - s.N50 - misses
+	return s
+}
+
+// Performance is the pp of a play and what it is made of
+type Performance struct {
+	Total    float64
+	Aim      float64
+	Speed    float64
+	Accuracy float64
+}
+
+// basePP turns the star rating of a skill into pp
+func basePP(stars float64) float64 {
+	return math.Pow(5*max(1, stars/starScalingFactor)-4, 3) / 100000
+}
+
+// PP computes the performance points of a score on the beatmap of d
+func (d *Difficulty) PP(s Score) Performance {
+	objects := float64(d.Objects())
+	combo := s.Combo
+	if combo <= 0 || combo > d.MaxCombo {
+		combo = d.MaxCombo
+	}
+	misses := float64(s.Misses)
+	ar, od := d.Stats.AR, d.Stats.OD
+	accuracy := s.Accuracy()
+	mods := d.Mods
+
+	lengthBonus := 0.95 + 0.4*min(1, objects/2000)
+	if objects > 2000 {
+		lengthBonus += math.Log10(objects/2000) * 0.5
+	}
+	comboBreak := 1.0
+	if d.MaxCombo > 0 {
+		comboBreak = math.Pow(float64(combo), 0.8) / math.Pow(float64(d.MaxCombo), 0.8)
+	}
+	arBonus := 1.0
+	if ar > 10.33 {
+		arBonus += 0.4 * (ar - 10.33)
+	} else if ar < 8 {
+		arBonus += 0.01 * (8 - ar)
+	}
+	hdBonus := 1.0
+	if mods&Hidden != 0 {
+		hdBonus += 0.04 * (12 - ar)
+	}
+
+	var p Performance
+	p.Aim = basePP(d.Aim) * lengthBonus * comboBreak * arBonus * hdBonus
+	if misses > 0 {
+		p.Aim *= 0.97 * math.Pow(1-math.Pow(misses/objects, 0.775), misses)
+	}
+	if mods&Flashlight != 0 {
+		flBonus := 1 + 0.35*min(1, objects/200)
+		if objects > 200 {
+			flBonus += 0.3 * min(1, (objects-200)/300)
+		}
+		if objects > 500 {
+			flBonus += (objects - 500) / 1200
+		}
+		p.Aim *= flBonus
+	}
+	p.Aim *= (0.5 + accuracy/2) * (0.98 + od*od/2500)
+
+	p.Speed = basePP(d.Speed) * lengthBonus * comboBreak * hdBonus
+	if misses > 0 {
+		p.Speed *= 0.97 * math.Pow(1-math.Pow(misses/objects, 0.775), math.Pow(misses, 0.875))
+	}
+	if ar > 10.33 {
+		p.Speed *= arBonus
+	}
+	p.Speed *= (0.95 + od*od/750) * math.Pow(accuracy, (14.5-max(od, 8))/2)
+	if n50 := float64(s.N50); n50 >= objects/500 {
+		p.Speed *= math.Pow(0.98, n50-objects/500)
+	}
+
+	// only circles count for the accuracy, sliders are too lenient
+	circleAccuracy := 0.0
+	if d.Circles > 0 {
+		n300 := s.N300 - (d.Sliders + d.Spinners)
+		circleAccuracy = max(0, float64(n300*300+s.N100*100+s.N50*50)/float64(d.Circles*300))
+	}
+	p.Accuracy = math.Pow(1.52163, od) * math.Pow(circleAccuracy, 24) * 2.83 * min(1.15, math.Pow(float64(d.Circles)/1000, 0.3))
+	if mods&Hidden != 0 {
+		p.Accuracy *= 1.08
+	}
+	if mods&Flashlight != 0 {
+		p.Accuracy *= 1.02
+	}
+
+	multiplier := 1.12
+	if mods&NoFail != 0 {
+		multiplier *= 0.9
+	}
+	if mods&SpunOut != 0 {
+		multiplier *= 0.95
+	}
+	p.Total = math.Pow(math.Pow(p.Aim, 1.1)+math.Pow(p.Speed, 1.1)+math.Pow(p.Accuracy, 1.1), 1/1.1) * multiplier
+	return p
+}
@@ -0,0 +1,163 @@
+package osu
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ticksAtUnixEpoch are the .NET ticks, 100ns since year 1, of 1970-01-01
+const ticksAtUnixEpoch = 621355968000000000
+
+// maxReplayString is the longest string read from a replay, the life bar
+// graph being the longest one
+const maxReplayString = 1 << 20
+
+// Replay is the header of a .osr replay, the cursor and key presses that
+// follow it are left out
+type Replay struct {
+	Mode       int
+	Version    int
+	BeatmapMD5 string
+	Player     string
+	ReplayMD5  string
+
+	N300, N100, N50 int
+	Geki, Katu      int
+	Misses          int
+	TotalScore      int
+	MaxCombo        int
+	Perfect         bool
+	Mods            Mods
+	Time            time.Time
+}
+
+// ParseReplay reads the header of a .osr replay
+func ParseReplay(r io.Reader) (*Replay, error) {
+	rr := &replayReader{r: bufio.NewReader(r)}
+	replay := &Replay{
+		Mode:       int(rr.byte()),
+		Version:    int(rr.int32()),
+		BeatmapMD5: rr.string(),
+		Player:     rr.string(),
+		ReplayMD5:  rr.string(),
+		N300:       int(rr.uint16()),
+		N100:       int(rr.uint16()),
+		N50:        int(rr.uint16()),
+		Geki:       int(rr.uint16()),
+		Katu:       int(rr.uint16()),
+		Misses:     int(rr.uint16()),
+		TotalScore: int(rr.int32()),
+		MaxCombo:   int(rr.uint16()),
+		Perfect:    rr.byte() == 1,
+		Mods:       Mods(rr.int32()),
+	}
+	// the life bar graph
+	rr.string()
+	ticks := rr.int64()
+	if rr.err != nil {
+		return nil, fmt.Errorf("osu: bad replay: %w", rr.err)
+	}
+	if replay.Mode < ModeStandard || replay.Mode > ModeMania {
+		return nil, fmt.Errorf("osu: bad replay: unknown mode %d", replay.Mode)
+	}
+	replay.Time = time.Unix(0, (ticks-ticksAtUnixEpoch)*100).UTC()
+	return replay, nil
+}
+
+// Score is the score of the replay, for PP
+func (r *Replay) Score() Score {
+	return Score{N300: r.N300, N100: r.N100, N50: r.N50, Misses: r.Misses, Combo: r.MaxCombo}
+}
+
+// Accuracy is the accuracy of the replay between 0 and 1, counted the way
+// its game mode does
+func (r *Replay) Accuracy() float64 {
+	var hit, total float64
+	switch r.Mode {
+	case ModeTaiko:
+		hit = float64(r.N300) + float64(r.N100)*0.5
+		total = float64(r.N300 + r.N100 + r.Misses)
+	case ModeCatch:
+		hit = float64(r.N300 + r.N100 + r.N50)
+		total = float64(r.N300 + r.N100 + r.N50 + r.Katu + r.Misses)
+	case ModeMania:
+		hit = float64((r.N300+r.Geki)*300+r.Katu*200+r.N100*100+r.N50*50) / 300
+		total = float64(r.N300 + r.Geki + r.Katu + r.N100 + r.N50 + r.Misses)
+	default:
+		return r.Score().Accuracy()
+	}
+	if total == 0 {
+		return 0
+	}
+	return hit / total
+}
+
+// replayReader reads the little endian values of a replay and keeps the
+// first error
+type replayReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (rr *replayReader) read(v interface{}) {
+	if rr.err == nil {
+		rr.err = binary.Read(rr.r, binary.LittleEndian, v)
+	}
+}
+
+func (rr *replayReader) byte() byte {
+	var v byte
+	rr.read(&v)
+	return v
+}
+
+func (rr *replayReader) uint16() uint16 {
+	var v uint16
+	rr.read(&v)
+	return v
+}
+
+func (rr *replayReader) int32() int32 {
+	var v int32
+	rr.read(&v)
+	return v
+}
+
+func (rr *replayReader) int64() int64 {
+	var v int64
+	rr.read(&v)
+	return v
+}
+
+// string reads a string: 0x00 when there is none, or 0x0b then its length
+// as ULEB128 and its UTF-8 bytes
+func (rr *replayReader) string() string {
+	switch rr.byte() {
+	case 0x00:
+		// also where an error was hit before
+		return ""
+	case 0x0b:
+	default:
+		rr.err = errors.New("bad string")
+		return ""
+	}
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		rr.err = err
+		return ""
+	}
+	if n > maxReplayString {
+		rr.err = errors.New("string too long")
+		return ""
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rr.r, b); err != nil {
+		rr.err = err
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,141 @@
+package osu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testReplay writes the header of a .osr replay with the hits and the mods
+// given, and a life bar graph long enough for a two byte length
+func testReplay(mode byte, player string, hits [6]uint16, mods Mods, at time.Time) []byte {
+	var b bytes.Buffer
+	write := func(v interface{}) { binary.Write(&b, binary.LittleEndian, v) }
+	writeString := func(s string) {
+		if s == "" {
+			b.WriteByte(0x00)
+			return
+		}
+		b.WriteByte(0x0b)
+		b.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		b.WriteString(s)
+	}
+	write(mode)
+	write(int32(20260101))
+	writeString("0123456789abcdef0123456789abcdef")
+	writeString(player)
+	writeString("")
+	write(hits)
+	write(int32(72389038))
+	write(uint16(2385))
+	write(byte(1))
+	write(int32(mods))
+	writeString(strings.Repeat("1000|1,", 100))
+	write(at.UnixNano()/100 + ticksAtUnixEpoch)
+	// the compressed cursor data that isn't read
+	write(int32(4))
+	b.WriteString("data")
+	return b.Bytes()
+}
+
+func TestParseReplay(t *testing.T) {
+	at := time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)
+	data := testReplay(ModeStandard, "クッキー", [6]uint16{1500, 20, 1, 300, 15, 2}, Hidden|DoubleTime, at)
+	replay, err := ParseReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Replay{
+		Mode:       ModeStandard,
+		Version:    20260101,
+		BeatmapMD5: "0123456789abcdef0123456789abcdef",
+		Player:     "クッキー",
+		N300:       1500,
+		N100:       20,
+		N50:        1,
+		Geki:       300,
+		Katu:       15,
+		Misses:     2,
+		TotalScore: 72389038,
+		MaxCombo:   2385,
+		Perfect:    true,
+		Mods:       Hidden | DoubleTime,
+		Time:       at,
+	}
+	if *replay != want {
+		t.Errorf("got %+v, want %+v", *replay, want)
+	}
+	if replay.Mods.String() != "HDDT" {
+		t.Errorf("mods are %s, want HDDT", replay.Mods)
+	}
+	if s := replay.Score(); s != (Score{N300: 1500, N100: 20, N50: 1, Misses: 2, Combo: 2385}) {
+		t.Errorf("score is %+v", s)
+	}
+}
+
+func TestReplayAccuracy(t *testing.T) {
+	tests := []struct {
+		mode byte
+		hits [6]uint16 // 300, 100, 50, geki, katu, misses
+		want float64
+	}{
+		{ModeStandard, [6]uint16{90, 6, 3, 40, 4, 1}, (90*300 + 6*100 + 3*50) / 30000.0},
+		{ModeTaiko, [6]uint16{90, 8, 0, 0, 0, 2}, (90 + 8*0.5) / 100},
+		{ModeCatch, [6]uint16{80, 10, 5, 0, 3, 2}, 95 / 100.0},
+		{ModeMania, [6]uint16{50, 10, 5, 30, 4, 1}, (80*300 + 4*200 + 10*100 + 5*50) / (100 * 300.0)},
+		{ModeMania, [6]uint16{}, 0},
+	}
+	for _, tt := range tests {
+		replay, err := ParseReplay(bytes.NewReader(testReplay(tt.mode, "player", tt.hits, 0, time.Now())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := replay.Accuracy(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("mode %d %v: accuracy %v, want %v", tt.mode, tt.hits, got, tt.want)
+		}
+	}
+}
+
+func TestParseReplayErrors(t *testing.T) {
+	data := testReplay(ModeStandard, "player", [6]uint16{100, 0, 0, 0, 0, 0}, 0, time.Now())
+	badString := bytes.Clone(data)
+	// the player is the second string after the mode, the version and the
+	// 34 bytes of the MD5 of the beatmap
+	badString[1+4+34] = 0x01
+	tooLong := append(bytes.Clone(data[:1+4]), 0x0b)
+	tooLong = binary.AppendUvarint(tooLong, maxReplayString+1)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", data[:3]},
+		{"truncated string", data[:1+4+10]},
+		{"no date", data[:len(data)-8-4-4]},
+		{"bad string", badString},
+		{"string too long", tooLong},
+		{"unknown mode", testReplay(4, "player", [6]uint16{}, 0, time.Now())},
+		{"not a replay", []byte("osu file format v14\n\n[General]\nMode: 0\n")},
+	}
+	for _, tt := range tests {
+		replay, err := ParseReplay(bytes.NewReader(tt.data))
+		if err == nil {
+			t.Errorf("%s: got %+v, want an error", tt.name, replay)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "osu: bad replay: ") {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+
+	_, err := ParseReplay(bytes.NewReader(data[:20]))
+	if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		t.Errorf("a truncated replay fails with %v", err)
+	}
+}
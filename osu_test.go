@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/afifmakarim/go-tamako/provider"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+func TestDefaultOsuMode(t *testing.T) {
+	plays := func(standard, taiko, ctb, mania int) map[string]OsuStatistics {
+		return map[string]OsuStatistics{
+			"standard": {PlayCount: standard},
+			"taiko":    {PlayCount: taiko},
+			"ctb":      {PlayCount: ctb},
+			"mania":    {PlayCount: mania},
+		}
+	}
+	tests := []struct {
+		name string
+		user OsuUser
+		want string
+	}{
+		{"most played", OsuUser{Playmode: "osu", Rulesets: plays(10, 0, 0, 500)}, "mania"},
+		{"tie keeps the first", OsuUser{Playmode: "mania", Rulesets: plays(0, 7, 7, 0)}, "taiko"},
+		{"no plays uses the profile", OsuUser{Playmode: "fruits", Rulesets: plays(0, 0, 0, 0)}, "ctb"},
+		{"missing modes", OsuUser{Playmode: "osu", Rulesets: map[string]OsuStatistics{"taiko": {PlayCount: 3}}}, "taiko"},
+		{"nothing known", OsuUser{}, "standard"},
+	}
+	for _, tt := range tests {
+		if got := defaultOsuMode(&tt.user); got.Key != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got.Key, tt.want)
+		}
+	}
+}
+
+func TestOsuRulesetArg(t *testing.T) {
+	tests := []struct {
+		args  []string
+		flags map[string]string
+		mode  string // empty when the arguments are left alone
+		text  string
+	}{
+		{[]string{"taiko", "cookiezi"}, nil, "taiko", "cookiezi"},
+		{[]string{"STD"}, nil, "standard", ""},
+		{[]string{"fruits", "mr", "ekko"}, nil, "ctb", "mr ekko"},
+		{[]string{"cookiezi"}, nil, "", "cookiezi"},
+		{[]string{"mania", "cookiezi"}, map[string]string{"mode": "taiko"}, "", "mania cookiezi"},
+		{nil, nil, "", ""},
+	}
+	for _, tt := range tests {
+		req := &CommandRequest{Args: tt.args, Flags: tt.flags, Text: strings.Join(tt.args, " ")}
+		got := osuRulesetArg(req)
+		if got != (tt.mode != "") || req.Text != tt.text {
+			t.Errorf("%q: got %v %q, want %v %q", tt.args, got, req.Text, tt.mode != "", tt.text)
+		}
+		if got && req.Flags["mode"] != tt.mode {
+			t.Errorf("%q: --mode is %q, want %q", tt.args, req.Flags["mode"], tt.mode)
+		}
+	}
+}
+
+// osuUpstream serves a token and the users of the osu! API, cookiezi plays
+// mania the most and every other user is unknown
+func osuUpstream() http.Handler {
+	playCounts := map[string]int{"osu": 100, "taiko": 20, "fruits": 0, "mania": 900}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/oauth/token":
+			fmt.Fprint(w, `{"access_token":"token","expires_in":86400}`)
+		case strings.HasPrefix(r.URL.Path, "/api/v2/users/@cookiezi/"):
+			ruleset := strings.TrimPrefix(r.URL.Path, "/api/v2/users/@cookiezi/")
+			fmt.Fprintf(w, `{"id":124493,"username":"cookiezi","playmode":"osu","statistics":{"global_rank":%d,"pp":1000,"play_count":%d}}`, playCounts[ruleset]+1, playCounts[ruleset])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestOsuModeCard(t *testing.T) {
+	app, messenger := newTestBot(t, osuUpstream(), func(cfg *Config) {
+		cfg.Providers[providerOsu] = ProviderConfig{ClientID: "id", Key: "secret", Endpoint: cfg.Providers[providerOsu].Endpoint}
+	})
+	tests := []struct {
+		text    string
+		altText string
+	}{
+		{"!osu cookiezi", "osu! information of cookiezi"},
+		{"!osu taiko cookiezi", "osu! Taiko information of cookiezi"},
+		{"!osu std cookiezi", "osu! Standard information of cookiezi"},
+		{"!osu cookiezi --mode=ctb", "osu! Catch the beat information of cookiezi"},
+		{"!osu cookiezi --mode", "osu! Mania information of cookiezi"},
+		{"!osu --mode=taiko mania", "osu information not found"},
+	}
+	for _, tt := range tests {
+		before := len(messenger.Replies())
+		sendText(t, app, messenger, tt.text)
+		replies := messenger.Replies()[before:]
+		if len(replies) != 1 || len(replies[0].Messages) != 1 {
+			t.Errorf("%q: got %d replies, want one", tt.text, len(replies))
+			continue
+		}
+		if got := messageSummary(replies[0].Messages[0]); got != tt.altText {
+			t.Errorf("%q: got %q, want %q", tt.text, got, tt.altText)
+		}
+	}
+}
+
+// messageSummary is the text of a text message or the alt text of a flex
+// message
+func messageSummary(message linebot.SendingMessage) string {
+	switch m := message.(type) {
+	case *linebot.TextMessage:
+		return m.Text
+	case *linebot.FlexMessage:
+		return m.AltText
+	}
+	return fmt.Sprintf("%T", message)
+}
+
+func TestOsuGetRenewsRejectedToken(t *testing.T) {
+	// the tokens up to revoked are rejected
+	var tokens, calls, revoked int32 = 0, 0, 1
+	app, _ := newTestBot(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			fmt.Fprintf(w, `{"access_token":"token %d","expires_in":86400}`, atomic.AddInt32(&tokens, 1))
+		case "/api/v2/me":
+			atomic.AddInt32(&calls, 1)
+			var n int32
+			fmt.Sscanf(r.Header.Get("Authorization"), "Bearer token %d", &n)
+			if n <= atomic.LoadInt32(&revoked) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"id":124493,"username":"cookiezi"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}), func(cfg *Config) {
+		cfg.Providers[providerOsu] = ProviderConfig{ClientID: "id", Key: "secret", Endpoint: cfg.Providers[providerOsu].Endpoint}
+	})
+	app.http.Cache = nil
+
+	// the revoked token is fetched again once
+	var user OsuUser
+	if err := app.osuGet(context.Background(), "/me", &user); err != nil || user.Username != "cookiezi" {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+	if tokens != 2 || calls != 2 {
+		t.Errorf("fetched %d tokens for %d calls, want 2 and 2", tokens, calls)
+	}
+	// and the new one is kept
+	if err := app.osuGet(context.Background(), "/me", &user); err != nil || tokens != 2 || calls != 3 {
+		t.Errorf("got %v after %d tokens and %d calls", err, tokens, calls)
+	}
+
+	// a token rejected twice in a row gives up
+	atomic.StoreInt32(&revoked, 3)
+	err := app.osuGet(context.Background(), "/me", &user)
+	if perr, ok := provider.AsError(err); !ok || perr.Status != http.StatusUnauthorized || tokens != 3 || calls != 5 {
+		t.Errorf("got %v after %d tokens and %d calls", err, tokens, calls)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/chart"
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// osuHistoryInterval is how often the tracked osu! players are checked for
+// a new snapshot
+const osuHistoryInterval = time.Hour
+
+// osuSnapshotEvery is the least time between two snapshots of a player, a
+// bit less than a day so the daily snapshot doesn't drift
+const osuSnapshotEvery = 20 * time.Hour
+
+// osuTrackFor is how long after the last lookup a player keeps being
+// snapshotted
+const osuTrackFor = 30 * 24 * time.Hour
+
+// osuSnapshot is the snapshot of the ranks of a user in every game mode
+func osuSnapshot(user *OsuUser, now time.Time) store.OsuSnapshot {
+	snapshot := store.OsuSnapshot{Time: now, Ranks: make(map[string]store.OsuRank)}
+	for key, stats := range user.Rulesets {
+		snapshot.Ranks[key] = store.OsuRank{Global: stats.GlobalRank, Country: stats.CountryRank, Accuracy: stats.HitAccuracy}
+	}
+	return snapshot
+}
+
+// recordOsuSnapshot adds a snapshot to the history of a player, unless the
+// last one is too recent or it misses modes. A lookup keeps the player
+// tracked.
+func (app *TamakoBot) recordOsuSnapshot(userID, username string, snapshot store.OsuSnapshot, lookup bool) (*store.OsuPlayer, error) {
+	var saved store.OsuPlayer
+	err := app.store.UpdateOsuPlayer(userID, func(player *store.OsuPlayer) error {
+		player.Username = username
+		if lookup {
+			player.LastLookup = snapshot.Time
+		}
+		// a snapshot missing modes would hold the next full one back
+		if len(snapshot.Ranks) < len(osuModes) {
+			saved = *player
+			return nil
+		}
+		if latest := player.Latest(); latest == nil || snapshot.Time.Sub(latest.Time) >= osuSnapshotEvery {
+			player.Snapshots = append(player.Snapshots, snapshot)
+		}
+		saved = *player
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// osuWeeklyChange describes how the global rank of a player in a game mode
+// moved since a week ago, "" when there is nothing to compare with
+func osuWeeklyChange(ctx context.Context, player *store.OsuPlayer, mode string, now time.Time) string {
+	if player == nil {
+		return ""
+	}
+	old := player.SnapshotAt(now.Add(-7 * 24 * time.Hour))
+	latest := player.Latest()
+	if old == nil || latest == nil {
+		return ""
+	}
+	before, after := old.Ranks[mode].Global, latest.Ranks[mode].Global
+	if before == 0 || after == 0 {
+		return ""
+	}
+	// climbing means a smaller rank number
+	if gain := before - after; gain != 0 {
+		return tr(ctx, "%+d since last week", gain)
+	}
+	return tr(ctx, "No change since last week")
+}
+
+// snapshotOsuPlayers takes the daily snapshot of the players looked up lately
+func (app *TamakoBot) snapshotOsuPlayers(ctx context.Context, now time.Time) {
+	players, err := app.store.OsuPlayers()
+	if err != nil {
+		slog.ErrorContext(ctx, "osu! players", "err", err)
+		return
+	}
+	for _, player := range players {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Sub(player.LastLookup) > osuTrackFor {
+			continue
+		}
+		if latest := player.Latest(); latest != nil && now.Sub(latest.Time) < osuSnapshotEvery {
+			continue
+		}
+		// by ID, the player may have been renamed since
+		user, err := app.osuUser(ctx, player.UserID, osuModes...)
+		if err != nil {
+			slog.WarnContext(ctx, "osu! snapshot", "user", player.Username, "err", err)
+			continue
+		}
+		if user == nil {
+			continue
+		}
+		if _, err := app.recordOsuSnapshot(player.UserID, user.Username, osuSnapshot(user, now), false); err != nil {
+			slog.ErrorContext(ctx, "osu! snapshot", "user", player.Username, "err", err)
+		}
+	}
+}
+
+// osuHistoryCommand replies with a chart of the global rank of a player over
+// time in one game mode
+func (app *TamakoBot) osuHistoryCommand(req *CommandRequest, name string) error {
+	ctx := req.Context()
+	mode, auto, ok := osuModeFlag(req)
+	if !ok {
+		return app.replyText(req.ReplyToken, tr(ctx, "Unknown mode, use one of %s", strings.Join(osuModeKeys(), ", ")))
+	}
+	now := time.Now()
+	user, err := app.osuUser(ctx, osuUserByName(name), osuModes...)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return app.replyText(req.ReplyToken, tr(ctx, "osu information not found"))
+	}
+	if auto {
+		mode = defaultOsuMode(user)
+	}
+	userID, username := strconv.Itoa(user.ID), user.Username
+	player, err := app.recordOsuSnapshot(userID, username, osuSnapshot(user, now), true)
+	if err != nil {
+		return err
+	}
+
+	var points []chart.Point
+	for _, s := range player.Snapshots {
+		if rank := s.Ranks[mode.Key].Global; rank > 0 {
+			points = append(points, chart.Point{Time: s.Time, Value: float64(rank)})
+		}
+	}
+	if len(points) < 2 {
+		return app.replyText(req.ReplyToken, tr(ctx, "Not enough %s history for %s yet, snapshots are taken daily from now on", mode.Name, username))
+	}
+
+	// one chart per player and mode, replaced on every request so the
+	// public download directory doesn't grow
+	chartName := fmt.Sprintf("osu-history-%s-%s.png", userID, mode.Key)
+	line := &chart.Line{Points: points, Inverted: true, Color: mode.Color}
+	if err := writeChart(filepath.Join(app.config.DownloadDir, chartName), line); err != nil {
+		return err
+	}
+
+	first, last := points[0], points[len(points)-1]
+	days := int(last.Time.Sub(first.Time).Hours()/24 + 0.5)
+	summary := tr(ctx, "%s rank of %s over %d days\n#%d → #%d", mode.Name, username, days, int(first.Value), int(last.Value))
+	// the query makes LINE fetch the new chart instead of a cached one
+	imageURL := fmt.Sprintf("%s/downloaded/%s?t=%d", app.config.AppBaseURL, chartName, now.Unix())
+	return app.bot.ReplyMessage(
+		req.ReplyToken,
+		linebot.NewTextMessage(summary),
+		linebot.NewImageMessage(imageURL, imageURL),
+	)
+}
+
+// writeChart renders a chart to path through a temporary file, so the old
+// chart is served until the new one is complete
+func writeChart(path string, line *chart.Line) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = line.EncodePNG(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// fullSnapshot is a snapshot ranking a player at rank in every mode
+func fullSnapshot(at time.Time, rank int) store.OsuSnapshot {
+	snapshot := store.OsuSnapshot{Time: at, Ranks: make(map[string]store.OsuRank)}
+	for _, mode := range osuModes {
+		snapshot.Ranks[mode.Key] = store.OsuRank{Global: rank}
+	}
+	return snapshot
+}
+
+func TestOsuWeeklyChange(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	player := func(snapshots ...store.OsuSnapshot) *store.OsuPlayer {
+		return &store.OsuPlayer{Snapshots: snapshots}
+	}
+	tests := []struct {
+		name   string
+		player *store.OsuPlayer
+		want   string
+	}{
+		{"no player", nil, ""},
+		{"no snapshots", player(), ""},
+		{"younger than a week", player(fullSnapshot(day(6), 500), fullSnapshot(day(0), 400)), ""},
+		{"climbed", player(fullSnapshot(day(8), 500), fullSnapshot(day(0), 400)), "+100 since last week"},
+		{"fell", player(fullSnapshot(day(7), 400), fullSnapshot(day(1), 450)), "-50 since last week"},
+		{"the week old one wins", player(fullSnapshot(day(14), 900), fullSnapshot(day(7), 500), fullSnapshot(day(3), 450), fullSnapshot(day(0), 500)), "No change since last week"},
+		{"unranked a week ago", player(fullSnapshot(day(8), 0), fullSnapshot(day(0), 400)), ""},
+		{"unranked now", player(fullSnapshot(day(8), 500), fullSnapshot(day(0), 0)), ""},
+		{"mode missing", player(store.OsuSnapshot{Time: day(8)}, fullSnapshot(day(0), 400)), ""},
+	}
+	for _, tt := range tests {
+		if got := osuWeeklyChange(context.Background(), tt.player, "taiko", now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordOsuSnapshot(t *testing.T) {
+	app, _ := newTestBot(t, osuUpstream(), nil)
+	start := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after     time.Duration
+		snapshot  store.OsuSnapshot
+		lookup    bool
+		snapshots int
+	}{
+		{0, fullSnapshot(start, 500), true, 1},
+		{time.Hour, fullSnapshot(start.Add(time.Hour), 499), true, 1},
+		{osuSnapshotEvery - time.Minute, fullSnapshot(start.Add(osuSnapshotEvery-time.Minute), 498), false, 1},
+		{osuSnapshotEvery, fullSnapshot(start.Add(osuSnapshotEvery), 497), false, 2},
+		// a snapshot missing modes is left out
+		{3 * osuSnapshotEvery, store.OsuSnapshot{Time: start.Add(3 * osuSnapshotEvery), Ranks: map[string]store.OsuRank{"standard": {Global: 1}}}, true, 2},
+	}
+	lastLookup := time.Time{}
+	for i, step := range steps {
+		player, err := app.recordOsuSnapshot("124493", "cookiezi", step.snapshot, step.lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.lookup {
+			lastLookup = step.snapshot.Time
+		}
+		if len(player.Snapshots) != step.snapshots || !player.LastLookup.Equal(lastLookup) || player.Username != "cookiezi" {
+			t.Errorf("step %d: %d snapshots, last lookup %s, want %d and %s", i, len(player.Snapshots), player.LastLookup, step.snapshots, lastLookup)
+		}
+	}
+	player, err := app.store.OsuPlayer("124493")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranks := []int{player.Snapshots[0].Ranks["mania"].Global, player.Snapshots[1].Ranks["mania"].Global}; ranks[0] != 500 || ranks[1] != 497 {
+		t.Errorf("saved the ranks %v, want 500 and 497", ranks)
+	}
+}
+
+func TestOsuHistoryCommand(t *testing.T) {
+	app, messenger := newTestBot(t, osuUpstream(), func(cfg *Config) {
+		cfg.AppBaseURL = "https://tamako.example"
+		cfg.Providers[providerOsu] = ProviderConfig{ClientID: "id", Key: "secret", Endpoint: cfg.Providers[providerOsu].Endpoint}
+	})
+	if replies := sendText(t, app, messenger, "!osu history cookiezi"); len(replies) != 1 || !strings.HasPrefix(replies[0], "Not enough Mania history for cookiezi yet") {
+		t.Fatalf("got %q", replies)
+	}
+	// a snapshot from three days ago
+	err := app.store.UpdateOsuPlayer("124493", func(player *store.OsuPlayer) error {
+		player.Snapshots = []store.OsuSnapshot{fullSnapshot(time.Now().Add(-72*time.Hour), 2000)}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		before := len(messenger.Replies())
+		sendText(t, app, messenger, "!osu history cookiezi")
+		replies := messenger.Replies()[before:]
+		if len(replies) != 1 || len(replies[0].Messages) != 2 {
+			t.Fatalf("got %+v, want a text and a chart", replies)
+		}
+		if summary := messageSummary(replies[0].Messages[0]); summary != "Mania rank of cookiezi over 3 days\n#2000 → #901" {
+			t.Errorf("got %q", summary)
+		}
+		image, ok := replies[0].Messages[1].(*linebot.ImageMessage)
+		if !ok || !strings.HasPrefix(image.OriginalContentURL, "https://tamako.example/downloaded/osu-history-124493-mania.png?t=") {
+			t.Errorf("got %#v, want the chart of cookiezi", replies[0].Messages[1])
+		}
+	}
+
+	files, err := os.ReadDir(app.config.DownloadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	if len(names) != 1 || names[0] != "osu-history-124493-mania.png" {
+		t.Errorf("the download directory holds %q, want one chart", names)
+	}
+	// the lookups in a row took a single snapshot
+	if player, _ := app.store.OsuPlayer("124493"); len(player.Snapshots) != 2 {
+		t.Errorf("%d snapshots, want 2", len(player.Snapshots))
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/osu"
+)
+
+// osuMapAccuracies are the accuracies of the pp table of the beatmap card
+var osuMapAccuracies = []float64{95, 98, 99, 100}
+
+// beatmapLinkPattern matches the links to a beatmap difficulty, the ID is
+// the first group
+var beatmapLinkPattern = regexp.MustCompile(`https?://osu\.ppy\.sh/(?:beatmapsets/\d+#(?:osu|taiko|fruits|mania)/|beatmaps/|b/)(\d+)`)
+
+// osuBeatmapFile downloads and reads the .osu file of a beatmap, nil when
+// there is no such beatmap
+func (app *TamakoBot) osuBeatmapFile(ctx context.Context, id int) (*osu.Beatmap, error) {
+	body, err := app.http.Get(ctx, "osu!", app.providerURL(providerOsu, "/osu/"+strconv.Itoa(id)), nil)
+	if err != nil {
+		return nil, err
+	}
+	beatmap, err := osu.Parse(bytes.NewReader(body))
+	if errors.Is(err, osu.ErrNoHitObjects) {
+		return nil, nil
+	}
+	return beatmap, err
+}
+
+// osuMapCommand replies with the difficulty and the pp of a beatmap, by ID
+// or link, with the mods of an argument like +HDDT
+func (app *TamakoBot) osuMapCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	usage := tr(ctx, "Usage : %s", req.Prefix+"osu map <beatmap id or link> [+<mods>]")
+	var id int
+	var mods osu.Mods
+	for _, arg := range req.Args {
+		if strings.HasPrefix(arg, "+") {
+			m, err := osu.ParseMods(arg)
+			if err != nil {
+				return app.replyText(req.ReplyToken, tr(ctx, "Unknown mods %s, write them like +HDDT", arg))
+			}
+			mods = m
+			continue
+		}
+		if match := beatmapLinkPattern.FindStringSubmatch(arg); match != nil {
+			arg = match[1]
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return app.replyText(req.ReplyToken, usage)
+		}
+		id = n
+	}
+	if id == 0 {
+		return app.replyText(req.ReplyToken, usage)
+	}
+
+	beatmap, err := app.osuBeatmapFile(ctx, id)
+	if err != nil {
+		return err
+	}
+	if beatmap == nil {
+		return app.replyText(req.ReplyToken, tr(ctx, "Beatmap not found"))
+	}
+	// the files of unsubmitted difficulties may lack their ID
+	if beatmap.BeatmapID == 0 {
+		beatmap.BeatmapID = id
+	}
+	return app.replyBeatmap(ctx, req.ReplyToken, beatmap, mods)
+}
+
+// replyBeatmap replies with the card of a beatmap played with mods. The
+// beatmaps of the other game modes get the card without difficulty.
+func (app *TamakoBot) replyBeatmap(ctx context.Context, replyToken string, beatmap *osu.Beatmap, mods osu.Mods) error {
+	difficulty, err := osu.Calculate(beatmap, mods)
+	if err != nil && !errors.Is(err, osu.ErrNotStandard) {
+		return err
+	}
+	bubble := osuBeatmapBubble(ctx, beatmap, difficulty)
+	return app.replyFlex(replyToken, fmt.Sprintf("%s - %s [%s]", beatmap.Artist, beatmap.Title, beatmap.Version), &flex.Carousel{Contents: []*flex.Bubble{bubble}})
+}
+
+// osuBeatmapBubble is the card of a beatmap with its pp for a few accuracies,
+// d is nil for the beatmaps that can't be calculated
+func osuBeatmapBubble(ctx context.Context, beatmap *osu.Beatmap, d *osu.Difficulty) *flex.Bubble {
+	mode := osuModes[beatmap.Mode]
+	speed, stats, version := 1.0, beatmap.Stats(), defaultValue(beatmap.Version)
+	if d != nil {
+		speed, stats = d.Mods.SpeedMultiplier(), d.Stats
+		version = fmt.Sprintf("%s %.2f★ %s", version, d.Stars, d.Mods)
+	}
+	length := time.Duration(float64(beatmap.Length()) / speed).Round(time.Second)
+
+	header := flex.VBox(
+		&flex.Text{Text: defaultValue(beatmap.Title), Weight: "bold", Size: "md", Color: "#ffffff", Wrap: true},
+		&flex.Text{Text: defaultValue(beatmap.Artist), Size: "xs", Color: "#ffffff", Wrap: true},
+	)
+	header.BackgroundColor = fmt.Sprintf("#%02x%02x%02x", mode.Color.R, mode.Color.G, mode.Color.B)
+	header.PaddingAll = "13px"
+
+	body := flex.VBox(
+		&flex.Text{Text: "[" + version + "]", Size: "sm", Weight: "bold", Wrap: true},
+		&flex.Text{Text: tr(ctx, "mapped by %s", defaultValue(beatmap.Creator)), Size: "xs", Color: "#aaaaaa", Wrap: true},
+		&flex.Separator{Margin: "md"},
+		detailRow(tr(ctx, "Mode"), mode.Name),
+		detailRow("CS/AR/OD/HP", fmt.Sprintf("%.1f/%.1f/%.1f/%.1f", stats.CS, stats.AR, stats.OD, stats.HP)),
+		detailRow("BPM", strconv.FormatFloat(beatmap.BPM()*speed, 'f', 0, 64)),
+		detailRow(tr(ctx, "Length"), fmt.Sprintf("%d:%02d", int(length.Minutes()), int(length.Seconds())%60)),
+	)
+	if d == nil {
+		body.Add(
+			&flex.Separator{Margin: "md"},
+			&flex.Text{Text: tr(ctx, "Only osu!standard beatmaps can be calculated"), Size: "xs", Color: "#aaaaaa", Wrap: true},
+		)
+	} else {
+		body.Add(
+			detailRow(tr(ctx, "Max Combo"), strconv.Itoa(d.MaxCombo)+"x"),
+			detailRow(tr(ctx, "Aim/Speed"), fmt.Sprintf("%.2f★/%.2f★", d.Aim, d.Speed)),
+			&flex.Separator{Margin: "md"},
+		)
+		for _, accuracy := range osuMapAccuracies {
+			pp := d.PP(d.ScoreFor(accuracy, 0))
+			body.Add(rankRow(strconv.FormatFloat(accuracy, 'f', -1, 64)+"%", strconv.FormatFloat(pp.Total, 'f', 0, 64)+"pp"))
+		}
+	}
+	body.Spacing = "sm"
+	body.PaddingAll = "13px"
+
+	bubble := &flex.Bubble{Size: "kilo", Header: header, Body: body}
+	if beatmap.BeatmapSetID > 0 {
+		bubble.Hero = &flex.Image{URL: "https://assets.ppy.sh/beatmaps/" + strconv.Itoa(beatmap.BeatmapSetID) + "/covers/card.jpg", Size: "full", AspectRatio: "20:7", AspectMode: "cover"}
+	}
+	if beatmap.BeatmapID > 0 {
+		bubble.Footer = flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Beatmap"), URI: "https://osu.ppy.sh/b/" + strconv.Itoa(beatmap.BeatmapID)},
+			Style:  "primary",
+			Color:  header.BackgroundColor,
+		})
+	}
+	return bubble
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// testOsuFile is a .osu file of 200 jumps with the metadata given
+func testOsuFile(title, artist, version string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "osu file format v14\n\n[General]\nMode: 0\n\n[Metadata]\nTitle:%s\nArtist:%s\nCreator:Creator\nVersion:%s\nBeatmapSetID:2\n\n", title, artist, version)
+	b.WriteString("[Difficulty]\nHPDrainRate:5\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\nSliderMultiplier:1.8\nSliderTickRate:1\n\n[TimingPoints]\n0,333.33,4,2,0,60,1,0\n\n[HitObjects]\n")
+	for i := 0; i < 200; i++ {
+		x := 356
+		if i%2 == 1 {
+			x = 156
+		}
+		fmt.Fprintf(&b, "%d,192,%d,1,0,0:0:0:0:\n", x, i*166)
+	}
+	return b.String()
+}
+
+func TestOsuMapCommand(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("ＦＲＥＥＤＯＭ ＤｉＶＥ ", 30))
+	files := map[string]string{
+		"/osu/1": testOsuFile("FREEDOM DiVE", "xi", "FOUR DIMENSIONS"),
+		"/osu/2": testOsuFile(long, long, long),
+		"/osu/3": strings.Replace(testOsuFile("Taiko", "A", "Oni"), "Mode: 0", "Mode: 1", 1),
+	}
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the website serves an empty file for a beatmap that doesn't exist
+		fmt.Fprint(w, files[r.URL.Path])
+	})
+	app, messenger := newTestBot(t, upstream, func(cfg *Config) {
+		cfg.Providers[providerOsu] = ProviderConfig{ClientID: "id", Key: "secret", Endpoint: cfg.Providers[providerOsu].Endpoint}
+	})
+	tests := []struct {
+		text    string
+		altText string
+	}{
+		{"!osu map 1 +HDDT", "xi - FREEDOM DiVE [FOUR DIMENSIONS]"},
+		{"!osu map https://osu.ppy.sh/beatmapsets/2#osu/2", flex.AltText(fmt.Sprintf("%s - %s [%s]", long, long, long))},
+		{"!osu map 3", "A - Taiko [Oni]"},
+		{"!osu map 4", "Beatmap not found"},
+		{"!osu map 1 +XX", "Unknown mods +XX, write them like +HDDT"},
+	}
+	for _, tt := range tests {
+		before := len(messenger.Replies())
+		sendText(t, app, messenger, tt.text)
+		replies := messenger.Replies()[before:]
+		if len(replies) != 1 || len(replies[0].Messages) != 1 {
+			t.Errorf("%q: got %d replies, want one", tt.text, len(replies))
+			continue
+		}
+		got := messageSummary(replies[0].Messages[0])
+		if got != tt.altText {
+			t.Errorf("%q: got %q, want %q", tt.text, got, tt.altText)
+		}
+		if utf8.RuneCountInString(got) > flex.MaxAltTextLength {
+			t.Errorf("%q: the alt text is %d characters long", tt.text, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+func TestBeatmapLinkDetection(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testOsuFile("FREEDOM DiVE", "xi", "FOUR DIMENSIONS"))
+	})
+	withOsu := func(cfg *Config) {
+		cfg.Providers[providerOsu] = ProviderConfig{ClientID: "id", Key: "secret", Endpoint: cfg.Providers[providerOsu].Endpoint}
+	}
+	const link = "have you tried https://osu.ppy.sh/b/1 yet?"
+	replies := func(app *TamakoBot, messenger *RecordingMessenger, text string) []string {
+		before := len(messenger.Replies())
+		sendText(t, app, messenger, text)
+		var summaries []string
+		for _, reply := range messenger.Replies()[before:] {
+			for _, message := range reply.Messages {
+				summaries = append(summaries, messageSummary(message))
+			}
+		}
+		return summaries
+	}
+
+	app, messenger := newTestBot(t, upstream, withOsu)
+	if got := replies(app, messenger, link); len(got) != 1 || got[0] != "xi - FREEDOM DiVE [FOUR DIMENSIONS]" {
+		t.Errorf("got %q, want the beatmap card", got)
+	}
+	if got := replies(app, messenger, "just chatting"); len(got) != 0 {
+		t.Errorf("got %q for a message without link", got)
+	}
+
+	// the link is left alone where osu would refuse it
+	app.store.UpdateChat("G1", func(chat *store.Chat) error {
+		chat.Disabled = []string{"osu"}
+		return nil
+	})
+	if got := replies(app, messenger, link); len(got) != 0 {
+		t.Errorf("got %q with osu turned off", got)
+	}
+	app, messenger = newTestBot(t, upstream, nil)
+	if got := replies(app, messenger, link); len(got) != 0 {
+		t.Errorf("got %q without osu! credentials", got)
+	}
+	app, messenger = newTestBot(t, upstream, withOsu)
+	app.commands = NewCommandRegistry()
+	if got := replies(app, messenger, link); len(got) != 0 {
+		t.Errorf("got %q without the osu command", got)
+	}
+}
+
+func TestOsuFileUpload(t *testing.T) {
+	app, messenger := newTestBot(t, http.NotFoundHandler(), nil)
+	tests := []struct {
+		mode    string
+		altText string
+		stars   bool
+	}{
+		{"0", "xi - FREEDOM DiVE [FOUR DIMENSIONS]", true},
+		{"1", "xi - FREEDOM DiVE [FOUR DIMENSIONS]", false},
+		{"3", "xi - FREEDOM DiVE [FOUR DIMENSIONS]", false},
+		{"7", "map.osu is not a beatmap I can read", false},
+	}
+	for _, tt := range tests {
+		file := strings.Replace(testOsuFile("FREEDOM DiVE", "xi", "FOUR DIMENSIONS"), "Mode: 0", "Mode: "+tt.mode, 1)
+		messenger.SetContent("M"+tt.mode, []byte(file))
+		before := len(messenger.Replies())
+		if err := app.handleOsuFile(context.Background(), &linebot.FileMessage{ID: "M" + tt.mode, FileName: "map.osu"}, "reply-token"); err != nil {
+			t.Fatalf("mode %s: %v", tt.mode, err)
+		}
+		replies := messenger.Replies()[before:]
+		if len(replies) != 1 || messageSummary(replies[0].Messages[0]) != tt.altText {
+			t.Errorf("mode %s: got %+v, want %q", tt.mode, replies, tt.altText)
+			continue
+		}
+		if _, ok := replies[0].Messages[0].(*linebot.FlexMessage); !ok {
+			continue
+		}
+		_, card := lastFlex(t, messenger)
+		for _, row := range []string{"CS/AR/OD/HP", "BPM", "Length", "0:33"} {
+			if !strings.Contains(card, row) {
+				t.Errorf("mode %s: the card lacks %s", tt.mode, row)
+			}
+		}
+		if got := strings.Contains(card, "★") && strings.Contains(card, "pp"); got != tt.stars {
+			t.Errorf("mode %s: the card shows the stars and pp: %v, want %v", tt.mode, got, tt.stars)
+		}
+		if got := strings.Contains(card, "Only osu!standard beatmaps can be calculated"); got == tt.stars {
+			t.Errorf("mode %s: the card explains the missing pp: %v", tt.mode, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/afifmakarim/go-tamako/flex"
+	"github.com/afifmakarim/go-tamako/osu"
+	"github.com/afifmakarim/go-tamako/provider"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// maxOsuUpload is the largest replay or beatmap read from the chat
+const maxOsuUpload = 8 << 20
+
+// osuBeatmapByChecksum looks a beatmap up by the MD5 of its .osu file, as
+// replays name it. The beatmap is nil when the osu! API doesn't know it.
+func (app *TamakoBot) osuBeatmapByChecksum(ctx context.Context, md5 string) (*OsuBeatmap, error) {
+	var beatmap OsuBeatmap
+	err := app.osuGet(ctx, "/beatmaps/lookup?checksum="+url.QueryEscape(md5), &beatmap)
+	if provider.IsKind(err, provider.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &beatmap, nil
+}
+
+// handleOsuFile answers a .osr replay or a .osu beatmap sent to the chat
+func (app *TamakoBot) handleOsuFile(ctx context.Context, message *linebot.FileMessage, replyToken string) error {
+	content, err := app.bot.GetMessageContent(message.ID)
+	if err != nil {
+		return err
+	}
+	defer content.Content.Close()
+	data, err := io.ReadAll(io.LimitReader(content.Content, maxOsuUpload+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxOsuUpload {
+		return app.replyText(replyToken, tr(ctx, "%s is too large", message.FileName))
+	}
+
+	if strings.EqualFold(filepath.Ext(message.FileName), ".osu") {
+		beatmap, err := osu.Parse(bytes.NewReader(data))
+		if err != nil {
+			slog.InfoContext(ctx, "Bad beatmap", "file", message.FileName, "err", err)
+			return app.replyText(replyToken, tr(ctx, "%s is not a beatmap I can read", message.FileName))
+		}
+		return app.replyBeatmap(ctx, replyToken, beatmap, 0)
+	}
+	replay, err := osu.ParseReplay(bytes.NewReader(data))
+	if err != nil {
+		slog.InfoContext(ctx, "Bad replay", "file", message.FileName, "err", err)
+		return app.replyText(replyToken, tr(ctx, "%s is not a replay I can read", message.FileName))
+	}
+	return app.replyReplay(ctx, replyToken, replay)
+}
+
+// replyReplay replies with the card of a replay, with its beatmap and pp
+// when the osu! API knows the beatmap
+func (app *TamakoBot) replyReplay(ctx context.Context, replyToken string, replay *osu.Replay) error {
+	var beatmap *OsuBeatmap
+	var difficulty *osu.Difficulty
+	if app.config.ProviderConfigured(providerOsu) && replay.BeatmapMD5 != "" {
+		var err error
+		if beatmap, err = app.osuBeatmapByChecksum(ctx, replay.BeatmapMD5); err != nil {
+			// the card is still worth sending without the beatmap
+			slog.WarnContext(ctx, "osu! beatmap lookup", "md5", replay.BeatmapMD5, "err", err)
+		}
+		if beatmap != nil && replay.Mode == osu.ModeStandard {
+			file, err := app.osuBeatmapFile(ctx, beatmap.ID)
+			if err == nil && file != nil {
+				difficulty, err = osu.Calculate(file, replay.Mods)
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "osu! replay pp", "beatmap", beatmap.ID, "err", err)
+			}
+		}
+	}
+	bubble := osuReplayBubble(ctx, replay, beatmap, difficulty)
+	return app.replyFlex(replyToken, tr(ctx, "Replay of %s", defaultValue(replay.Player)), &flex.Carousel{Contents: []*flex.Bubble{bubble}})
+}
+
+// osuReplayBubble is the card of a replay, beatmap and difficulty are nil
+// when they are unknown
+func osuReplayBubble(ctx context.Context, replay *osu.Replay, beatmap *OsuBeatmap, difficulty *osu.Difficulty) *flex.Bubble {
+	mode := osuModes[replay.Mode]
+	title, artist := tr(ctx, "Unknown beatmap"), replay.BeatmapMD5
+	if beatmap != nil {
+		title, artist = beatmap.Beatmapset.Title, beatmap.Beatmapset.Artist
+	}
+	header := flex.VBox(
+		&flex.Text{Text: defaultValue(title), Weight: "bold", Size: "md", Color: "#ffffff", Wrap: true},
+		&flex.Text{Text: defaultValue(artist), Size: "xs", Color: "#ffffff", Wrap: true},
+	)
+	header.BackgroundColor = fmt.Sprintf("#%02x%02x%02x", mode.Color.R, mode.Color.G, mode.Color.B)
+	header.PaddingAll = "13px"
+
+	body := flex.VBox()
+	if beatmap != nil {
+		body.Add(&flex.Text{Text: fmt.Sprintf("[%s] %.2f★", defaultValue(beatmap.Version), beatmap.DifficultyRating), Size: "sm", Weight: "bold", Wrap: true})
+	}
+	combo := strconv.Itoa(replay.MaxCombo) + "x"
+	if beatmap != nil && beatmap.MaxCombo > 0 {
+		combo += "/" + strconv.Itoa(beatmap.MaxCombo) + "x"
+	}
+	body.Add(
+		detailRow(tr(ctx, "Player"), replay.Player),
+		detailRow(tr(ctx, "Mode"), mode.Name),
+		detailRow(tr(ctx, "Mods"), replay.Mods.String()),
+		detailRow("300/100/50/X", fmt.Sprintf("%d/%d/%d/%d", replay.N300, replay.N100, replay.N50, replay.Misses)),
+		detailRow(tr(ctx, "Combo"), combo),
+		detailRow(tr(ctx, "Accuracy"), fmt.Sprintf("%.2f%%", replay.Accuracy()*100)),
+		detailRow(tr(ctx, "Score"), strconv.Itoa(replay.TotalScore)),
+	)
+	if difficulty != nil {
+		pp := difficulty.PP(replay.Score()).Total
+		body.Add(detailRow("PP", strconv.FormatFloat(pp, 'f', 0, 64)+"pp"))
+	}
+	body.Add(&flex.Text{Text: replay.Time.Format("2006-01-02 15:04"), Size: "xs", Color: "#aaaaaa", Align: "end"})
+	body.Spacing = "sm"
+	body.PaddingAll = "13px"
+
+	bubble := &flex.Bubble{Size: "kilo", Header: header, Body: body}
+	if beatmap != nil {
+		if cover := beatmap.Beatmapset.Covers.Card; cover != "" {
+			bubble.Hero = &flex.Image{URL: cover, Size: "full", AspectRatio: "20:7", AspectMode: "cover"}
+		}
+		bubble.Footer = flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Beatmap"), URI: "https://osu.ppy.sh/b/" + strconv.Itoa(beatmap.ID)},
+			Style:  "primary",
+			Color:  "#dc98a4",
+		})
+	}
+	return bubble
+}
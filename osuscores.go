@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/afifmakarim/go-tamako/flex"
+)
+
+// osuScoreCount is how many plays the best and recent cards show
+const osuScoreCount = 5
+
+// osuGrades are the grades as the game shows them with their color, the
+// silver ones are those with hidden or flashlight
+var osuGrades = map[string]struct{ Text, Color string }{
+	"XH": {"SS", "#a8b4c0"},
+	"X":  {"SS", "#d9a400"},
+	"SH": {"S", "#a8b4c0"},
+	"S":  {"S", "#d9a400"},
+	"A":  {"A", "#5a9c2a"},
+	"B":  {"B", "#2a78c2"},
+	"C":  {"C", "#8a4ac2"},
+	"D":  {"D", "#c23c2a"},
+	"F":  {"F", "#777777"},
+}
+
+// osuScores returns the best plays of a user in a game mode, or the plays
+// of the last 24 hours, failed ones included, when kind is recent
+func (app *TamakoBot) osuScores(ctx context.Context, userID int, kind string, mode osuMode) ([]OsuScore, error) {
+	query := "?mode=" + mode.Ruleset + "&limit=" + strconv.Itoa(osuScoreCount)
+	if kind == "recent" {
+		query += "&include_fails=1"
+	}
+	var scores []OsuScore
+	if err := app.osuGet(ctx, "/users/"+strconv.Itoa(userID)+"/scores/"+kind+query, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// osuScoresCommand replies with the best or the recent plays of a player,
+// kind is best or recent
+func (app *TamakoBot) osuScoresCommand(kind string) func(req *CommandRequest, name string) error {
+	return func(req *CommandRequest, name string) error {
+		ctx := req.Context()
+		if _, _, ok := osuModeFlag(req); !ok {
+			return app.replyText(req.ReplyToken, tr(ctx, "Unknown mode, use one of %s", strings.Join(osuModeKeys(), ", ")))
+		}
+		user, mode, err := app.osuUserInMode(ctx, req, name)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return app.replyText(req.ReplyToken, tr(ctx, "osu information not found"))
+		}
+		scores, err := app.osuScores(ctx, user.ID, kind, mode)
+		if err != nil {
+			return err
+		}
+
+		if len(scores) == 0 {
+			if kind == "recent" {
+				return app.replyText(req.ReplyToken, tr(ctx, "%s played no %s in the last 24 hours", user.Username, mode.Name))
+			}
+			return app.replyText(req.ReplyToken, tr(ctx, "%s has no %s plays yet", user.Username, mode.Name))
+		}
+		carousel := &flex.Carousel{}
+		for _, score := range scores {
+			carousel.Add(osuScoreBubble(ctx, mode, score))
+		}
+		altText := tr(ctx, "Best %s plays of %s", mode.Name, user.Username)
+		if kind == "recent" {
+			altText = tr(ctx, "Recent %s plays of %s", mode.Name, user.Username)
+		}
+		return app.replyFlex(req.ReplyToken, altText, carousel)
+	}
+}
+
+// osuModsText shows the mods of a play the way the game does, e.g. +HDDT
+func osuModsText(mods []string) string {
+	if len(mods) == 0 {
+		return "NM"
+	}
+	return "+" + strings.Join(mods, "")
+}
+
+// osuScoreBubble is the card of one play
+func osuScoreBubble(ctx context.Context, mode osuMode, score OsuScore) *flex.Bubble {
+	grade, ok := osuGrades[score.Rank]
+	if !ok {
+		grade.Text, grade.Color = score.Rank, "#777777"
+	}
+	if !score.Passed {
+		grade = osuGrades["F"]
+	}
+	pp := "-"
+	if score.PP > 0 {
+		pp = strconv.FormatFloat(score.PP, 'f', 0, 64) + "pp"
+	}
+
+	header := flex.HBox(
+		flex.VBox(
+			&flex.Text{Text: defaultValue(score.Beatmapset.Title), Weight: "bold", Size: "md", Color: "#ffffff", Wrap: true},
+			&flex.Text{Text: defaultValue(score.Beatmapset.Artist), Size: "xs", Color: "#ffffff", Wrap: true},
+		),
+		&flex.Text{Text: grade.Text, Weight: "bold", Size: "xxl", Color: grade.Color, Flex: flex.Int(0)},
+	)
+	header.BackgroundColor = fmt.Sprintf("#%02x%02x%02x", mode.Color.R, mode.Color.G, mode.Color.B)
+	header.PaddingAll = "13px"
+
+	stats := score.Statistics
+	body := flex.VBox(
+		&flex.Text{Text: fmt.Sprintf("[%s] %.2f★", defaultValue(score.Beatmap.Version), score.Beatmap.DifficultyRating), Size: "sm", Weight: "bold", Wrap: true},
+		detailRow(tr(ctx, "Mods"), osuModsText(score.Mods)),
+		detailRow(tr(ctx, "Accuracy"), fmt.Sprintf("%.2f%%", score.Accuracy*100)),
+		detailRow(tr(ctx, "Combo"), strconv.Itoa(score.MaxCombo)+"x"),
+		detailRow("300/100/50/X", fmt.Sprintf("%d/%d/%d/%d", stats.Count300, stats.Count100, stats.Count50, stats.CountMiss)),
+		detailRow("PP", pp),
+		&flex.Text{Text: score.CreatedAt.Format("2006-01-02 15:04"), Size: "xs", Color: "#aaaaaa", Align: "end"},
+	)
+	body.Spacing = "sm"
+	body.PaddingAll = "13px"
+
+	bubble := &flex.Bubble{
+		Size:   "kilo",
+		Header: header,
+		Hero:   &flex.Image{URL: defaultImage(score.Beatmapset.Covers.Card), Size: "full", AspectRatio: "20:7", AspectMode: "cover"},
+		Body:   body,
+	}
+	if score.Beatmap.URL != "" {
+		bubble.Footer = flex.VBox(&flex.Button{
+			Action: &flex.URIAction{Label: tr(ctx, "Open Beatmap"), URI: score.Beatmap.URL},
+			Style:  "primary",
+			Color:  "#dc98a4",
+		})
+	}
+	return bubble
+}
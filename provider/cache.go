@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a response body kept by a Cache
+type Entry struct {
+	Body    []byte    `json:"body"`
+	Stored  time.Time `json:"stored"`
+	Expires time.Time `json:"expires"`
+}
+
+// Fresh reports whether the entry is still within its TTL
+func (e Entry) Fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+// Cache stores response bodies by key. Expired entries are kept so they can
+// be served when the provider is down.
+type Cache interface {
+	Get(key string) (Entry, bool)
+	Set(key string, entry Entry)
+}
+
+// CacheRule gives the TTL of the responses of a provider whose URL path
+// contains Match. The first matching rule wins.
+type CacheRule struct {
+	Provider string
+	Match    string
+	TTL      time.Duration
+}
+
+// ttl returns how long the response of url may be cached, 0 when it must not be
+func (c *Client) ttl(provider, rawURL string) time.Duration {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	for _, rule := range c.CacheRules {
+		if rule.Provider == provider && strings.Contains(u.Path, rule.Match) {
+			return rule.TTL
+		}
+	}
+	return 0
+}
+
+// cacheKey must not leak the API keys in the query, so it is hashed
+func cacheKey(provider, rawURL string) string {
+	sum := sha256.Sum256([]byte(provider + " " + rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// LRUCache is an in-memory Cache holding at most Size entries
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry Entry
+}
+
+// NewLRUCache function
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry of key and marks it as recently used
+func (c *LRUCache) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruItem).entry, true
+}
+
+// Set stores the entry of key, evicting the least recently used entries
+func (c *LRUCache) Set(key string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+// DiskCache is a Cache keeping one file per entry in a directory, so cached
+// responses survive restarts
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates dir if needed and removes the entries stored more
+// than maxAge ago. A maxAge of 0 keeps everything.
+func NewDiskCache(dir string, maxAge time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &DiskCache{dir: dir}
+	if maxAge > 0 {
+		c.prune(time.Now().Add(-maxAge))
+	}
+	return c, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get reads the entry of key from disk
+func (c *DiskCache) Get(key string) (Entry, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Set writes the entry of key to disk, a failed write only loses the entry
+func (c *DiskCache) Set(key string, entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(c.dir, key+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (c *DiskCache) prune(before time.Time) {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.ModTime().Before(before) {
+			os.Remove(filepath.Join(c.dir, file.Name()))
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	entry := func(body string) Entry { return Entry{Body: []byte(body)} }
+	c.Set("a", entry("a"))
+	c.Set("b", entry("b"))
+	// a is now the most recently used, so b goes first
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a is missing")
+	}
+	c.Set("c", entry("c"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b wasn't evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if got, ok := c.Get(key); !ok || string(got.Body) != key {
+			t.Errorf("Get(%q) = %q, %v", key, got.Body, ok)
+		}
+	}
+	// replacing an entry doesn't evict anything
+	c.Set("a", entry("a2"))
+	if got, _ := c.Get("a"); string(got.Body) != "a2" || c.order.Len() != 2 {
+		t.Errorf("got %q with %d entries", got.Body, c.order.Len())
+	}
+	c.Set("d", entry("d"))
+	if _, ok := c.Get("c"); ok {
+		t.Error("c wasn't evicted")
+	}
+}
+
+func TestEntryFresh(t *testing.T) {
+	now := time.Now()
+	entry := Entry{Stored: now, Expires: now.Add(time.Minute)}
+	if !entry.Fresh(now) || !entry.Fresh(now.Add(59*time.Second)) {
+		t.Error("the entry expired too early")
+	}
+	if entry.Fresh(now.Add(time.Minute)) || entry.Fresh(now.Add(time.Hour)) {
+		t.Error("the entry is fresh after its TTL")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c, err := NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+	c.Set("old", Entry{Body: []byte("old"), Stored: stored, Expires: stored.Add(time.Minute)})
+	c.Set("new", Entry{Body: []byte("new"), Stored: stored, Expires: stored.Add(time.Minute)})
+	if _, ok := c.Get("missing"); ok {
+		t.Error("got an entry that was never set")
+	}
+	os.WriteFile(c.path("corrupt"), []byte("{"), 0644)
+	if _, ok := c.Get("corrupt"); ok {
+		t.Error("got a corrupt entry")
+	}
+	os.Remove(c.path("corrupt"))
+
+	// the entries survive a reopen, the ones not written for a day are pruned
+	dayAgo := time.Now().Add(-25 * time.Hour)
+	if err := os.Chtimes(c.path("old"), dayAgo, dayAgo); err != nil {
+		t.Fatal(err)
+	}
+	c, err = NewDiskCache(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("old"); ok {
+		t.Error("old wasn't pruned")
+	}
+	got, ok := c.Get("new")
+	if !ok || string(got.Body) != "new" || !got.Stored.Equal(stored) || !got.Expires.Equal(stored.Add(time.Minute)) {
+		t.Errorf("Get(new) = %+v, %v after a reopen", got, ok)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("%d files left in the cache, want 1", len(files))
+	}
+}
+
+// flaky serves numbered bodies until it is told to fail with status
+type flaky struct {
+	calls  int32
+	status int32
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&f.calls, 1)
+	if status := atomic.LoadInt32(&f.status); status != 0 {
+		w.WriteHeader(int(status))
+		return
+	}
+	fmt.Fprintf(w, "body %d", n)
+}
+
+func TestGetCached(t *testing.T) {
+	upstream := &flaky{}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	c := testClient()
+	c.MaxRetries = 0
+	c.Cache = NewLRUCache(10)
+	c.CacheRules = []CacheRule{{Provider: "test", Match: "/cached", TTL: time.Hour}}
+	get := func(path string) string {
+		body, err := c.Get(context.Background(), "test", server.URL+path, nil)
+		if err != nil {
+			return err.Error()
+		}
+		return string(body)
+	}
+
+	if a, b := get("/cached"), get("/cached?page=1"); a != "body 1" || b != "body 2" {
+		t.Errorf("got %q and %q, want two fetches", a, b)
+	}
+	if got := get("/cached"); got != "body 1" {
+		t.Errorf("got %q, want the cached body", got)
+	}
+	if a, b := get("/other"), get("/other"); a != "body 3" || b != "body 4" {
+		t.Errorf("got %q and %q, the endpoints without rules aren't cached", a, b)
+	}
+	if got := get("/cached"); got != "body 1" || upstream.calls != 4 {
+		t.Errorf("got %q after %d calls", got, upstream.calls)
+	}
+}
+
+func TestGetServesStale(t *testing.T) {
+	upstream := &flaky{}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	c := testClient()
+	c.MaxRetries = 0
+	c.Cache = NewLRUCache(10)
+	c.CacheRules = []CacheRule{{Provider: "test", Match: "/", TTL: time.Hour}}
+	url := server.URL + "/player"
+	if _, err := c.Get(context.Background(), "test", url, nil); err != nil {
+		t.Fatal(err)
+	}
+	// the entry expires, then the upstream goes down
+	key := cacheKey("test", url)
+	entry, _ := c.Cache.Get(key)
+	entry.Stored = time.Now().Add(-2 * time.Hour)
+	entry.Expires = time.Now().Add(-time.Hour)
+	c.Cache.Set(key, entry)
+	atomic.StoreInt32(&upstream.status, http.StatusServiceUnavailable)
+
+	body, err := c.Get(context.Background(), "test", url, nil)
+	if err != nil || string(body) != "body 1" || upstream.calls != 2 {
+		t.Errorf("got %q, %v after %d calls, want the stale body", body, err, upstream.calls)
+	}
+
+	// unless it is older than MaxStale
+	c.MaxStale = time.Hour
+	if _, err := c.Get(context.Background(), "test", url, nil); !IsKind(err, ErrUnavailable) {
+		t.Errorf("got %v, the entry is too old to be served", err)
+	}
+	c.MaxStale = 0
+
+	// or the upstream says the data is gone
+	atomic.StoreInt32(&upstream.status, http.StatusNotFound)
+	if _, err := c.Get(context.Background(), "test", url, nil); !IsKind(err, ErrNotFound) {
+		t.Errorf("got %v, want not_found", err)
+	}
+
+	// a fresh answer replaces the stale entry
+	atomic.StoreInt32(&upstream.status, 0)
+	if body, err := c.Get(context.Background(), "test", url, nil); err != nil || string(body) != "body 5" {
+		t.Errorf("got %q, %v", body, err)
+	}
+	if entry, _ := c.Cache.Get(key); !entry.Fresh(time.Now()) || string(entry.Body) != "body 5" {
+		t.Errorf("the cache holds %+v", entry)
+	}
+}
@@ -0,0 +1,259 @@
+// Package provider is the HTTP client used to call the upstream APIs of the
+// bot (Steam, OpenDota, osu!, Kitsu, GiantBomb, ...). It never exits the
+// process: every failure is returned as an *Error the handlers can turn
+// into a friendly reply. Requests are logged with the default slog logger,
+// URLs included, so it must redact the API keys they carry.
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default settings of NewClient
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 2
+	DefaultBackoff    = 300 * time.Millisecond
+	DefaultMaxBytes   = 2 << 20
+)
+
+// Client calls upstream providers with timeouts, bounded retries and a
+// maximum response size
+type Client struct {
+	// HTTP is the underlying client, its Timeout is ignored in favour of Timeout
+	HTTP *http.Client
+	// Timeout of a single attempt
+	Timeout time.Duration
+	// MaxRetries is the number of attempts made after the first one
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled on every retry
+	Backoff time.Duration
+	// MaxBytes is the largest body accepted
+	MaxBytes int64
+	// UserAgent is sent when the request has none
+	UserAgent string
+	// Cache keeps the responses matched by CacheRules, nil disables caching
+	Cache Cache
+	// CacheRules give the TTL of each cached endpoint
+	CacheRules []CacheRule
+	// MaxStale is how old an expired entry may be and still be served when
+	// the provider is down, 0 means any age
+	MaxStale time.Duration
+	// Observe, when set, is called after every attempt that reached out to
+	// the provider, err is nil on success
+	Observe func(provider string, elapsed time.Duration, err *Error)
+}
+
+// NewClient returns a client with the default settings
+func NewClient() *Client {
+	return &Client{
+		HTTP:       &http.Client{},
+		Timeout:    DefaultTimeout,
+		MaxRetries: DefaultMaxRetries,
+		Backoff:    DefaultBackoff,
+		MaxBytes:   DefaultMaxBytes,
+		UserAgent:  "go-tamako",
+	}
+}
+
+// Get fetches url and returns its body. header may be nil. Responses of the
+// endpoints matched by CacheRules are served from the cache while fresh, and
+// after they expired when the provider is unavailable.
+func (c *Client) Get(ctx context.Context, provider, url string, header http.Header) ([]byte, error) {
+	ttl := c.ttl(provider, url)
+	if c.Cache == nil || ttl <= 0 {
+		body, err := c.fetch(ctx, provider, http.MethodGet, url, header, "")
+		if err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+	key := cacheKey(provider, url)
+	now := time.Now()
+	entry, cached := c.Cache.Get(key)
+	if cached && entry.Fresh(now) {
+		slog.DebugContext(ctx, "Provider cache hit", "provider", provider, "url", url)
+		return entry.Body, nil
+	}
+	body, err := c.fetch(ctx, provider, http.MethodGet, url, header, "")
+	if err != nil {
+		if cached && retryable(err) && (c.MaxStale <= 0 || now.Sub(entry.Stored) <= c.MaxStale) {
+			slog.WarnContext(ctx, "Serving stale response", "provider", provider, "url", url, "age", now.Sub(entry.Stored))
+			return entry.Body, nil
+		}
+		return nil, err
+	}
+	c.Cache.Set(key, Entry{Body: body, Stored: now, Expires: now.Add(ttl)})
+	return body, nil
+}
+
+// fetch calls the provider, retrying the attempts that may succeed later
+func (c *Client) fetch(ctx context.Context, provider, method, url string, header http.Header, body string) ([]byte, *Error) {
+	var lastErr *Error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt, lastErr); err != nil {
+				return nil, &Error{Provider: provider, Kind: ErrUnavailable, Err: err}
+			}
+		}
+		start := time.Now()
+		answer, err := c.do(ctx, provider, method, url, header, body)
+		elapsed := time.Since(start)
+		if c.Observe != nil {
+			c.Observe(provider, elapsed, err)
+		}
+		if err == nil {
+			slog.DebugContext(ctx, "Provider request", "provider", provider, "method", method, "url", url, "attempt", attempt+1, "elapsed", elapsed)
+			return answer, nil
+		}
+		slog.WarnContext(ctx, "Provider request failed", "provider", provider, "method", method, "url", url, "attempt", attempt+1, "elapsed", elapsed, "err", err)
+		lastErr = err
+		if !retryable(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// GetJSON fetches url and decodes its JSON body into v
+func (c *Client) GetJSON(ctx context.Context, provider, url string, header http.Header, v interface{}) error {
+	body, err := c.Get(ctx, provider, url, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &Error{Provider: provider, Kind: ErrBadResponse, Err: err}
+	}
+	return nil
+}
+
+// PostForm posts form to url and decodes the JSON answer into v. Posts are
+// never cached.
+func (c *Client) PostForm(ctx context.Context, provider, url string, form url.Values, v interface{}) error {
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	body, err := c.fetch(ctx, provider, http.MethodPost, url, header, form.Encode())
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &Error{Provider: provider, Kind: ErrBadResponse, Err: err}
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, provider, method, url string, header http.Header, body string) ([]byte, *Error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, &Error{Provider: provider, Kind: ErrBadResponse, Err: err}
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if req.Header.Get("User-Agent") == "" && c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, &Error{Provider: provider, Kind: ErrUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &Error{Provider: provider, Kind: ErrNotFound, Status: resp.StatusCode}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, &Error{Provider: provider, Kind: ErrRateLimited, Status: resp.StatusCode, Err: retryAfter(resp)}
+	case resp.StatusCode >= 500:
+		return nil, &Error{Provider: provider, Kind: ErrUnavailable, Status: resp.StatusCode}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, &Error{Provider: provider, Kind: ErrBadResponse, Status: resp.StatusCode}
+	}
+
+	limited := io.Reader(resp.Body)
+	if c.MaxBytes > 0 {
+		limited = io.LimitReader(resp.Body, c.MaxBytes+1)
+	}
+	answer, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, &Error{Provider: provider, Kind: ErrUnavailable, Status: resp.StatusCode, Err: err}
+	}
+	if c.MaxBytes > 0 && int64(len(answer)) > c.MaxBytes {
+		return nil, &Error{Provider: provider, Kind: ErrTooLarge, Status: resp.StatusCode}
+	}
+	return answer, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// wait sleeps before a retry, doubling the backoff on every attempt with
+// some jitter, or longer when the provider asked for it with Retry-After
+func (c *Client) wait(ctx context.Context, attempt int, lastErr *Error) error {
+	delay := c.Backoff << uint(attempt-1)
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	}
+	var after *retryAfterError
+	if lastErr != nil && errors.As(lastErr.Err, &after) && after.delay > delay {
+		delay = after.delay
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryable(err *Error) bool {
+	return err.Kind == ErrUnavailable || err.Kind == ErrRateLimited
+}
+
+// longest Retry-After we are willing to honour inside a chat reply
+const maxRetryAfter = 5 * time.Second
+
+type retryAfterError struct {
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return "retry after " + e.delay.String()
+}
+
+func retryAfter(resp *http.Response) error {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return nil
+	}
+	delay := time.Duration(seconds) * time.Second
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return &retryAfterError{delay: delay}
+}
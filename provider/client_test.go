@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient is a client that doesn't wait between retries
+func testClient() *Client {
+	c := NewClient()
+	c.Backoff = time.Millisecond
+	return c
+}
+
+// answers serves the statuses in turn, the last one forever, and counts the
+// requests
+func answers(statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		status := statuses[min(n, len(statuses))-1]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, "body")
+	}))
+	return server, &calls
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    int32
+		kind     ErrorKind // of the error, when the last call fails
+	}{
+		{"ok", []int{200}, 1, -1},
+		{"a 503 is retried", []int{503, 200}, 2, -1},
+		{"retries are bounded", []int{502}, DefaultMaxRetries + 1, ErrUnavailable},
+		{"a 404 isn't retried", []int{404, 200}, 1, ErrNotFound},
+		{"a 400 isn't retried", []int{400, 200}, 1, ErrBadResponse},
+		{"a 403 isn't retried", []int{403, 200}, 1, ErrBadResponse},
+		{"a 302 without location is bad", []int{302}, 1, ErrBadResponse},
+	}
+	for _, tt := range tests {
+		server, calls := answers(tt.statuses...)
+		body, err := testClient().Get(context.Background(), "test", server.URL, nil)
+		server.Close()
+		if *calls != tt.calls {
+			t.Errorf("%s: %d calls, want %d", tt.name, *calls, tt.calls)
+		}
+		if tt.kind < 0 {
+			if err != nil || string(body) != "body" {
+				t.Errorf("%s: got %q, %v", tt.name, body, err)
+			}
+			continue
+		}
+		if !IsKind(err, tt.kind) {
+			t.Errorf("%s: got %v, want a %s error", tt.name, err, tt.kind)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, calls := answers(http.StatusTooManyRequests, http.StatusOK)
+	defer server.Close()
+	start := time.Now()
+	body, err := testClient().Get(context.Background(), "test", server.URL, nil)
+	if err != nil || string(body) != "body" || *calls != 2 {
+		t.Fatalf("got %q, %v after %d calls", body, err, *calls)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, the provider asked for 1s", elapsed)
+	}
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"2", 2 * time.Second},
+		{"3600", maxRetryAfter},
+		{"", 0},
+		{"0", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2026 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{"Retry-After": {tt.header}}}
+		var after *retryAfterError
+		got := time.Duration(0)
+		if errors.As(retryAfter(resp), &after) {
+			got = after.delay
+		}
+		if got != tt.want {
+			t.Errorf("Retry-After %q: got %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitedOnEveryAttempt(t *testing.T) {
+	server, calls := answers(http.StatusTooManyRequests)
+	defer server.Close()
+	c := testClient()
+	c.MaxRetries = 0
+	_, err := c.Get(context.Background(), "test", server.URL, nil)
+	if !IsKind(err, ErrRateLimited) || *calls != 1 {
+		t.Errorf("got %v after %d calls, want rate_limited", err, *calls)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	const limit = 16
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", len(r.URL.Path)-1))
+	}))
+	defer server.Close()
+	c := testClient()
+	c.MaxBytes = limit
+
+	body, err := c.Get(context.Background(), "test", server.URL+"/"+strings.Repeat("a", limit), nil)
+	if err != nil || len(body) != limit {
+		t.Errorf("a body of %d bytes: got %d bytes, %v", limit, len(body), err)
+	}
+	_, err = c.Get(context.Background(), "test", server.URL+"/"+strings.Repeat("a", limit+1), nil)
+	if !IsKind(err, ErrTooLarge) {
+		t.Errorf("a body of %d bytes: got %v, want too_large", limit+1, err)
+	}
+}
+
+func TestCancelledContextStopsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := testClient()
+	c.Backoff = time.Hour
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "test", server.URL, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if n := atomic.LoadInt32(&calls); !IsKind(err, ErrUnavailable) || n != 1 {
+			t.Errorf("got %v after %d calls", err, n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client kept waiting after the context was cancelled")
+	}
+
+	// a context cancelled during the backoff ends the wait
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := c.wait(ctx, 1, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	server, _ := answers(http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+	c := testClient()
+	var observed []string
+	c.Observe = func(provider string, elapsed time.Duration, err *Error) {
+		if err != nil {
+			observed = append(observed, err.Kind.String())
+		} else {
+			observed = append(observed, "ok")
+		}
+	}
+	if _, err := c.Get(context.Background(), "test", server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(observed, " ") != "unavailable ok" {
+		t.Errorf("observed %q", observed)
+	}
+}
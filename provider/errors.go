@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind tells why a call to an upstream provider failed
+type ErrorKind int
+
+// ErrorKind constants
+const (
+	// ErrUnavailable covers network errors, timeouts and 5xx answers
+	ErrUnavailable ErrorKind = iota
+	// ErrNotFound is a 404 answer
+	ErrNotFound
+	// ErrRateLimited is a 429 answer
+	ErrRateLimited
+	// ErrTooLarge is a body bigger than Client.MaxBytes
+	ErrTooLarge
+	// ErrBadResponse is any other status or a body that can't be decoded
+	ErrBadResponse
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrUnavailable:
+		return "unavailable"
+	case ErrNotFound:
+		return "not_found"
+	case ErrRateLimited:
+		return "rate_limited"
+	case ErrTooLarge:
+		return "too_large"
+	case ErrBadResponse:
+		return "bad_response"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// Error is returned by Client for every failed call
+type Error struct {
+	Provider string
+	Kind     ErrorKind
+	Status   int
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := e.Provider + ": " + e.Kind.String()
+	if e.Status != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.Status)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// AsError returns the provider error wrapped in err, if any
+func AsError(err error) (*Error, bool) {
+	var perr *Error
+	if errors.As(err, &perr) {
+		return perr, true
+	}
+	return nil, false
+}
+
+// IsKind reports whether err is a provider error of the given kind
+func IsKind(err error, kind ErrorKind) bool {
+	perr, ok := AsError(err)
+	return ok && perr.Kind == kind
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// tokenMargin is how long before it expires a token is renewed, so it
+// doesn't run out in the middle of a command
+const tokenMargin = time.Minute
+
+// ClientCredentials fetches an OAuth 2 token with the client credentials
+// grant and keeps it until shortly before it expires. It is safe for
+// concurrent use.
+type ClientCredentials struct {
+	Client       *Client
+	Provider     string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Token returns the cached token, or fetches a new one when there is none
+// or it is about to expire
+func (cc *ClientCredentials) Token(ctx context.Context) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.token != "" && time.Now().Before(cc.expires) {
+		return cc.token, nil
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {cc.ClientID},
+		"client_secret": {cc.ClientSecret},
+	}
+	if cc.Scope != "" {
+		form.Set("scope", cc.Scope)
+	}
+	var resp tokenResponse
+	if err := cc.Client.PostForm(ctx, cc.Provider, cc.TokenURL, form, &resp); err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", &Error{Provider: cc.Provider, Kind: ErrBadResponse, Err: errors.New("no access token in the answer")}
+	}
+	if resp.ExpiresIn <= 0 {
+		return "", &Error{Provider: cc.Provider, Kind: ErrBadResponse, Err: errors.New("no expiry in the answer")}
+	}
+	cc.token = resp.AccessToken
+	cc.expires = time.Now().Add(tokenLifetime(time.Duration(resp.ExpiresIn) * time.Second))
+	return cc.token, nil
+}
+
+// tokenLifetime is how long a token valid for expiresIn is kept. A token
+// too short-lived to be renewed tokenMargin early is kept for half of it.
+func tokenLifetime(expiresIn time.Duration) time.Duration {
+	if expiresIn <= 2*tokenMargin {
+		return expiresIn / 2
+	}
+	return expiresIn - tokenMargin
+}
+
+// Invalidate forgets the token, e.g. after the provider rejected it
+func (cc *ClientCredentials) Invalidate() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.token = ""
+}
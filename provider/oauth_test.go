@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer hands out numbered tokens valid for expiresIn seconds and
+// counts the requests
+func tokenServer(t *testing.T, expiresIn int) (*ClientCredentials, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("client_id") != "id" || r.PostFormValue("client_secret") != "secret" || r.PostFormValue("scope") != "public" {
+			t.Errorf("posted %v", r.PostForm)
+		}
+		fmt.Fprintf(w, `{"access_token":"token %d","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	cc := &ClientCredentials{
+		Client:       testClient(),
+		Provider:     "test",
+		TokenURL:     server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scope:        "public",
+	}
+	return cc, &calls
+}
+
+func TestTokenFetchedOnce(t *testing.T) {
+	cc, calls := tokenServer(t, 86400)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := cc.Token(context.Background()); err != nil || token != "token 1" {
+				t.Errorf("got %q, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if *calls != 1 {
+		t.Errorf("fetched the token %d times", *calls)
+	}
+	if left := time.Until(cc.expires); left < 86400*time.Second-tokenMargin-time.Minute || left > 86400*time.Second-tokenMargin {
+		t.Errorf("the token is kept for %s", left)
+	}
+
+	// the token is renewed once it expired, or was invalidated
+	cc.expires = time.Now().Add(-time.Second)
+	if token, err := cc.Token(context.Background()); err != nil || token != "token 2" {
+		t.Errorf("got %q, %v after the expiry", token, err)
+	}
+	cc.Invalidate()
+	if token, err := cc.Token(context.Background()); err != nil || token != "token 3" {
+		t.Errorf("got %q, %v after Invalidate", token, err)
+	}
+	if token, _ := cc.Token(context.Background()); token != "token 3" || *calls != 3 {
+		t.Errorf("got %q after %d calls", token, *calls)
+	}
+}
+
+func TestShortLivedToken(t *testing.T) {
+	cc, calls := tokenServer(t, 1)
+	for i := 0; i < 3; i++ {
+		if token, err := cc.Token(context.Background()); err != nil || token != "token 1" {
+			t.Fatalf("got %q, %v", token, err)
+		}
+	}
+	time.Sleep(600 * time.Millisecond)
+	if token, _ := cc.Token(context.Background()); token != "token 2" || *calls != 2 {
+		t.Errorf("got %q after %d calls, want a new token", token, *calls)
+	}
+}
+
+func TestTokenLifetime(t *testing.T) {
+	tests := []struct {
+		expiresIn, want time.Duration
+	}{
+		{time.Second, 500 * time.Millisecond},
+		{time.Minute, 30 * time.Second},
+		{2 * time.Minute, time.Minute},
+		{3 * time.Minute, 2 * time.Minute},
+		{24 * time.Hour, 24*time.Hour - tokenMargin},
+	}
+	for _, tt := range tests {
+		if got := tokenLifetime(tt.expiresIn); got != tt.want {
+			t.Errorf("tokenLifetime(%s) = %s, want %s", tt.expiresIn, got, tt.want)
+		}
+	}
+}
+
+func TestBadTokenAnswer(t *testing.T) {
+	for _, body := range []string{`{"expires_in":3600}`, `{"access_token":"token"}`, `{"access_token":"token","expires_in":-1}`, `nope`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		cc := &ClientCredentials{Client: testClient(), Provider: "test", TokenURL: server.URL}
+		if token, err := cc.Token(context.Background()); !IsKind(err, ErrBadResponse) {
+			t.Errorf("%s: got %q, %v, want bad_response", body, token, err)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,185 @@
+// Package ratelimit keeps token buckets by key, e.g. one per LINE user, one
+// per group and one per command, and checks several of them at once so a
+// request denied by one bucket doesn't use up the others.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Count requests per Per, with bursts of up to Count requests.
+// The zero Limit allows everything.
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+// Unlimited reports whether the limit allows everything
+func (l Limit) Unlimited() bool {
+	return l.Count <= 0 || l.Per <= 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return strconv.Itoa(l.Count) + "/" + l.Per.String()
+}
+
+// ParseLimit reads limits such as "5/1m", "20/h" or "off"
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("rate limit %q must look like 5/1m", s)
+	}
+	count, err := strconv.Atoi(parts[0])
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: bad count", s)
+	}
+	per, err := time.ParseDuration(parts[1])
+	if err != nil {
+		per, err = time.ParseDuration("1" + parts[1])
+	}
+	if err != nil || per <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: bad period", s)
+	}
+	return Limit{Count: count, Per: per}, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+// Rule applies a limit to the bucket of a key
+type Rule struct {
+	Key   string
+	Limit Limit
+}
+
+// Decision is the outcome of Limiter.Allow
+type Decision struct {
+	Allowed bool
+	// Key of the bucket that denied the request
+	Key string
+	// RetryAfter is when the denying bucket has a token again
+	RetryAfter time.Duration
+	// Notify is true for the first denied request since the bucket last
+	// allowed one, so the caller warns once instead of on every request
+	Notify bool
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	per    time.Duration
+	warned bool
+}
+
+// sweepEvery is how often buckets that refilled completely are forgotten
+const sweepEvery = 10 * time.Minute
+
+// Limiter holds the buckets. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	// Now is the clock, replaceable in tests
+	Now func() time.Time
+}
+
+// New function
+func New() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		Now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of every rule, or from none of them
+// when one of the buckets is empty
+func (l *Limiter) Allow(rules ...Rule) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Now()
+	l.sweep(now)
+
+	buckets := make([]*bucket, len(rules))
+	for i, rule := range rules {
+		if rule.Limit.Unlimited() {
+			continue
+		}
+		b := l.refill(rule, now)
+		if b.tokens < 1 {
+			missing := (1 - b.tokens) * float64(rule.Limit.Per) / float64(rule.Limit.Count)
+			decision := Decision{
+				Key:        rule.Key,
+				RetryAfter: time.Duration(missing).Round(time.Second),
+				Notify:     !b.warned,
+			}
+			if decision.RetryAfter < time.Second {
+				decision.RetryAfter = time.Second
+			}
+			b.warned = true
+			return decision
+		}
+		buckets[i] = b
+	}
+	for _, b := range buckets {
+		if b != nil {
+			b.tokens--
+			b.warned = false
+		}
+	}
+	return Decision{Allowed: true}
+}
+
+func (l *Limiter) refill(rule Rule, now time.Time) *bucket {
+	limit := float64(rule.Limit.Count)
+	b, ok := l.buckets[rule.Key]
+	if !ok {
+		b = &bucket{tokens: limit, last: now, per: rule.Limit.Per}
+		l.buckets[rule.Key] = b
+		return b
+	}
+	b.per = rule.Limit.Per
+	b.tokens += now.Sub(b.last).Seconds() * limit / rule.Limit.Per.Seconds()
+	if b.tokens > limit {
+		b.tokens = limit
+	}
+	b.last = now
+	return b
+}
+
+// sweep forgets the buckets untouched for a whole period, they would be
+// full again anyway
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepEvery {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= b.per {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock returns a limiter whose clock only moves when advance is called
+func fakeClock() (*Limiter, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.Now = func() time.Time { return now }
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+	}{
+		{"5/1m", Limit{Count: 5, Per: time.Minute}},
+		{" 20/h ", Limit{Count: 20, Per: time.Hour}},
+		{"3/30s", Limit{Count: 3, Per: 30 * time.Second}},
+		{"0/1m", Limit{Count: 0, Per: time.Minute}},
+		{"off", Limit{}},
+		{"", Limit{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if err != nil {
+			t.Errorf("ParseLimit(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"5", "x/1m", "-1/1m", "5/soon", "5/0s", "5/-1m"} {
+		if _, err := ParseLimit(in); err == nil {
+			t.Errorf("ParseLimit(%q): want an error", in)
+		}
+	}
+}
+
+func TestLimitText(t *testing.T) {
+	for _, in := range []string{"5/1m0s", "off"} {
+		var l Limit
+		if err := l.UnmarshalText([]byte(in)); err != nil {
+			t.Fatal(err)
+		}
+		out, _ := l.MarshalText()
+		if string(out) != in {
+			t.Errorf("%q came back as %q", in, out)
+		}
+	}
+	if !(Limit{Count: 0, Per: time.Minute}).Unlimited() || (Limit{Count: 1, Per: time.Minute}).Unlimited() {
+		t.Error("only a zero count or period should be unlimited")
+	}
+}
+
+func TestAllowBurstAndRefill(t *testing.T) {
+	l, advance := fakeClock()
+	rule := Rule{Key: "user:U1", Limit: Limit{Count: 3, Per: 3 * time.Second}}
+	for i := 0; i < 3; i++ {
+		if d := l.Allow(rule); !d.Allowed {
+			t.Fatalf("request %d of the burst was denied", i+1)
+		}
+	}
+	d := l.Allow(rule)
+	if d.Allowed || d.Key != "user:U1" || d.RetryAfter != time.Second || !d.Notify {
+		t.Fatalf("got %+v, want a notified denial retrying after 1s", d)
+	}
+	if d := l.Allow(rule); d.Allowed || d.Notify {
+		t.Fatalf("got %+v, want a silent denial", d)
+	}
+
+	advance(time.Second)
+	if d := l.Allow(rule); !d.Allowed {
+		t.Fatal("a token should be back after a second")
+	}
+	if d := l.Allow(rule); d.Allowed || !d.Notify {
+		t.Fatalf("got %+v, want a notified denial after an allowed request", d)
+	}
+
+	advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if d := l.Allow(rule); !d.Allowed {
+			t.Fatalf("the bucket should refill up to its limit only, request %d denied", i+1)
+		}
+	}
+	if d := l.Allow(rule); d.Allowed {
+		t.Fatal("the bucket refilled over its limit")
+	}
+}
+
+func TestAllowRetryAfter(t *testing.T) {
+	l, advance := fakeClock()
+	rule := Rule{Key: "cmd:osu", Limit: Limit{Count: 2, Per: time.Minute}}
+	l.Allow(rule)
+	l.Allow(rule)
+	if d := l.Allow(rule); d.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 30s", d.RetryAfter)
+	}
+	advance(20 * time.Second)
+	if d := l.Allow(rule); d.RetryAfter != 10*time.Second {
+		t.Errorf("RetryAfter = %s, want 10s", d.RetryAfter)
+	}
+}
+
+func TestAllowIsAllOrNothing(t *testing.T) {
+	l, _ := fakeClock()
+	user := Rule{Key: "user:U1", Limit: Limit{Count: 5, Per: time.Minute}}
+	group := Rule{Key: "group:G1", Limit: Limit{Count: 1, Per: time.Minute}}
+	if d := l.Allow(user, group); !d.Allowed {
+		t.Fatal("first request denied")
+	}
+	for i := 0; i < 3; i++ {
+		if d := l.Allow(user, group); d.Allowed || d.Key != "group:G1" {
+			t.Fatalf("got %+v, want a denial by the group", d)
+		}
+	}
+	// the denied requests didn't take the tokens of the user
+	for i := 0; i < 4; i++ {
+		if d := l.Allow(user); !d.Allowed {
+			t.Fatalf("user request %d denied, the denials used up its bucket", i+1)
+		}
+	}
+	if d := l.Allow(user); d.Allowed {
+		t.Fatal("the user bucket should be empty")
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	l, _ := fakeClock()
+	off := Rule{Key: "user:U1", Limit: Limit{}}
+	for i := 0; i < 1000; i++ {
+		if d := l.Allow(off); !d.Allowed {
+			t.Fatal("an unlimited rule denied a request")
+		}
+	}
+	if len(l.buckets) != 0 {
+		t.Errorf("unlimited rules should keep no bucket, got %d", len(l.buckets))
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, advance := fakeClock()
+	short := Rule{Key: "short", Limit: Limit{Count: 1, Per: time.Minute}}
+	long := Rule{Key: "long", Limit: Limit{Count: 1, Per: 24 * time.Hour}}
+	l.Allow(short, long)
+	advance(sweepEvery)
+	l.Allow()
+	if _, ok := l.buckets["short"]; ok {
+		t.Error("the refilled bucket wasn't forgotten")
+	}
+	if _, ok := l.buckets["long"]; !ok {
+		t.Error("the bucket still refilling was forgotten")
+	}
+	if d := l.Allow(long); d.Allowed {
+		t.Error("the sweep gave the long bucket its token back")
+	}
+}
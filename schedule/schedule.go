@@ -0,0 +1,151 @@
+// Package schedule reads the recurring schedules of subscriptions, such as
+// "daily 09:00", "weekly mon 20:30" or "hourly :15", and finds their next run.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period of a schedule
+type Period int
+
+// Periods
+const (
+	Hourly Period = iota
+	Daily
+	Weekly
+)
+
+// Words are the first words of the schedules Parse understands
+var Words = []string{"hourly", "daily", "weekly"}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule is a recurring time of the day, week or hour
+type Schedule struct {
+	Period  Period
+	Weekday time.Weekday
+	Hour    int
+	Minute  int
+}
+
+// Parse reads "hourly [:MM]", "daily HH:MM" or "weekly <day> HH:MM"
+func Parse(s string) (Schedule, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return Schedule{}, fmt.Errorf("empty schedule")
+	}
+	var sched Schedule
+	var err error
+	switch fields[0] {
+	case "hourly":
+		sched.Period = Hourly
+		switch len(fields) {
+		case 1:
+		case 2:
+			sched.Minute, err = parseMinute(strings.TrimPrefix(fields[1], ":"))
+		default:
+			err = fmt.Errorf("use hourly :MM")
+		}
+	case "daily":
+		sched.Period = Daily
+		if len(fields) != 2 {
+			return Schedule{}, fmt.Errorf("use daily HH:MM")
+		}
+		sched.Hour, sched.Minute, err = parseClock(fields[1])
+	case "weekly":
+		sched.Period = Weekly
+		if len(fields) != 3 {
+			return Schedule{}, fmt.Errorf("use weekly <day> HH:MM")
+		}
+		day, ok := weekdays[truncate(fields[1], 3)]
+		if !ok {
+			return Schedule{}, fmt.Errorf("unknown day %q", fields[1])
+		}
+		sched.Weekday = day
+		sched.Hour, sched.Minute, err = parseClock(fields[2])
+	default:
+		return Schedule{}, fmt.Errorf("a schedule starts with hourly, daily or weekly")
+	}
+	if err != nil {
+		return Schedule{}, err
+	}
+	return sched, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func parseClock(s string) (hour, minute int, err error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("time %q must look like 09:00", s)
+	}
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("bad hour in %q", s)
+	}
+	minute, err = parseMinute(parts[1])
+	return hour, minute, err
+}
+
+func parseMinute(s string) (int, error) {
+	minute, err := strconv.Atoi(s)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("bad minute %q", s)
+	}
+	return minute, nil
+}
+
+// String formats the schedule the way Parse reads it
+func (s Schedule) String() string {
+	switch s.Period {
+	case Hourly:
+		return fmt.Sprintf("hourly :%02d", s.Minute)
+	case Weekly:
+		return fmt.Sprintf("weekly %s %02d:%02d", strings.ToLower(s.Weekday.String()[:3]), s.Hour, s.Minute)
+	default:
+		return fmt.Sprintf("daily %02d:%02d", s.Hour, s.Minute)
+	}
+}
+
+// Next returns the first run strictly after t, in the location of t
+func (s Schedule) Next(t time.Time) time.Time {
+	switch s.Period {
+	case Hourly:
+		next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), s.Minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = next.Add(time.Hour)
+		}
+		return next
+	case Weekly:
+		next := time.Date(t.Year(), t.Month(), t.Day(), s.Hour, s.Minute, 0, 0, t.Location())
+		days := (int(s.Weekday) - int(t.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	default:
+		next := time.Date(t.Year(), t.Month(), t.Day(), s.Hour, s.Minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
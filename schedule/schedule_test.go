@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Schedule
+		str  string
+	}{
+		{"hourly", Schedule{Period: Hourly}, "hourly :00"},
+		{"hourly :15", Schedule{Period: Hourly, Minute: 15}, "hourly :15"},
+		{"HOURLY 45", Schedule{Period: Hourly, Minute: 45}, "hourly :45"},
+		{"daily 09:00", Schedule{Period: Daily, Hour: 9}, "daily 09:00"},
+		{"  daily   9:05 ", Schedule{Period: Daily, Hour: 9, Minute: 5}, "daily 09:05"},
+		{"daily 23:59", Schedule{Period: Daily, Hour: 23, Minute: 59}, "daily 23:59"},
+		{"weekly mon 20:30", Schedule{Period: Weekly, Weekday: time.Monday, Hour: 20, Minute: 30}, "weekly mon 20:30"},
+		{"Weekly Saturday 00:00", Schedule{Period: Weekly, Weekday: time.Saturday}, "weekly sat 00:00"},
+		{"weekly sun 7:00", Schedule{Period: Weekly, Weekday: time.Sunday, Hour: 7}, "weekly sun 07:00"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got.String(), tt.str)
+		}
+		if again, err := Parse(got.String()); err != nil || again != got {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"monthly 1 09:00",
+		"hourly :60",
+		"hourly :x",
+		"hourly :15 extra",
+		"daily",
+		"daily 9",
+		"daily 24:00",
+		"daily -1:00",
+		"daily 09:60",
+		"daily 09:00 mon",
+		"weekly 09:00",
+		"weekly someday 09:00",
+		"weekly mon 9am",
+	} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	// a Wednesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, wib)
+	}
+	tests := []struct {
+		sched string
+		from  time.Time
+		want  time.Time
+	}{
+		{"hourly :15", at(14, 10, 0), at(14, 10, 15)},
+		{"hourly :15", at(14, 10, 15), at(14, 11, 15)},
+		{"hourly :15", at(14, 10, 30), at(14, 11, 15)},
+		{"hourly :15", at(14, 23, 30), at(15, 0, 15)},
+		{"daily 09:00", at(14, 8, 59), at(14, 9, 0)},
+		{"daily 09:00", at(14, 9, 0), at(15, 9, 0)},
+		{"daily 09:00", at(31, 10, 0), time.Date(2026, time.November, 1, 9, 0, 0, 0, wib)},
+		{"weekly wed 20:30", at(14, 20, 0), at(14, 20, 30)},
+		{"weekly wed 20:30", at(14, 20, 30), at(21, 20, 30)},
+		{"weekly mon 20:30", at(14, 12, 0), at(19, 20, 30)},
+		{"weekly fri 00:00", at(14, 12, 0), at(16, 0, 0)},
+		{"weekly tue 08:00", at(14, 12, 0), at(20, 8, 0)},
+	}
+	for _, tt := range tests {
+		sched, err := Parse(tt.sched)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := sched.Next(tt.from)
+		if !got.Equal(tt.want) || got.Location() != wib {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.sched, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestNextSecondsAfterTheRun(t *testing.T) {
+	sched := Schedule{Period: Daily, Hour: 9}
+	from := time.Date(2026, time.October, 14, 9, 0, 1, 0, time.UTC)
+	if got, want := sched.Next(from), time.Date(2026, time.October, 15, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
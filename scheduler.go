@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afifmakarim/go-tamako/schedule"
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// schedulerTick is how often the subscriptions are checked
+const schedulerTick = 30 * time.Second
+
+// missedRunGrace is how late a run may still be posted, e.g. after a
+// restart. Older runs are skipped.
+const missedRunGrace = time.Hour
+
+// loop calls a function in the background at a fixed interval until stopped
+type loop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startLoop calls fn every interval, with the time of the tick
+func startLoop(interval time.Duration, fn func(ctx context.Context, now time.Time)) *loop {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &loop{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				fn(ctx, now)
+			}
+		}
+	}()
+	return l
+}
+
+// stop waits for the running call to finish
+func (l *loop) stop() {
+	l.cancel()
+	<-l.done
+}
+
+// runDueSubscriptions posts the subscriptions whose run came
+func (app *TamakoBot) runDueSubscriptions(ctx context.Context, now time.Time) {
+	subs, err := app.store.Subscriptions("")
+	if err != nil {
+		slog.ErrorContext(ctx, "Subscriptions", "err", err)
+		return
+	}
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		ctx := withLogAttrs(ctx, "subscription", sub.ID, "chat", sub.ChatID)
+		sched, err := schedule.Parse(sub.Schedule)
+		if err != nil {
+			slog.ErrorContext(ctx, "Bad subscription schedule", "err", err)
+			continue
+		}
+		last := sub.LastRun
+		if last.IsZero() {
+			last = sub.Created
+		}
+		next := sched.Next(last.In(app.location))
+		if now.Before(next) {
+			continue
+		}
+		if now.Sub(next) <= missedRunGrace {
+			if err := app.runSubscription(withLogAttrs(ctx, "event_id", newCorrelationID()), sub); err != nil {
+				slog.ErrorContext(ctx, "Subscription failed", "err", err)
+			}
+		}
+		if err := app.store.MarkSubscriptionRun(sub.ID, now); err != nil {
+			slog.ErrorContext(ctx, "Marking subscription run", "err", err)
+		}
+	}
+}
+
+// runSubscription runs the command of a subscription and pushes its output
+// to the chat
+func (app *TamakoBot) runSubscription(ctx context.Context, sub *store.Subscription) error {
+	cmd, ok := app.commands.Lookup(sub.Command)
+	if !ok {
+		return fmt.Errorf("unknown command %s", sub.Command)
+	}
+	chat, err := app.store.Chat(sub.ChatID)
+	if err != nil {
+		return err
+	}
+	if chat.IsDisabled(cmd.Name) {
+		return nil
+	}
+	if missing := app.config.MissingProviders(cmd); len(missing) > 0 {
+		return fmt.Errorf("%s is not configured", strings.Join(missing, ", "))
+	}
+	args, err := parseArgs(sub.Args, cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	replyToken := fmt.Sprintf("subscription-%d-%d", sub.ID, time.Now().UnixNano())
+	app.replies.pushTo(replyToken, sub.ChatID)
+	defer app.replies.forget(replyToken)
+
+	ctx, cancel := context.WithTimeout(ctx, eventTimeout)
+	defer cancel()
+	ctx = withLanguage(ctx, app.chatLanguage(sub.ChatID))
+	source := &linebot.EventSource{Type: linebot.EventSourceType(sub.ChatType), UserID: sub.CreatedBy}
+	switch source.Type {
+	case linebot.EventSourceTypeGroup:
+		source.GroupID = sub.ChatID
+	case linebot.EventSourceTypeRoom:
+		source.RoomID = sub.ChatID
+	}
+	return app.runCommand(cmd, &CommandRequest{
+		ctx:        ctx,
+		Command:    cmd,
+		Prefix:     app.chatPrefix(chat),
+		Chat:       chat,
+		Args:       args.Positional,
+		Flags:      args.Flags,
+		Text:       strings.Join(args.Positional, " "),
+		ReplyToken: replyToken,
+		Source:     source,
+	})
+}
+
+// splitSchedule splits `<keyword> [<arguments>...] <schedule>` at the last
+// word starting a schedule
+func splitSchedule(args []string) (keyword string, cmdArgs []string, sched string, ok bool) {
+	for i := len(args) - 1; i >= 1; i-- {
+		for _, word := range schedule.Words {
+			if strings.ToLower(args[i]) == word {
+				return args[0], args[1:i], strings.Join(args[i:], " "), true
+			}
+		}
+	}
+	return "", nil, "", false
+}
+
+func (app *TamakoBot) subscribeCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	keyword, cmdArgs, rawSchedule, ok := splitSchedule(req.Args)
+	if !ok {
+		return app.replyText(req.ReplyToken, tr(ctx, "Missing schedule")+"\n"+tr(ctx, "Usage : %s", req.Command.UsageLine(req.Prefix)))
+	}
+	sched, err := schedule.Parse(rawSchedule)
+	if err != nil {
+		return app.replyText(req.ReplyToken, tr(ctx, "Bad schedule, %s", err))
+	}
+	cmd, ok := app.commands.Lookup(strings.TrimPrefix(keyword, req.Prefix))
+	if !ok {
+		return app.replyText(req.ReplyToken, tr(ctx, "Unknown keyword %s", keyword))
+	}
+	if !cmd.Schedulable {
+		return app.replyText(req.ReplyToken, tr(ctx, "%s can't be scheduled", req.Prefix+cmd.Name))
+	}
+	if _, err := parseArgs(cmdArgs, cmd.Args); err != nil {
+		return app.replyText(req.ReplyToken, argErrorText(ctx, err)+"\n"+tr(ctx, "Usage : %s", cmd.UsageLine(req.Prefix)))
+	}
+
+	sub := &store.Subscription{
+		ChatID:    sourceID(req.Source),
+		ChatType:  string(req.Source.Type),
+		Command:   cmd.Name,
+		Args:      cmdArgs,
+		Schedule:  sched.String(),
+		CreatedBy: req.Source.UserID,
+		Created:   time.Now(),
+	}
+	if err := app.store.AddSubscription(sub); err != nil {
+		return err
+	}
+	next := sched.Next(time.Now().In(app.location))
+	return app.replyText(req.ReplyToken, tr(ctx, "Subscribed #%d, %s %s\nNext post : %s",
+		sub.ID, describeSubscription(req.Prefix, sub), sub.Schedule, next.Format("Mon 2 Jan 15:04")))
+}
+
+func (app *TamakoBot) subscriptionsCommand(req *CommandRequest) error {
+	subs, err := app.store.Subscriptions(sourceID(req.Source))
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "No subscriptions in this chat"))
+	}
+	lines := []string{tr(req.Context(), "Subscriptions :")}
+	for _, sub := range subs {
+		lines = append(lines, fmt.Sprintf("#%d %s %s", sub.ID, describeSubscription(req.Prefix, sub), sub.Schedule))
+	}
+	lines = append(lines, "", tr(req.Context(), "Stop one with %s", req.Prefix+"unsubscribe <number>"))
+	return app.replyText(req.ReplyToken, strings.Join(lines, "\n"))
+}
+
+func (app *TamakoBot) unsubscribeCommand(req *CommandRequest) error {
+	id, err := strconv.ParseUint(strings.TrimPrefix(req.Args[0], "#"), 10, 64)
+	if err != nil {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "Usage : %s", req.Command.UsageLine(req.Prefix)))
+	}
+	removed, err := app.store.RemoveSubscription(sourceID(req.Source), id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return app.replyText(req.ReplyToken, tr(req.Context(), "No subscription #%d in this chat", id))
+	}
+	return app.replyText(req.ReplyToken, tr(req.Context(), "Unsubscribed #%d", id))
+}
+
+// describeSubscription is the command line a subscription runs
+func describeSubscription(prefix string, sub *store.Subscription) string {
+	return strings.TrimSpace(prefix + sub.Command + " " + strings.Join(sub.Args, " "))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/afifmakarim/go-tamako/i18n"
+	"github.com/afifmakarim/go-tamako/store"
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+// maxPrefixLength is the longest prefix a chat can choose
+const maxPrefixLength = 5
+
+// errSettingRejected carries a reply for a refused settings change
+type errSettingRejected struct {
+	reply string
+}
+
+func (e *errSettingRejected) Error() string {
+	return e.reply
+}
+
+func (app *TamakoBot) configCommand(req *CommandRequest) error {
+	ctx := req.Context()
+	chatID := sourceID(req.Source)
+	if len(req.Args) == 0 {
+		return app.replyText(req.ReplyToken, app.describeChat(ctx, req.Chat))
+	}
+	if !app.isChatAdmin(req.Chat, req.Source) {
+		return app.replyText(req.ReplyToken, tr(ctx, "Only the admins of this chat can change its settings"))
+	}
+
+	action, args := strings.ToLower(req.Args[0]), req.Args[1:]
+	if action == "reset" {
+		if err := app.store.ResetChat(chatID); err != nil {
+			return err
+		}
+		return app.replyText(req.ReplyToken, tr(ctx, "Settings are back to the defaults, prefix is %s", app.config.Prefix))
+	}
+
+	var reply string
+	_, err := app.store.UpdateChat(chatID, func(chat *store.Chat) error {
+		switch action {
+		case "prefix":
+			if len(args) != 1 {
+				return &errSettingRejected{tr(ctx, "Usage : %s", req.Prefix+"config prefix <prefix>")}
+			}
+			prefix := args[0]
+			if utf8.RuneCountInString(prefix) > maxPrefixLength || strings.IndexFunc(prefix, unicode.IsSpace) >= 0 {
+				return &errSettingRejected{tr(ctx, "The prefix must be at most %d characters without spaces", maxPrefixLength)}
+			}
+			chat.Prefix = prefix
+			reply = tr(ctx, "Prefix is now %s", prefix)
+		case "disable", "enable":
+			if len(args) == 0 {
+				return &errSettingRejected{tr(ctx, "Usage : %s", req.Prefix+"config "+action+" <keyword>...")}
+			}
+			var names []string
+			for _, arg := range args {
+				cmd, ok := app.commands.Lookup(strings.TrimPrefix(arg, req.Prefix))
+				if !ok {
+					return &errSettingRejected{tr(ctx, "Unknown keyword %s", arg)}
+				}
+				if cmd.Name == req.Command.Name {
+					return &errSettingRejected{tr(ctx, "%s can't be turned off", req.Prefix+cmd.Name)}
+				}
+				names = append(names, cmd.Name)
+			}
+			for _, name := range names {
+				chat.Disabled = removeString(chat.Disabled, name)
+				if action == "disable" {
+					chat.Disabled = append(chat.Disabled, name)
+				}
+			}
+			if action == "disable" {
+				reply = tr(ctx, "Turned off %s", strings.Join(names, ", "))
+			} else {
+				reply = tr(ctx, "Turned on %s", strings.Join(names, ", "))
+			}
+		case "admin":
+			if len(args) != 2 || (args[0] != "add" && args[0] != "remove") {
+				return &errSettingRejected{tr(ctx, "Usage : %s", req.Prefix+"config admin <add|remove> <me|user ID>")}
+			}
+			userID := args[1]
+			if strings.ToLower(userID) == "me" {
+				userID = req.Source.UserID
+			}
+			if !isLineUserID(userID) {
+				return &errSettingRejected{tr(ctx, "%s is not a LINE user ID", userID)}
+			}
+			chat.Admins = removeString(chat.Admins, userID)
+			if args[0] == "add" {
+				chat.Admins = append(chat.Admins, userID)
+				reply = tr(ctx, "%s is now an admin", app.displayName(userID))
+			} else {
+				reply = tr(ctx, "%s is no longer an admin", app.displayName(userID))
+			}
+		default:
+			return &errSettingRejected{tr(ctx, "Usage : %s", req.Command.UsageLine(req.Prefix))}
+		}
+		return nil
+	})
+	var rejected *errSettingRejected
+	if errors.As(err, &rejected) {
+		return app.replyText(req.ReplyToken, rejected.reply)
+	}
+	if err != nil {
+		return err
+	}
+	return app.replyText(req.ReplyToken, reply)
+}
+
+// isChatAdmin reports whether the sender of a message may run the admin
+// commands of its chat: the admins of the chat, the owners of the bot, who
+// add the first admins, and the user of a 1:1 chat
+func (app *TamakoBot) isChatAdmin(chat *store.Chat, source *linebot.EventSource) bool {
+	if source == nil {
+		return false
+	}
+	return source.Type == linebot.EventSourceTypeUser || app.config.IsOwner(source.UserID) || chat.IsAdmin(source.UserID)
+}
+
+// describeChat lists the settings of a chat
+func (app *TamakoBot) describeChat(ctx context.Context, chat *store.Chat) string {
+	lines := []string{tr(ctx, "Prefix : %s", app.chatPrefix(chat))}
+	if len(chat.Disabled) > 0 {
+		lines = append(lines, tr(ctx, "Turned off : %s", strings.Join(chat.Disabled, ", ")))
+	} else {
+		lines = append(lines, tr(ctx, "Turned off : %s", "-"))
+	}
+	if chat.Lang != "" {
+		lines = append(lines, tr(ctx, "Language : %s", i18n.Name(chat.Lang)))
+	} else {
+		lines = append(lines, tr(ctx, "Language : %s", tr(ctx, "each user's own")))
+	}
+	if len(chat.Admins) > 0 {
+		names := make([]string, len(chat.Admins))
+		for i, userID := range chat.Admins {
+			names[i] = app.displayName(userID)
+		}
+		lines = append(lines, tr(ctx, "Admins : %s", strings.Join(names, ", ")))
+	} else {
+		lines = append(lines, tr(ctx, "Admins : %s", tr(ctx, "the owners of the bot")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displayName returns the LINE name of a user, or the ID when the bot can't
+// see the profile
+func (app *TamakoBot) displayName(userID string) string {
+	profile, err := app.bot.GetProfile(userID)
+	if err != nil || profile.DisplayName == "" {
+		return userID
+	}
+	return profile.DisplayName
+}
+
+// isLineUserID reports whether id looks like a LINE user ID, a U followed by
+// 32 hex digits
+func isLineUserID(id string) bool {
+	if len(id) != 33 || id[0] != 'U' {
+		return false
+	}
+	for _, r := range id[1:] {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func removeString(list []string, value string) []string {
+	kept := list[:0]
+	for _, item := range list {
+		if item != value {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/linebot"
+)
+
+func TestConfigAdmins(t *testing.T) {
+	const (
+		owner  = "U00000000000000000000000000000001"
+		member = "U00000000000000000000000000000002"
+		other  = "U00000000000000000000000000000003"
+	)
+	app, messenger := newTestBot(t, http.NotFoundHandler(), func(cfg *Config) {
+		cfg.Owners = []string{owner}
+	})
+	from := func(userID string) *linebot.EventSource {
+		return &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "G1", UserID: userID}
+	}
+	steps := []struct {
+		userID string
+		text   string
+		want   string
+	}{
+		// nobody takes over a group without admins
+		{member, "!config admin add me", "Only the admins of this chat can change its settings"},
+		{member, "!bye", "Only the admins of this chat can use !bye"},
+		{member, "!config", "Prefix : !\nTurned off : -\nLanguage : each user's own\nAdmins : the owners of the bot"},
+		// the owner adds the first admin
+		{owner, "!config admin add " + member, member + " is now an admin"},
+		{member, "!config prefix ?", "Prefix is now ?"},
+		{other, "?config disable write", "Only the admins of this chat can change its settings"},
+		// a reset keeps the admins
+		{member, "?config reset", "Settings are back to the defaults, prefix is !"},
+		{member, "!config disable write", "Turned off write"},
+		{other, "!config reset", "Only the admins of this chat can change its settings"},
+		{member, "!config admin remove me", member + " is no longer an admin"},
+		{member, "!config enable write", "Only the admins of this chat can change its settings"},
+	}
+	for _, step := range steps {
+		replies := sendTextFrom(t, app, messenger, from(step.userID), step.text)
+		if len(replies) != 1 || replies[0] != step.want {
+			t.Errorf("%s %q: got %q, want %q", step.userID, step.text, replies, step.want)
+		}
+	}
+}
+
+func TestConfigInPrivateChat(t *testing.T) {
+	app, messenger := newTestBot(t, http.NotFoundHandler(), nil)
+	source := &linebot.EventSource{Type: linebot.EventSourceTypeUser, UserID: "U1"}
+	replies := sendTextFrom(t, app, messenger, source, "!config prefix .")
+	if len(replies) != 1 || !strings.HasPrefix(replies[0], "Prefix is now .") {
+		t.Errorf("got %q, the user of a 1:1 chat should manage it", replies)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const simulatorHelp = `Type a chat message to send it to the bot, or one of:
+  /postback <data>          send a postback event
+  /follow                   send a follow event
+  /join                     send a join event from the current group or room
+  /source <user|group|room> change where the next events come from
+  /quit                     leave the simulator`
+
+// simulatedReply is a message call captured by the fake LINE API
+type simulatedReply struct {
+	Endpoint   string
+	ReplyToken string
+	To         string
+	Messages   []map[string]interface{}
+}
+
+// fakeLineAPI answers the LINE Messaging API calls made by the bot
+type fakeLineAPI struct {
+	mu      sync.Mutex
+	replies []simulatedReply
+}
+
+func (api *fakeLineAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/v2/bot/message/reply" || r.URL.Path == "/v2/bot/message/push":
+		var call struct {
+			ReplyToken string                   `json:"replyToken"`
+			To         string                   `json:"to"`
+			Messages   []map[string]interface{} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"message":%q}`, err.Error())
+			return
+		}
+		api.mu.Lock()
+		api.replies = append(api.replies, simulatedReply{
+			Endpoint:   strings.TrimPrefix(r.URL.Path, "/v2/bot/message/"),
+			ReplyToken: call.ReplyToken,
+			To:         call.To,
+			Messages:   call.Messages,
+		})
+		api.mu.Unlock()
+		io.WriteString(w, "{}")
+	case strings.HasPrefix(r.URL.Path, "/v2/bot/profile/"):
+		userID := strings.TrimPrefix(r.URL.Path, "/v2/bot/profile/")
+		json.NewEncoder(w).Encode(map[string]string{
+			"userId":      userID,
+			"displayName": "Simulator",
+		})
+	case strings.HasSuffix(r.URL.Path, "/leave"):
+		io.WriteString(w, "{}")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"message":"Not found"}`)
+	}
+}
+
+// take returns and forgets the captured replies
+func (api *fakeLineAPI) take() []simulatedReply {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	replies := api.replies
+	api.replies = nil
+	return replies
+}
+
+// runSimulator reads chat lines from in, delivers them to the bot as signed
+// webhook events and prints the replies to out
+func runSimulator(in io.Reader, out io.Writer) error {
+	api := &fakeLineAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.EndpointBase = server.URL
+	if cfg.ChannelSecret == "" {
+		cfg.ChannelSecret = "simulator"
+	}
+	if cfg.ChannelToken == "" {
+		cfg.ChannelToken = "simulator"
+	}
+	// the simulator must not touch the data of the real bot
+	dataDir, err := ioutil.TempDir("", "tamako-simulator")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataDir)
+	cfg.DBPath = filepath.Join(dataDir, "tamako.db")
+	// only problems are logged between the replies, unless asked otherwise
+	if os.Getenv("LOG_LEVEL") == "" {
+		cfg.LogLevel = "warn"
+	}
+	logger, err := newLogger(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	app, err := NewTamakoBot(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close(context.Background())
+
+	source := map[string]string{"type": "user", "userId": "Usimulator"}
+	fmt.Fprintln(out, simulatorHelp)
+	scanner := bufio.NewScanner(in)
+	for seq := 1; ; seq++ {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		replyToken := "simulated-" + strconv.Itoa(seq)
+		event := map[string]interface{}{
+			"replyToken": replyToken,
+			"source":     source,
+			"timestamp":  time.Now().UnixNano() / int64(time.Millisecond),
+			"mode":       "active",
+		}
+		command, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			command, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		switch command {
+		case "/quit":
+			return nil
+		case "/source":
+			switch arg {
+			case "user":
+				source = map[string]string{"type": "user", "userId": "Usimulator"}
+			case "group":
+				source = map[string]string{"type": "group", "groupId": "Gsimulator", "userId": "Usimulator"}
+			case "room":
+				source = map[string]string{"type": "room", "roomId": "Rsimulator", "userId": "Usimulator"}
+			default:
+				fmt.Fprintln(out, "source must be user, group or room")
+			}
+			continue
+		case "/postback":
+			event["type"] = "postback"
+			event["postback"] = map[string]string{"data": arg}
+		case "/follow":
+			event["type"] = "follow"
+		case "/join":
+			event["type"] = "join"
+		default:
+			if strings.HasPrefix(command, "/") {
+				fmt.Fprintln(out, simulatorHelp)
+				continue
+			}
+			event["type"] = "message"
+			event["message"] = map[string]string{
+				"id":   strconv.Itoa(seq),
+				"type": "text",
+				"text": line,
+			}
+		}
+
+		status, err := deliverEvent(app, cfg.ChannelSecret, event)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			fmt.Fprintf(out, "callback answered %d\n", status)
+		}
+		// events are handled in the background, wait for the replies
+		app.events.Wait()
+		for _, reply := range api.take() {
+			if reply.Endpoint == "push" {
+				fmt.Fprintf(out, "(pushed to %s)\n", reply.To)
+			}
+			for _, message := range reply.Messages {
+				fmt.Fprintln(out, renderMessage(message))
+			}
+		}
+	}
+}
+
+// deliverEvent signs the event with the channel secret the same way LINE
+// does and passes it to the bot callback
+func deliverEvent(app *TamakoBot, channelSecret string, event map[string]interface{}) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"destination": "Usimulatorbot",
+		"events":      []interface{}{event},
+	})
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body))
+	req.Header.Set("X-Line-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	app.Callback(rec, req)
+	return rec.Code, nil
+}
+
+// renderMessage turns a message object of the Messaging API into readable text
+func renderMessage(message map[string]interface{}) string {
+	var b strings.Builder
+	switch message["type"] {
+	case "text":
+		b.WriteString(jsonString(message["text"]))
+	case "image", "video":
+		fmt.Fprintf(&b, "[%s] %s", message["type"], jsonString(message["originalContentUrl"]))
+	case "audio":
+		fmt.Fprintf(&b, "[audio] %s", jsonString(message["originalContentUrl"]))
+	case "sticker":
+		fmt.Fprintf(&b, "[sticker] %s/%s", jsonString(message["packageId"]), jsonString(message["stickerId"]))
+	case "location":
+		fmt.Fprintf(&b, "[location] %s, %s", jsonString(message["title"]), jsonString(message["address"]))
+	case "template":
+		fmt.Fprintf(&b, "[template] %s\n", jsonString(message["altText"]))
+		renderTemplate(&b, message["template"], "  ")
+	case "flex":
+		fmt.Fprintf(&b, "[flex] %s\n", jsonString(message["altText"]))
+		renderFlex(&b, message["contents"], "  ")
+	default:
+		raw, _ := json.Marshal(message)
+		b.Write(raw)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderTemplate(b *strings.Builder, node interface{}, indent string) {
+	template, _ := node.(map[string]interface{})
+	if template == nil {
+		return
+	}
+	if columns, ok := template["columns"].([]interface{}); ok {
+		for i, column := range columns {
+			fmt.Fprintf(b, "%s#%d\n", indent, i+1)
+			renderTemplate(b, column, indent+"  ")
+		}
+		return
+	}
+	for _, key := range []string{"title", "text"} {
+		if text := jsonString(template[key]); text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				fmt.Fprintf(b, "%s%s\n", indent, line)
+			}
+		}
+	}
+	if actions, ok := template["actions"].([]interface{}); ok {
+		for _, action := range actions {
+			renderAction(b, action, indent)
+		}
+	}
+}
+
+func renderFlex(b *strings.Builder, node interface{}, indent string) {
+	component, _ := node.(map[string]interface{})
+	if component == nil {
+		return
+	}
+	switch component["type"] {
+	case "carousel":
+		contents, _ := component["contents"].([]interface{})
+		for i, bubble := range contents {
+			fmt.Fprintf(b, "%s#%d\n", indent, i+1)
+			renderFlex(b, bubble, indent+"  ")
+		}
+	case "bubble":
+		for _, part := range []string{"header", "hero", "body", "footer"} {
+			renderFlex(b, component[part], indent)
+		}
+	case "box":
+		contents, _ := component["contents"].([]interface{})
+		if component["layout"] == "vertical" {
+			for _, child := range contents {
+				renderFlex(b, child, indent)
+			}
+			return
+		}
+		// horizontal and baseline boxes are printed on one line
+		var cells []string
+		for _, child := range contents {
+			var cell strings.Builder
+			renderFlex(&cell, child, "")
+			if text := strings.TrimSpace(cell.String()); text != "" {
+				cells = append(cells, text)
+			}
+		}
+		if len(cells) > 0 {
+			fmt.Fprintf(b, "%s%s\n", indent, strings.Join(cells, " | "))
+		}
+	case "text":
+		text := jsonString(component["text"])
+		if contents, ok := component["contents"].([]interface{}); ok && text == "" {
+			for _, span := range contents {
+				if span, ok := span.(map[string]interface{}); ok {
+					text += jsonString(span["text"])
+				}
+			}
+		}
+		fmt.Fprintf(b, "%s%s\n", indent, text)
+	case "image":
+		fmt.Fprintf(b, "%s[image] %s\n", indent, jsonString(component["url"]))
+	case "button":
+		renderAction(b, component["action"], indent)
+	case "separator":
+		fmt.Fprintf(b, "%s----\n", indent)
+	}
+}
+
+func renderAction(b *strings.Builder, node interface{}, indent string) {
+	action, _ := node.(map[string]interface{})
+	if action == nil {
+		return
+	}
+	target := jsonString(action["uri"])
+	if target == "" {
+		target = jsonString(action["data"])
+	}
+	if target == "" {
+		target = jsonString(action["text"])
+	}
+	fmt.Fprintf(b, "%s[%s] -> %s\n", indent, jsonString(action["label"]), target)
+}
+
+func jsonString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
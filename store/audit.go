@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AuditEntry is an action taken through the admin API
+type AuditEntry struct {
+	ID     uint64    `json:"id"`
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Target is the chat acted on, if any
+	Target string `json:"target,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// Remote is the address the request came from
+	Remote string `json:"remote"`
+	// Error is why the action failed, empty when it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// AddAudit appends an entry to the audit log and sets its ID
+func (s *Store) AddAudit(entry *AuditEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(id), data)
+	})
+}
+
+// AuditLog returns the last limit entries of the audit log, newest first
+func (s *Store) AuditLog(limit int) ([]*AuditEntry, error) {
+	var entries []*AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(auditBucket).Cursor()
+		for k, data := c.Last(); k != nil && len(entries) < limit; k, data = c.Prev() {
+			entry := &AuditEntry{}
+			if err := json.Unmarshal(data, entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	s := openTestStore(t)
+	if entries, err := s.AuditLog(10); err != nil || len(entries) != 0 {
+		t.Fatalf("got %+v, %v for an empty log", entries, err)
+	}
+	at := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		entry := &AuditEntry{Time: at, Action: fmt.Sprintf("action %d", i), Target: "G1", Remote: "127.0.0.1"}
+		if i == 3 {
+			entry.Error = "failed"
+		}
+		if err := s.AddAudit(entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.ID != uint64(i) {
+			t.Errorf("entry %d got the ID %d", i, entry.ID)
+		}
+	}
+	entries, err := s.AuditLog(3)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("got %d entries, %v, want 3", len(entries), err)
+	}
+	for i, entry := range entries {
+		want := fmt.Sprintf("action %d", 5-i)
+		if entry.Action != want || entry.Target != "G1" || !entry.Time.Equal(at) {
+			t.Errorf("entry %d is %+v, want %s", i, entry, want)
+		}
+	}
+	if entries[2].Error != "failed" {
+		t.Errorf("the error of action 3 is %q", entries[2].Error)
+	}
+	if all, _ := s.AuditLog(100); len(all) != 5 {
+		t.Errorf("got %d entries, want 5", len(all))
+	}
+}
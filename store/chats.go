@@ -0,0 +1,74 @@
+package store
+
+import "time"
+
+// Chat holds the settings of a group, room or 1:1 chat
+type Chat struct {
+	// Prefix replaces the default command prefix when set
+	Prefix string `json:"prefix,omitempty"`
+	// Disabled are the names of the commands turned off
+	Disabled []string `json:"disabled,omitempty"`
+	// Admins are the LINE user IDs allowed to run admin commands, besides
+	// the owners of the bot
+	Admins []string `json:"admins,omitempty"`
+	// Lang is the language of the replies in a group or room, "" for the
+	// language of each user
+	Lang string `json:"lang,omitempty"`
+	// Updated is when the record last changed
+	Updated time.Time `json:"updated"`
+}
+
+// IsDisabled reports whether a command is turned off
+func (c *Chat) IsDisabled(command string) bool {
+	return c != nil && contains(c.Disabled, command)
+}
+
+// IsAdmin reports whether a user may run admin commands
+func (c *Chat) IsAdmin(userID string) bool {
+	return c != nil && contains(c.Admins, userID)
+}
+
+// Chat returns the settings of a chat, empty ones when there are none
+func (s *Store) Chat(chatID string) (*Chat, error) {
+	chat := &Chat{}
+	if err := s.get(chatsBucket, chatID, chat); err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return chat, nil
+}
+
+// UpdateChat lets fn change the settings of a chat and saves them, unless fn
+// fails
+func (s *Store) UpdateChat(chatID string, fn func(chat *Chat) error) (*Chat, error) {
+	chat := &Chat{}
+	err := s.update(chatsBucket, chatID, chat, func() error {
+		if err := fn(chat); err != nil {
+			return err
+		}
+		chat.Updated = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+// ResetChat puts the settings of a chat back to the defaults, the admins
+// are kept
+func (s *Store) ResetChat(chatID string) error {
+	_, err := s.UpdateChat(chatID, func(chat *Chat) error {
+		*chat = Chat{Admins: chat.Admins}
+		return nil
+	})
+	return err
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
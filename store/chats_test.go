@@ -0,0 +1,56 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChats(t *testing.T) {
+	s := openTestStore(t)
+	chat, err := s.Chat("G1")
+	if err != nil || chat.Prefix != "" || chat.IsDisabled("osu") || chat.IsAdmin("U1") {
+		t.Fatalf("got %+v, %v, want empty settings", chat, err)
+	}
+	chat, err = s.UpdateChat("G1", func(chat *Chat) error {
+		chat.Prefix = "?"
+		chat.Disabled = append(chat.Disabled, "osu")
+		chat.Admins = append(chat.Admins, "U1")
+		chat.Lang = "id"
+		return nil
+	})
+	if err != nil || chat.Updated.IsZero() {
+		t.Fatalf("got %+v, %v", chat, err)
+	}
+	chat, err = s.Chat("G1")
+	if err != nil || chat.Prefix != "?" || chat.Lang != "id" || !chat.IsDisabled("osu") || chat.IsDisabled("steam") || !chat.IsAdmin("U1") || chat.IsAdmin("U2") {
+		t.Errorf("got %+v, %v", chat, err)
+	}
+
+	// a failing update changes nothing
+	boom := errors.New("boom")
+	if _, err := s.UpdateChat("G1", func(chat *Chat) error {
+		chat.Prefix = "."
+		return boom
+	}); err != boom {
+		t.Errorf("got %v, want the error of fn", err)
+	}
+	if chat, _ := s.Chat("G1"); chat.Prefix != "?" {
+		t.Errorf("the prefix is %q after a failed update", chat.Prefix)
+	}
+
+	if err := s.ResetChat("G1"); err != nil {
+		t.Fatal(err)
+	}
+	chat, _ = s.Chat("G1")
+	if chat.Prefix != "" || chat.Lang != "" || chat.Disabled != nil || !reflect.DeepEqual(chat.Admins, []string{"U1"}) {
+		t.Errorf("got %+v after a reset, want only the admins", chat)
+	}
+}
+
+func TestNilChat(t *testing.T) {
+	var chat *Chat
+	if chat.IsDisabled("osu") || chat.IsAdmin("U1") {
+		t.Error("a nil chat has settings")
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DotaWatch is a Dota 2 player watched by some chats
+type DotaWatch struct {
+	// AccountID is the 32-bit Steam account ID used by OpenDota
+	AccountID string `json:"account_id"`
+	// LastMatchID is the newest match already announced
+	LastMatchID int `json:"last_match_id"`
+	// Chats maps the watching chats to the vanity name they watch the
+	// player as
+	Chats map[string]string `json:"chats"`
+}
+
+// ChatIDs returns the watching chats in a stable order
+func (w *DotaWatch) ChatIDs() []string {
+	ids := make([]string, 0, len(w.Chats))
+	for id := range w.Chats {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// WatchDota makes a chat watch a player. lastMatchID is only used when
+// nobody watched the player yet.
+func (s *Store) WatchDota(accountID, chatID, vanity string, lastMatchID int) error {
+	watch := &DotaWatch{}
+	return s.update(dotaWatchBucket, accountID, watch, func() error {
+		if watch.AccountID == "" {
+			watch.AccountID = accountID
+			watch.LastMatchID = lastMatchID
+		}
+		if watch.Chats == nil {
+			watch.Chats = make(map[string]string)
+		}
+		watch.Chats[chatID] = vanity
+		return nil
+	})
+}
+
+// UnwatchDota stops a chat from watching a player, or every player when
+// accountID is empty. It returns the vanity names no longer watched.
+func (s *Store) UnwatchDota(chatID, accountID string) ([]string, error) {
+	var removed []string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dotaWatchBucket)
+		var keys [][]byte
+		if accountID != "" {
+			keys = append(keys, []byte(accountID))
+		} else {
+			b.ForEach(func(k, _ []byte) error {
+				keys = append(keys, append([]byte(nil), k...))
+				return nil
+			})
+		}
+		for _, key := range keys {
+			data := b.Get(key)
+			if data == nil {
+				continue
+			}
+			var watch DotaWatch
+			if err := json.Unmarshal(data, &watch); err != nil {
+				return err
+			}
+			vanity, ok := watch.Chats[chatID]
+			if !ok {
+				continue
+			}
+			removed = append(removed, vanity)
+			delete(watch.Chats, chatID)
+			if len(watch.Chats) == 0 {
+				if err := b.Delete(key); err != nil {
+					return err
+				}
+				continue
+			}
+			data, err := json.Marshal(&watch)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	sort.Strings(removed)
+	return removed, err
+}
+
+// DotaWatches returns every watched player
+func (s *Store) DotaWatches() ([]*DotaWatch, error) {
+	var watches []*DotaWatch
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(dotaWatchBucket).ForEach(func(_, data []byte) error {
+			watch := &DotaWatch{}
+			if err := json.Unmarshal(data, watch); err != nil {
+				return err
+			}
+			watches = append(watches, watch)
+			return nil
+		})
+	})
+	return watches, err
+}
+
+// SetDotaLastMatch records the newest match announced for a player
+func (s *Store) SetDotaLastMatch(accountID string, matchID int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dotaWatchBucket)
+		data := b.Get([]byte(accountID))
+		if data == nil {
+			return ErrNotFound
+		}
+		var watch DotaWatch
+		if err := json.Unmarshal(data, &watch); err != nil {
+			return err
+		}
+		watch.LastMatchID = matchID
+		data, err := json.Marshal(&watch)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(accountID), data)
+	})
+}
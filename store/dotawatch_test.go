@@ -0,0 +1,55 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDotaWatch(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.SetDotaLastMatch("86745912", 10); err != ErrNotFound {
+		t.Errorf("SetDotaLastMatch of an unwatched player = %v, want ErrNotFound", err)
+	}
+	if err := s.WatchDota("86745912", "G1", "arteezy", 100); err != nil {
+		t.Fatal(err)
+	}
+	// the last match of a player already watched is kept
+	if err := s.WatchDota("86745912", "G2", "rtz", 90); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WatchDota("70388657", "G1", "dendi", 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDotaLastMatch("70388657", 51); err != nil {
+		t.Fatal(err)
+	}
+
+	watches, err := s.DotaWatches()
+	if err != nil || len(watches) != 2 {
+		t.Fatalf("got %+v, %v", watches, err)
+	}
+	dendi, rtz := watches[0], watches[1]
+	if dendi.LastMatchID != 51 || !reflect.DeepEqual(dendi.ChatIDs(), []string{"G1"}) {
+		t.Errorf("dendi is %+v", dendi)
+	}
+	if rtz.LastMatchID != 100 || !reflect.DeepEqual(rtz.Chats, map[string]string{"G1": "arteezy", "G2": "rtz"}) || !reflect.DeepEqual(rtz.ChatIDs(), []string{"G1", "G2"}) {
+		t.Errorf("rtz is %+v", rtz)
+	}
+
+	if removed, err := s.UnwatchDota("G2", "70388657"); err != nil || len(removed) != 0 {
+		t.Errorf("G2 unwatched %q, %v, it didn't watch dendi", removed, err)
+	}
+	if removed, err := s.UnwatchDota("G2", "86745912"); err != nil || !reflect.DeepEqual(removed, []string{"rtz"}) {
+		t.Errorf("UnwatchDota(G2, rtz) = %q, %v", removed, err)
+	}
+	if removed, err := s.UnwatchDota("G1", ""); err != nil || !reflect.DeepEqual(removed, []string{"arteezy", "dendi"}) {
+		t.Errorf("UnwatchDota(G1) = %q, %v", removed, err)
+	}
+	// players nobody watches are forgotten
+	if watches, _ := s.DotaWatches(); len(watches) != 0 {
+		t.Errorf("%d players are still watched", len(watches))
+	}
+	if err := s.SetDotaLastMatch("86745912", 101); err != ErrNotFound {
+		t.Errorf("SetDotaLastMatch after the unwatch = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Membership tells whether the bot is in a group or room
+type Membership struct {
+	ChatID string `json:"chat_id"`
+	// Type is group or room
+	Type string `json:"type"`
+	// Joined is when the bot was invited, zero when it was only seen in
+	// the chat because it joined before memberships were recorded
+	Joined time.Time `json:"joined"`
+	// Seen is when the bot first saw the chat
+	Seen time.Time `json:"seen"`
+	// Left is when the bot left, zero while it is in the chat
+	Left time.Time `json:"left"`
+}
+
+// Active reports whether the bot is still in the chat
+func (m *Membership) Active() bool {
+	return m.Left.IsZero()
+}
+
+// JoinChat records that the bot was invited to a group or room
+func (s *Store) JoinChat(chatID, chatType string, at time.Time) error {
+	m := &Membership{}
+	return s.update(membershipsBucket, chatID, m, func() error {
+		m.ChatID, m.Type = chatID, chatType
+		m.Joined, m.Left = at, time.Time{}
+		if m.Seen.IsZero() {
+			m.Seen = at
+		}
+		return nil
+	})
+}
+
+// SeenChat records a group or room the bot got an event from, it only
+// writes when the chat is new or was left
+func (s *Store) SeenChat(chatID, chatType string, at time.Time) error {
+	m, err := s.Membership(chatID)
+	if err == nil && m.Active() {
+		return nil
+	}
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	m = &Membership{}
+	return s.update(membershipsBucket, chatID, m, func() error {
+		m.ChatID, m.Type = chatID, chatType
+		m.Left = time.Time{}
+		if m.Seen.IsZero() {
+			m.Seen = at
+		}
+		return nil
+	})
+}
+
+// LeaveChat records that the bot left a group or room
+func (s *Store) LeaveChat(chatID string, at time.Time) error {
+	m := &Membership{}
+	return s.update(membershipsBucket, chatID, m, func() error {
+		if m.ChatID == "" {
+			return ErrNotFound
+		}
+		m.Left = at
+		return nil
+	})
+}
+
+// Membership returns the membership of a chat, or ErrNotFound
+func (s *Store) Membership(chatID string) (*Membership, error) {
+	m := &Membership{}
+	if err := s.get(membershipsBucket, chatID, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Memberships returns every group and room the bot has been in
+func (s *Store) Memberships() ([]*Membership, error) {
+	var memberships []*Membership
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(membershipsBucket).ForEach(func(_, data []byte) error {
+			m := &Membership{}
+			if err := json.Unmarshal(data, m); err != nil {
+				return err
+			}
+			memberships = append(memberships, m)
+			return nil
+		})
+	})
+	return memberships, err
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemberships(t *testing.T) {
+	s := openTestStore(t)
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 12, 0, 0, 0, time.UTC) }
+	if _, err := s.Membership("G1"); err != ErrNotFound {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+	if err := s.LeaveChat("G1", day(1)); err != ErrNotFound {
+		t.Errorf("leaving an unknown chat: got %v, want ErrNotFound", err)
+	}
+	if _, err := s.Membership("G1"); err != ErrNotFound {
+		t.Errorf("leaving an unknown chat recorded it: %v", err)
+	}
+
+	// a chat the bot was in before memberships were recorded
+	if err := s.SeenChat("R1", "room", day(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SeenChat("R1", "room", day(2)); err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.Membership("R1")
+	if err != nil || m.Type != "room" || !m.Seen.Equal(day(1)) || !m.Joined.IsZero() || !m.Active() {
+		t.Errorf("got %+v, %v", m, err)
+	}
+
+	if err := s.JoinChat("G1", "group", day(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LeaveChat("G1", day(4)); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := s.Membership("G1"); m.Active() || !m.Left.Equal(day(4)) || !m.Joined.Equal(day(3)) {
+		t.Errorf("got %+v after leaving", m)
+	}
+	// invited again
+	if err := s.JoinChat("G1", "group", day(5)); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := s.Membership("G1"); !m.Active() || !m.Joined.Equal(day(5)) || !m.Seen.Equal(day(3)) {
+		t.Errorf("got %+v after joining again", m)
+	}
+	// an event from a chat that was left marks it active again
+	s.LeaveChat("G1", day(6))
+	if err := s.SeenChat("G1", "group", day(7)); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := s.Membership("G1"); !m.Active() || !m.Seen.Equal(day(3)) {
+		t.Errorf("got %+v after an event", m)
+	}
+
+	all, err := s.Memberships()
+	if err != nil || len(all) != 2 || all[0].ChatID != "G1" || all[1].ChatID != "R1" {
+		t.Errorf("Memberships() = %+v, %v", all, err)
+	}
+}
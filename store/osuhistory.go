@@ -0,0 +1,103 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// maxOsuSnapshots bounds the history of a player, a bit more than a year of
+// daily snapshots
+const maxOsuSnapshots = 400
+
+// OsuRank is the standing of a player in one osu! game mode. Zero ranks mean
+// the player is unranked.
+type OsuRank struct {
+	Global   int     `json:"global"`
+	Country  int     `json:"country"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// OsuSnapshot is the standing of a player in every game mode at a time
+type OsuSnapshot struct {
+	Time time.Time `json:"time"`
+	// Ranks maps a game mode (standard, taiko, ctb, mania) to the rank in it
+	Ranks map[string]OsuRank `json:"ranks"`
+}
+
+// OsuPlayer is an osu! player whose ranks are tracked
+type OsuPlayer struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	// LastLookup is when the player was last asked for, players nobody asks
+	// for anymore stop being tracked
+	LastLookup time.Time `json:"last_lookup"`
+	// Snapshots are oldest first
+	Snapshots []OsuSnapshot `json:"snapshots"`
+}
+
+// Latest returns the newest snapshot, or nil
+func (p *OsuPlayer) Latest() *OsuSnapshot {
+	if len(p.Snapshots) == 0 {
+		return nil
+	}
+	return &p.Snapshots[len(p.Snapshots)-1]
+}
+
+// SnapshotAt returns the newest snapshot taken at or before t, or nil
+func (p *OsuPlayer) SnapshotAt(t time.Time) *OsuSnapshot {
+	for i := len(p.Snapshots) - 1; i >= 0; i-- {
+		if !p.Snapshots[i].Time.After(t) {
+			return &p.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// OsuPlayer returns a tracked player by osu! user ID, or ErrNotFound
+func (s *Store) OsuPlayer(userID string) (*OsuPlayer, error) {
+	player := &OsuPlayer{}
+	if err := s.get(osuHistoryBucket, userID, player); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// UpdateOsuPlayer lets fn change a tracked player, starting to track it when
+// it isn't yet, and saves it. Snapshots beyond the limit are dropped, oldest
+// first.
+func (s *Store) UpdateOsuPlayer(userID string, fn func(player *OsuPlayer) error) error {
+	player := &OsuPlayer{}
+	return s.update(osuHistoryBucket, userID, player, func() error {
+		player.UserID = userID
+		if err := fn(player); err != nil {
+			return err
+		}
+		if extra := len(player.Snapshots) - maxOsuSnapshots; extra > 0 {
+			player.Snapshots = append([]OsuSnapshot(nil), player.Snapshots[extra:]...)
+		}
+		return nil
+	})
+}
+
+// OsuPlayers returns every tracked player
+func (s *Store) OsuPlayers() ([]*OsuPlayer, error) {
+	var players []*OsuPlayer
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(osuHistoryBucket).ForEach(func(_, data []byte) error {
+			player := &OsuPlayer{}
+			if err := json.Unmarshal(data, player); err != nil {
+				return err
+			}
+			players = append(players, player)
+			return nil
+		})
+	})
+	return players, err
+}
+
+// ForgetOsuPlayer stops tracking a player and drops its history
+func (s *Store) ForgetOsuPlayer(userID string) error {
+	return s.delete(osuHistoryBucket, userID)
+}
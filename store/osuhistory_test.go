@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOsuHistory(t *testing.T) {
+	s := openTestStore(t)
+	if _, err := s.OsuPlayer("124493"); err != ErrNotFound {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, d) }
+	for d := 0; d < maxOsuSnapshots+10; d++ {
+		err := s.UpdateOsuPlayer("124493", func(player *OsuPlayer) error {
+			player.Username = "cookiezi"
+			player.LastLookup = day(d)
+			player.Snapshots = append(player.Snapshots, OsuSnapshot{Time: day(d), Ranks: map[string]OsuRank{"standard": {Global: 1000 - d}}})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	player, err := s.OsuPlayer("124493")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.UserID != "124493" || player.Username != "cookiezi" || len(player.Snapshots) != maxOsuSnapshots {
+		t.Fatalf("got %s %s with %d snapshots", player.UserID, player.Username, len(player.Snapshots))
+	}
+	// the oldest snapshots are dropped
+	if first := player.Snapshots[0]; !first.Time.Equal(day(10)) {
+		t.Errorf("the first snapshot is from %s, want %s", first.Time, day(10))
+	}
+	if latest := player.Latest(); !latest.Time.Equal(day(maxOsuSnapshots + 9)) {
+		t.Errorf("the latest snapshot is from %s", latest.Time)
+	}
+	if at := player.SnapshotAt(day(20).Add(time.Hour)); at == nil || !at.Time.Equal(day(20)) || at.Ranks["standard"].Global != 980 {
+		t.Errorf("SnapshotAt(day 20) = %+v", at)
+	}
+	if at := player.SnapshotAt(day(5)); at != nil {
+		t.Errorf("SnapshotAt(day 5) = %+v, the snapshot was dropped", at)
+	}
+	if (&OsuPlayer{}).Latest() != nil {
+		t.Error("a player without snapshots has a latest one")
+	}
+
+	// a failing update changes nothing
+	boom := errors.New("boom")
+	if err := s.UpdateOsuPlayer("124493", func(player *OsuPlayer) error {
+		player.Username = "someone"
+		return boom
+	}); err != boom {
+		t.Errorf("got %v, want the error of fn", err)
+	}
+	if err := s.UpdateOsuPlayer("2558286", func(player *OsuPlayer) error {
+		player.Username = "rafis"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	players, err := s.OsuPlayers()
+	if err != nil || len(players) != 2 || players[0].Username != "cookiezi" || players[1].Username != "rafis" {
+		t.Errorf("OsuPlayers() = %+v, %v", players, err)
+	}
+
+	if err := s.ForgetOsuPlayer("124493"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OsuPlayer("124493"); err != ErrNotFound {
+		t.Errorf("got %v after forgetting the player, want ErrNotFound", err)
+	}
+	if err := s.ForgetOsuPlayer("124493"); err != nil {
+		t.Errorf("forgetting twice: %v", err)
+	}
+}
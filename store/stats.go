@@ -0,0 +1,133 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// CommandUse is one command run in a chat
+type CommandUse struct {
+	ChatID  string
+	Command string
+	// UserID is who typed the command, empty for scheduled runs
+	UserID  string
+	Latency time.Duration
+	// Error is the kind of failure, empty when the command succeeded
+	Error string
+	Time  time.Time
+}
+
+// CommandStats counts the runs of one command
+type CommandStats struct {
+	Count int `json:"count"`
+	// Errors counts the failed runs by kind of failure
+	Errors map[string]int `json:"errors,omitempty"`
+	// Latency is the total time spent, see AverageLatency
+	Latency time.Duration `json:"latency"`
+}
+
+// AverageLatency is the mean time a run took
+func (c *CommandStats) AverageLatency() time.Duration {
+	if c.Count == 0 {
+		return 0
+	}
+	return c.Latency / time.Duration(c.Count)
+}
+
+// ErrorCount is the number of failed runs
+func (c *CommandStats) ErrorCount() int {
+	n := 0
+	for _, count := range c.Errors {
+		n += count
+	}
+	return n
+}
+
+// ChatStats is the command usage of a chat
+type ChatStats struct {
+	// Since is when the first command was recorded
+	Since    time.Time                `json:"since"`
+	Commands map[string]*CommandStats `json:"commands"`
+	// Users counts the commands typed by each user
+	Users map[string]int `json:"users"`
+}
+
+// Ranked is a name with a count, for top lists
+type Ranked struct {
+	Name  string
+	Count int
+}
+
+// TopCommands returns the n most used commands, most used first
+func (c *ChatStats) TopCommands(n int) []Ranked {
+	counts := make(map[string]int, len(c.Commands))
+	for name, stats := range c.Commands {
+		counts[name] = stats.Count
+	}
+	return top(counts, n)
+}
+
+// TopUsers returns the n users who typed the most commands, most active first
+func (c *ChatStats) TopUsers(n int) []Ranked {
+	return top(c.Users, n)
+}
+
+func top(counts map[string]int, n int) []Ranked {
+	ranked := make([]Ranked, 0, len(counts))
+	for name, count := range counts {
+		ranked = append(ranked, Ranked{Name: name, Count: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// RecordCommand adds a command run to the stats of its chat
+func (s *Store) RecordCommand(use CommandUse) error {
+	stats := &ChatStats{}
+	return s.update(statsBucket, use.ChatID, stats, func() error {
+		if stats.Since.IsZero() {
+			stats.Since = use.Time
+		}
+		if stats.Commands == nil {
+			stats.Commands = make(map[string]*CommandStats)
+		}
+		if stats.Users == nil {
+			stats.Users = make(map[string]int)
+		}
+		cmd, ok := stats.Commands[use.Command]
+		if !ok {
+			cmd = &CommandStats{}
+			stats.Commands[use.Command] = cmd
+		}
+		cmd.Count++
+		cmd.Latency += use.Latency
+		if use.Error != "" {
+			if cmd.Errors == nil {
+				cmd.Errors = make(map[string]int)
+			}
+			cmd.Errors[use.Error]++
+		}
+		if use.UserID != "" {
+			stats.Users[use.UserID]++
+		}
+		return nil
+	})
+}
+
+// ChatStats returns the command usage of a chat, an empty one when nothing
+// was recorded
+func (s *Store) ChatStats(chatID string) (*ChatStats, error) {
+	stats := &ChatStats{}
+	if err := s.get(statsBucket, chatID, stats); err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return stats, nil
+}
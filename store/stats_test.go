@@ -0,0 +1,60 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChatStats(t *testing.T) {
+	s := openTestStore(t)
+	stats, err := s.ChatStats("G1")
+	if err != nil || stats.Commands != nil || !stats.Since.IsZero() {
+		t.Fatalf("got %+v, %v, want empty stats", stats, err)
+	}
+	first := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	uses := []CommandUse{
+		{ChatID: "G1", Command: "osu", UserID: "U1", Latency: 100 * time.Millisecond, Time: first},
+		{ChatID: "G1", Command: "osu", UserID: "U2", Latency: 300 * time.Millisecond, Error: "unavailable", Time: first.Add(time.Minute)},
+		{ChatID: "G1", Command: "steam", UserID: "U1", Latency: 50 * time.Millisecond, Time: first.Add(2 * time.Minute)},
+		{ChatID: "G1", Command: "motw", Time: first.Add(3 * time.Minute)},
+		{ChatID: "G1", Command: "osu", UserID: "U1", Error: "unavailable", Time: first.Add(4 * time.Minute)},
+		{ChatID: "G2", Command: "help", UserID: "U3", Time: first},
+	}
+	for _, use := range uses {
+		if err := s.RecordCommand(use); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err = s.ChatStats("G1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.Since.Equal(first) {
+		t.Errorf("since %s, want %s", stats.Since, first)
+	}
+	osu := stats.Commands["osu"]
+	if osu.Count != 3 || osu.AverageLatency() != 400*time.Millisecond/3 || osu.ErrorCount() != 2 || osu.Errors["unavailable"] != 2 {
+		t.Errorf("osu stats are %+v", osu)
+	}
+	if steam := stats.Commands["steam"]; steam.ErrorCount() != 0 || steam.AverageLatency() != 50*time.Millisecond {
+		t.Errorf("steam stats are %+v", steam)
+	}
+	// scheduled runs aren't counted for anyone
+	if !reflect.DeepEqual(stats.Users, map[string]int{"U1": 3, "U2": 1}) {
+		t.Errorf("users are %v", stats.Users)
+	}
+	if top := stats.TopCommands(2); !reflect.DeepEqual(top, []Ranked{{"osu", 3}, {"motw", 1}}) {
+		t.Errorf("TopCommands(2) = %v", top)
+	}
+	if top := stats.TopUsers(5); !reflect.DeepEqual(top, []Ranked{{"U1", 3}, {"U2", 1}}) {
+		t.Errorf("TopUsers(5) = %v", top)
+	}
+	if g2, _ := s.ChatStats("G2"); g2.Commands["help"].Count != 1 || len(g2.Commands) != 1 {
+		t.Errorf("G2 stats are %+v", g2)
+	}
+	if avg := (&CommandStats{}).AverageLatency(); avg != 0 {
+		t.Errorf("the average of no runs is %s", avg)
+	}
+}
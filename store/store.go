@@ -0,0 +1,113 @@
+// Package store is the embedded database of the bot, a single bbolt file
+// holding JSON records keyed by LINE user, group or room ID.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the database
+var (
+	usersBucket         = []byte("users")
+	chatsBucket         = []byte("chats")
+	subscriptionsBucket = []byte("subscriptions")
+	dotaWatchBucket     = []byte("dota_watch")
+	osuHistoryBucket    = []byte("osu_history")
+	statsBucket         = []byte("stats")
+	membershipsBucket   = []byte("memberships")
+	auditBucket         = []byte("audit")
+)
+
+// buckets are created when the database is opened
+var buckets = [][]byte{
+	usersBucket, chatsBucket, subscriptionsBucket, dotaWatchBucket,
+	osuHistoryBucket, statsBucket, membershipsBucket, auditBucket,
+}
+
+// ErrNotFound is returned when a record doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Store is the bot database. It is safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database file at path
+func Open(path string) (*Store, error) {
+	// a second bot on the same file fails instead of hanging forever
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database can be read
+func (s *Store) Ping() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(usersBucket) == nil {
+			return errors.New("buckets are missing")
+		}
+		return nil
+	})
+}
+
+// get decodes the record of key into v, or returns ErrNotFound
+func (s *Store) get(bucket []byte, key string, v interface{}) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// update loads the record of key into v, which is left untouched when there
+// is none, lets fn change it and saves it, all in one transaction
+func (s *Store) update(bucket []byte, key string, v interface{}, fn func() error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if data := b.Get([]byte(key)); data != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				return err
+			}
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// delete removes the record of key, if any
+func (s *Store) delete(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestStore opens a database in a temporary file, closed at the end of
+// the test
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "tamako.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tamako.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Link("U1", "osu", "cookiezi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if account, err := s.LinkedAccount("U1", "osu"); err != nil || account != "cookiezi" {
+		t.Errorf("got %q, %v after a reopen", account, err)
+	}
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Subscription posts the output of a command to a chat on a schedule
+type Subscription struct {
+	ID       uint64   `json:"id"`
+	ChatID   string   `json:"chat_id"`
+	ChatType string   `json:"chat_type"`
+	Command  string   `json:"command"`
+	Args     []string `json:"args,omitempty"`
+	// Schedule is written the way schedule.Parse reads it
+	Schedule  string    `json:"schedule"`
+	CreatedBy string    `json:"created_by"`
+	Created   time.Time `json:"created"`
+	// LastRun is when the subscription was last posted, zero before the first
+	LastRun time.Time `json:"last_run"`
+}
+
+// sequenceKey is the key of a record numbered by NextSequence, big endian so
+// the records are sorted by number
+func sequenceKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// AddSubscription saves a new subscription and sets its ID
+func (s *Store) AddSubscription(sub *Subscription) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(subscriptionsBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		sub.ID = id
+		data, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(id), data)
+	})
+}
+
+// Subscriptions returns every subscription, or those of one chat when
+// chatID is not empty, oldest first
+func (s *Store) Subscriptions(chatID string) ([]*Subscription, error) {
+	var subs []*Subscription
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(subscriptionsBucket).ForEach(func(_, data []byte) error {
+			sub := &Subscription{}
+			if err := json.Unmarshal(data, sub); err != nil {
+				return err
+			}
+			if chatID == "" || sub.ChatID == chatID {
+				subs = append(subs, sub)
+			}
+			return nil
+		})
+	})
+	return subs, err
+}
+
+// RemoveSubscription deletes a subscription of a chat. It returns false when
+// the chat has no subscription with this ID.
+func (s *Store) RemoveSubscription(chatID string, id uint64) (bool, error) {
+	removed := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(subscriptionsBucket)
+		data := b.Get(sequenceKey(id))
+		if data == nil {
+			return nil
+		}
+		var sub Subscription
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return err
+		}
+		if sub.ChatID != chatID {
+			return nil
+		}
+		removed = true
+		return b.Delete(sequenceKey(id))
+	})
+	return removed, err
+}
+
+// MarkSubscriptionRun records when a subscription was posted
+func (s *Store) MarkSubscriptionRun(id uint64, at time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(subscriptionsBucket)
+		data := b.Get(sequenceKey(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		var sub Subscription
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return err
+		}
+		sub.LastRun = at
+		data, err := json.Marshal(&sub)
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(id), data)
+	})
+}
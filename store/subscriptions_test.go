@@ -0,0 +1,65 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscriptions(t *testing.T) {
+	s := openTestStore(t)
+	created := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	subs := []*Subscription{
+		{ChatID: "G1", ChatType: "group", Command: "motw", Schedule: "weekly mon 09:00", CreatedBy: "U1", Created: created},
+		{ChatID: "G2", ChatType: "group", Command: "osu", Args: []string{"cookiezi"}, Schedule: "daily 20:00", CreatedBy: "U2", Created: created},
+		{ChatID: "G1", ChatType: "group", Command: "itunes", Schedule: "hourly :00", CreatedBy: "U1", Created: created},
+	}
+	for i, sub := range subs {
+		if err := s.AddSubscription(sub); err != nil {
+			t.Fatal(err)
+		}
+		if sub.ID != uint64(i+1) {
+			t.Errorf("subscription %d got the ID %d", i, sub.ID)
+		}
+	}
+
+	all, err := s.Subscriptions("")
+	if err != nil || !reflect.DeepEqual(all, subs) {
+		t.Errorf("Subscriptions() = %+v, %v", all, err)
+	}
+	g1, err := s.Subscriptions("G1")
+	if err != nil || !reflect.DeepEqual(g1, []*Subscription{subs[0], subs[2]}) {
+		t.Errorf("Subscriptions(G1) = %+v, %v", g1, err)
+	}
+	if none, err := s.Subscriptions("G3"); err != nil || len(none) != 0 {
+		t.Errorf("Subscriptions(G3) = %+v, %v", none, err)
+	}
+
+	ran := created.Add(time.Hour)
+	if err := s.MarkSubscriptionRun(2, ran); err != nil {
+		t.Fatal(err)
+	}
+	if g2, _ := s.Subscriptions("G2"); len(g2) != 1 || !g2[0].LastRun.Equal(ran) {
+		t.Errorf("got %+v, want the run recorded", g2)
+	}
+	if err := s.MarkSubscriptionRun(9, ran); err != ErrNotFound {
+		t.Errorf("MarkSubscriptionRun(9) = %v, want ErrNotFound", err)
+	}
+
+	// a chat can only remove its own subscriptions
+	if removed, err := s.RemoveSubscription("G2", 1); err != nil || removed {
+		t.Errorf("G2 removed the subscription of G1: %v, %v", removed, err)
+	}
+	if removed, err := s.RemoveSubscription("G1", 1); err != nil || !removed {
+		t.Errorf("RemoveSubscription(G1, 1) = %v, %v", removed, err)
+	}
+	if removed, err := s.RemoveSubscription("G1", 1); err != nil || removed {
+		t.Errorf("removed twice: %v, %v", removed, err)
+	}
+
+	// IDs are never reused
+	sub := &Subscription{ChatID: "G1", Command: "motw", Schedule: "daily 09:00"}
+	if err := s.AddSubscription(sub); err != nil || sub.ID != 4 {
+		t.Errorf("got the ID %d, %v, want 4", sub.ID, err)
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// User is what the bot remembers about a LINE user
+type User struct {
+	// Links maps a service (steam, osu) to the account name on it
+	Links map[string]string `json:"links,omitempty"`
+	// Lang is the language the user chose for the replies, "" for the
+	// default one
+	Lang string `json:"lang,omitempty"`
+	// Updated is when the record last changed
+	Updated time.Time `json:"updated"`
+}
+
+// User returns the record of a LINE user, an empty one when there is none
+func (s *Store) User(userID string) (*User, error) {
+	user := &User{}
+	if err := s.get(usersBucket, userID, user); err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return user, nil
+}
+
+// LinkedAccount returns the account of a LINE user on service, or ""
+func (s *Store) LinkedAccount(userID, service string) (string, error) {
+	user, err := s.User(userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Links[service], nil
+}
+
+// Link remembers the account of a LINE user on service
+func (s *Store) Link(userID, service, account string) error {
+	user := &User{}
+	return s.update(usersBucket, userID, user, func() error {
+		if user.Links == nil {
+			user.Links = make(map[string]string)
+		}
+		user.Links[service] = account
+		user.Updated = time.Now()
+		return nil
+	})
+}
+
+// Unlink forgets the accounts of a LINE user on the given services, or on
+// every service when none is given. It returns the services unlinked.
+func (s *Store) Unlink(userID string, services ...string) ([]string, error) {
+	var removed []string
+	user := &User{}
+	err := s.update(usersBucket, userID, user, func() error {
+		if len(services) == 0 {
+			for service := range user.Links {
+				services = append(services, service)
+			}
+		}
+		for _, service := range services {
+			if _, ok := user.Links[service]; ok {
+				delete(user.Links, service)
+				removed = append(removed, service)
+			}
+		}
+		user.Updated = time.Now()
+		return nil
+	})
+	sort.Strings(removed)
+	return removed, err
+}
+
+// SetUserLanguage remembers the language of a LINE user, "" forgets it
+func (s *Store) SetUserLanguage(userID, lang string) error {
+	user := &User{}
+	return s.update(usersBucket, userID, user, func() error {
+		user.Lang = lang
+		user.Updated = time.Now()
+		return nil
+	})
+}
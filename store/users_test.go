@@ -0,0 +1,59 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	s := openTestStore(t)
+	user, err := s.User("U1")
+	if err != nil || user.Links != nil || user.Lang != "" || !user.Updated.IsZero() {
+		t.Fatalf("got %+v, %v, want an empty user", user, err)
+	}
+	for service, account := range map[string]string{"osu": "cookiezi", "steam": "gaben", "dota": "dendi"} {
+		if err := s.Link("U1", service, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Link("U1", "osu", "WhiteCat"); err != nil {
+		t.Fatal(err)
+	}
+	if account, err := s.LinkedAccount("U1", "osu"); err != nil || account != "WhiteCat" {
+		t.Errorf("got %q, %v, want the last link", account, err)
+	}
+	if account, err := s.LinkedAccount("U2", "osu"); err != nil || account != "" {
+		t.Errorf("got %q, %v for a user without links", account, err)
+	}
+
+	removed, err := s.Unlink("U1", "steam", "kitsu")
+	if err != nil || !reflect.DeepEqual(removed, []string{"steam"}) {
+		t.Errorf("Unlink(steam, kitsu) = %q, %v", removed, err)
+	}
+	removed, err = s.Unlink("U1")
+	if err != nil || !reflect.DeepEqual(removed, []string{"dota", "osu"}) {
+		t.Errorf("Unlink() = %q, %v", removed, err)
+	}
+	if user, _ := s.User("U1"); len(user.Links) != 0 || user.Updated.IsZero() {
+		t.Errorf("got %+v after unlinking everything", user)
+	}
+}
+
+func TestUserLanguage(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Link("U1", "osu", "cookiezi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserLanguage("U1", "ja"); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.User("U1"); user.Lang != "ja" || user.Links["osu"] != "cookiezi" {
+		t.Errorf("got %+v", user)
+	}
+	if err := s.SetUserLanguage("U1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.User("U1"); user.Lang != "" {
+		t.Errorf("the language is still %q", user.Lang)
+	}
+}